@@ -11,6 +11,7 @@ import (
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/arbitrum_types"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
@@ -114,6 +115,17 @@ func createRegisterAPIBackend(backend *Backend, filterConfig filters.Config, fal
 		fallbackClient: fallbackClient,
 	}
 	filterSystem := filters.NewFilterSystem(backend.apiBackend, filterConfig)
+	if backend.config.UserOps.Enable {
+		am := backend.stack.AccountManager()
+		if len(am.Backends(keystore.KeyStoreType)) == 0 {
+			am.AddBackend(keystore.NewKeyStore(backend.stack.KeyStoreDir(), keystore.StandardScryptN, keystore.StandardScryptP))
+		}
+		userOpBackend := &apiUserOpBackend{APIBackend: backend.apiBackend, filterSystem: filterSystem}
+		backend.userOpPool, err = newUserOpPool(userOpBackend, am, &backend.config.UserOps)
+		if err != nil {
+			return nil, err
+		}
+	}
 	backend.stack.RegisterAPIs(backend.apiBackend.GetAPIs(filterSystem))
 	return filterSystem, nil
 }
@@ -143,6 +155,41 @@ func (a *APIBackend) GetAPIs(filterSystem *filters.FilterSystem) []rpc.API {
 		Public:    true,
 	})
 
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewStateDiffAPI(a),
+		Public:    true,
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewStateExportAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewKintoDebugAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "kinto",
+		Version:   "1.0",
+		Service:   NewKintoAPI(a),
+		Public:    true,
+	})
+
+	if pool := a.b.userOpPool; pool != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewUserOperationAPI(pool),
+			Public:    true,
+		})
+	}
+
 	apis = append(apis, rpc.API{
 		Namespace: "net",
 		Version:   "1.0",
@@ -603,10 +650,16 @@ func (a *APIBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) even
 
 // Transaction pool API
 func (a *APIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if err := a.checkKintoAdmission(ctx, signedTx); err != nil {
+		return err
+	}
 	return a.b.EnqueueL2Message(ctx, signedTx, nil)
 }
 
 func (a *APIBackend) SendConditionalTx(ctx context.Context, signedTx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
+	if err := a.checkKintoAdmission(ctx, signedTx); err != nil {
+		return err
+	}
 	return a.b.EnqueueL2Message(ctx, signedTx, options)
 }
 
@@ -655,6 +708,15 @@ func (a *APIBackend) BloomStatus() (uint64, uint64) {
 	return a.b.config.BloomBitsBlocks, sections
 }
 
+// LogIndexRange returns the range of blocks covered by the exact log index, if
+// it is enabled.
+func (a *APIBackend) LogIndexRange() (uint64, uint64, bool) {
+	if a.b.logIndexer == nil {
+		return 0, 0, false
+	}
+	return a.b.logIndexer.Range()
+}
+
 func (a *APIBackend) GetLogs(ctx context.Context, hash common.Hash, number uint64) ([][]*types.Log, error) {
 	return rawdb.ReadLogs(a.ChainDb(), hash, number), nil
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/shutdowncheck"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -29,6 +30,10 @@ type Backend struct {
 
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
+	logIndexer    *core.LogIndexer               // Exact log indexer, nil if not enabled
+	userOpIndexer *core.UserOpIndexer            // User operation indexer, nil if not enabled
+	responseCache *responseCache                 // Cache of finalized RPC results, nil if not enabled
+	userOpPool    *userOpPool                    // ERC-4337 user operation pool, nil if not enabled
 
 	shutdownTracker *shutdowncheck.ShutdownTracker
 
@@ -56,19 +61,38 @@ func NewBackend(stack *node.Node, config *Config, chainDb ethdb.Database, publis
 		chanNewBlock: make(chan struct{}, 1),
 	}
 
-	if len(config.AllowMethod) > 0 {
-		rpcFilter := make(map[string]bool)
-		for _, method := range config.AllowMethod {
-			rpcFilter[method] = true
+	if rules := config.MethodRules(); !rules.Empty() {
+		// The node's own rules for a transport take precedence
+		for _, transport := range []string{node.TransportHTTP, node.TransportWS} {
+			current, err := backend.stack.MethodRules(transport)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !current.Empty() {
+				log.Warn("Ignoring arbitrum method rules for transport with its own rules", "transport", transport)
+				continue
+			}
+			if err := backend.stack.SetMethodRules(transport, rules); err != nil {
+				return nil, nil, err
+			}
 		}
-		backend.stack.ApplyAPIFilter(rpcFilter)
 	}
 
 	backend.bloomIndexer.Start(backend.arb.BlockChain())
+	if config.LogIndex {
+		backend.logIndexer = core.NewLogIndexer(backend.arb.BlockChain())
+	}
+	if config.UserOpIndex {
+		backend.userOpIndexer = core.NewUserOpIndexer(backend.arb.BlockChain())
+	}
 	filterSystem, err := createRegisterAPIBackend(backend, filterConfig, config.ClassicRedirect, config.ClassicRedirectTimeout)
 	if err != nil {
 		return nil, nil, err
 	}
+	if config.ResponseCache.Size > 0 {
+		backend.responseCache = newResponseCache(backend.apiBackend, config.ResponseCache)
+		stack.SetResponseCache(backend.responseCache)
+	}
 	backend.filterSystem = filterSystem
 	return backend, filterSystem, nil
 }
@@ -100,13 +124,28 @@ func (b *Backend) Start() error {
 	b.startBloomHandlers(b.config.BloomBitsBlocks)
 	b.shutdownTracker.MarkStartup()
 	b.shutdownTracker.Start()
+	if b.userOpPool != nil {
+		b.userOpPool.start()
+	}
 
 	return nil
 }
 
 func (b *Backend) Stop() error {
+	if b.userOpPool != nil {
+		b.userOpPool.stop()
+	}
 	b.scope.Close()
 	b.bloomIndexer.Close()
+	if b.logIndexer != nil {
+		b.logIndexer.Close()
+	}
+	if b.userOpIndexer != nil {
+		b.userOpIndexer.Close()
+	}
+	if b.responseCache != nil {
+		b.responseCache.close()
+	}
 	b.shutdownTracker.Stop()
 	b.chainDb.Close()
 	close(b.chanClose)
@@ -4,7 +4,9 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 	flag "github.com/spf13/pflag"
 )
 
@@ -25,9 +27,19 @@ type Config struct {
 	BloomBitsBlocks uint64 `koanf:"bloom-bits-blocks"`
 	BloomConfirms   uint64 `koanf:"bloom-confirms"`
 
+	// LogIndex enables the exact log index, used by the filter system instead
+	// of the bloom bits for the blocks it covers
+	LogIndex bool `koanf:"log-index"`
+
+	// UserOpIndex enables the index of the ERC-4337 user operations by hash,
+	// used by the user operation lookups instead of log queries
+	UserOpIndex bool `koanf:"user-op-index"`
+
 	// Parameters for the filter system
-	FilterLogCacheSize int           `koanf:"filter-log-cache-size"`
-	FilterTimeout      time.Duration `koanf:"filter-timeout"`
+	FilterLogCacheSize  int           `koanf:"filter-log-cache-size"`
+	FilterTimeout       time.Duration `koanf:"filter-timeout"`
+	FilterMaxBlockRange uint64        `koanf:"filter-max-block-range"`
+	FilterMaxLogs       int           `koanf:"filter-max-logs"`
 
 	// FeeHistoryMaxBlockCount limits the number of historical blocks a fee history request may cover
 	FeeHistoryMaxBlockCount uint64 `koanf:"feehistory-max-block-count"`
@@ -39,6 +51,33 @@ type Config struct {
 	MaxRecreateStateDepth  int64         `koanf:"max-recreate-state-depth"`
 
 	AllowMethod []string `koanf:"allow-method"`
+	DenyMethod  []string `koanf:"deny-method"`
+
+	ResponseCache ResponseCacheConfig `koanf:"response-cache"`
+
+	UserOps UserOpConfig `koanf:"user-ops"`
+}
+
+// MethodRules returns the rules selecting the methods served over HTTP and WS.
+func (c *Config) MethodRules() rpc.MethodRules {
+	return rpc.MethodRules{Allow: c.AllowMethod, Deny: c.DenyMethod}
+}
+
+// FilterConfig returns the configuration of the log filter system.
+func (c *Config) FilterConfig() filters.Config {
+	return filters.Config{
+		LogCacheSize:  c.FilterLogCacheSize,
+		Timeout:       c.FilterTimeout,
+		MaxBlockRange: c.FilterMaxBlockRange,
+		MaxLogs:       c.FilterMaxLogs,
+	}
+}
+
+// ResponseCacheConfig configures the cache of the RPC results which depend only
+// on finalized blocks.
+type ResponseCacheConfig struct {
+	Size    int    `koanf:"size"`    // megabytes, zero disables the cache
+	Journal string `koanf:"journal"` // directory persisting the cache across restarts
 }
 
 type ArbDebugConfig struct {
@@ -56,10 +95,27 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Uint64(prefix+".feehistory-max-block-count", DefaultConfig.FeeHistoryMaxBlockCount, "max number of blocks a fee history request may cover")
 	f.String(prefix+".classic-redirect", DefaultConfig.ClassicRedirect, "url to redirect classic requests, use \"error:[CODE:]MESSAGE\" to return specified error instead of redirecting")
 	f.Duration(prefix+".classic-redirect-timeout", DefaultConfig.ClassicRedirectTimeout, "timeout for forwarded classic requests, where 0 = no timeout")
+	f.Bool(prefix+".log-index", DefaultConfig.LogIndex, "maintain an exact index of log addresses and topics, used by log queries instead of bloom bits (backfill older blocks with 'geth db index-logs')")
+	f.Bool(prefix+".user-op-index", DefaultConfig.UserOpIndex, "maintain an index of the ERC-4337 user operations by hash (backfill older blocks with 'geth db index-userops')")
 	f.Int(prefix+".filter-log-cache-size", DefaultConfig.FilterLogCacheSize, "log filter system maximum number of cached blocks")
 	f.Duration(prefix+".filter-timeout", DefaultConfig.FilterTimeout, "log filter system maximum time filters stay active")
+	f.Uint64(prefix+".filter-max-block-range", DefaultConfig.FilterMaxBlockRange, "maximum number of blocks a log query may span (0=unlimited)")
+	f.Int(prefix+".filter-max-logs", DefaultConfig.FilterMaxLogs, "maximum number of logs a log query may return (0=unlimited)")
 	f.Int64(prefix+".max-recreate-state-depth", DefaultConfig.MaxRecreateStateDepth, "maximum depth for recreating state, measured in l2 gas (0=don't recreate state, -1=infinite, -2=use default value for archive or non-archive node (whichever is configured))")
-	f.StringSlice(prefix+".allow-method", DefaultConfig.AllowMethod, "list of whitelisted rpc methods")
+	f.StringSlice(prefix+".allow-method", DefaultConfig.AllowMethod, "list of whitelisted rpc methods and subscription names (e.g. eth_newHeads), '*' matches any characters (e.g. debug_*)")
+	f.StringSlice(prefix+".deny-method", DefaultConfig.DenyMethod, "list of blacklisted rpc methods, taking precedence over the whitelist, '*' matches any characters")
+	f.Int(prefix+".response-cache.size", DefaultConfig.ResponseCache.Size, "size in megabytes of the cache of rpc results on finalized blocks (0=disabled)")
+	f.String(prefix+".response-cache.journal", DefaultConfig.ResponseCache.Journal, "directory persisting the rpc response cache across restarts (empty=in-memory only)")
+	userOps := DefaultConfig.UserOps
+	f.Bool(prefix+".user-ops.enable", userOps.Enable, "enable the ERC-4337 user operation pool and the eth_sendUserOperation family of rpc methods")
+	f.String(prefix+".user-ops.bundler-account", userOps.BundlerAccount, "address of the keystore account submitting the user operation bundles and receiving their fees")
+	f.String(prefix+".user-ops.bundler-password-file", userOps.BundlerPasswordFile, "file holding the password of the bundler account (empty if the account is unlocked)")
+	f.Duration(prefix+".user-ops.bundle-interval", userOps.BundleInterval, "interval at which pending user operations are bundled")
+	f.Int(prefix+".user-ops.max-bundle-size", userOps.MaxBundleSize, "maximum number of user operations in a bundle")
+	f.Int(prefix+".user-ops.max-ops-per-sender", userOps.MaxOpsPerSender, "maximum number of pending user operations of a sender")
+	f.Int(prefix+".user-ops.max-pool-size", userOps.MaxPoolSize, "maximum number of pending user operations")
+	f.Uint64(prefix+".user-ops.log-lookback", userOps.LogLookback, "number of recent blocks searched for included user operations")
+	f.Bool(prefix+".user-ops.unsafe", userOps.Unsafe, "run the user operation pool without the ERC-7562 validation rules, the bundler paying for the bundles reverted by operations invalidated after their validation (required to enable the pool)")
 	arbDebug := DefaultConfig.ArbDebug
 	f.Uint64(prefix+".arbdebug.block-range-bound", arbDebug.BlockRangeBound, "bounds the number of blocks arbdebug calls may return")
 	f.Uint64(prefix+".arbdebug.timeout-queue-bound", arbDebug.TimeoutQueueBound, "bounds the length of timeout queues arbdebug calls may return")
@@ -79,14 +135,28 @@ var DefaultConfig = Config{
 	RPCEVMTimeout:           ethconfig.Defaults.RPCEVMTimeout, // 5 seconds
 	BloomBitsBlocks:         params.BloomBitsBlocks * 4,       // we generally have smaller blocks
 	BloomConfirms:           params.BloomConfirms,
+	LogIndex:                false,
+	UserOpIndex:             false,
 	FilterLogCacheSize:      32,
 	FilterTimeout:           5 * time.Minute,
+	FilterMaxBlockRange:     0,
+	FilterMaxLogs:           0,
 	FeeHistoryMaxBlockCount: 1024,
 	ClassicRedirect:         "",
 	MaxRecreateStateDepth:   UninitializedMaxRecreateStateDepth, // default value should be set for depending on node type (archive / non-archive)
 	AllowMethod:             []string{},
+	DenyMethod:              []string{},
 	ArbDebug: ArbDebugConfig{
 		BlockRangeBound:   256,
 		TimeoutQueueBound: 512,
 	},
+	UserOps: UserOpConfig{
+		Enable:          false,
+		BundleInterval:  time.Second,
+		MaxBundleSize:   16,
+		MaxOpsPerSender: 4,
+		MaxPoolSize:     4096,
+		LogLookback:     10000,
+		Unsafe:          false,
+	},
 }
@@ -0,0 +1,171 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errcodeKintoRejected is the JSON-RPC error code of the transactions rejected
+// by the Kinto rules. The "transaction rejected" code of EIP-1474, -32003, is
+// already the one of the responses too large and of the Arbitrum rejections.
+const errcodeKintoRejected = -32010
+
+// KintoRejectedError is the RPC error of a transaction rejected by the Kinto
+// rules at submission.
+type KintoRejectedError struct {
+	*core.KintoRuleError
+}
+
+// KintoRejection is the data of a KintoRejectedError.
+type KintoRejection struct {
+	Rule        string          `json:"rule"`
+	Hardfork    int             `json:"hardfork"`
+	Destination *common.Address `json:"destination"`
+}
+
+func (e *KintoRejectedError) ErrorCode() int { return errcodeKintoRejected }
+
+func (e *KintoRejectedError) ErrorData() interface{} {
+	return &KintoRejection{
+		Rule:        e.Rule,
+		Hardfork:    e.Hardfork,
+		Destination: e.Destination,
+	}
+}
+
+// checkKintoAdmission rejects the transactions the Kinto rules would reject in
+// the next block, checked against the latest state with the irregular state
+// changes of the next block applied. The transactions that can't be checked are
+// let through, the rules being enforced again at execution.
+func (a *APIBackend) checkKintoAdmission(ctx context.Context, tx *types.Transaction) error {
+	config := a.ChainConfig()
+	head := a.CurrentHeader()
+	next := new(big.Int).Add(head.Number, common.Big1)
+	if !config.Kinto().IsKintoRules(next) {
+		return nil
+	}
+	statedb, header, err := a.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		log.Warn("Kinto admission check skipped, state unavailable", "hash", tx.Hash(), "err", err)
+		return nil
+	}
+	core.ApplyIrregularStateChanges(config, next, statedb)
+	signer := types.MakeSigner(config, next, header.Time)
+	msg, err := core.TransactionToMessage(tx, signer, header.BaseFee, core.MessageCommitMode)
+	if err != nil {
+		// Invalid transactions are rejected when enqueued
+		return nil
+	}
+	blockCtx := core.NewEVMBlockContext(header, a.BlockChain(), nil)
+	blockCtx.BlockNumber = next
+	evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, config, vm.Config{NoBaseFee: true})
+
+	err = core.CheckKintoTransaction(evm, msg)
+	var rejection *core.KintoRuleError
+	if errors.As(err, &rejection) {
+		return &KintoRejectedError{rejection}
+	}
+	if err != nil {
+		log.Warn("Kinto admission check failed", "hash", tx.Hash(), "err", err)
+	}
+	return nil
+}
+
+// KintoAPI serves the kinto namespace, explaining the Kinto rules to wallets
+// and dApps before they sign transactions.
+type KintoAPI struct {
+	b *APIBackend
+}
+
+func NewKintoAPI(b *APIBackend) *KintoAPI {
+	return &KintoAPI{b}
+}
+
+// KintoCheckResult is the result of kinto_checkTransaction.
+type KintoCheckResult struct {
+	Block              hexutil.Uint64         `json:"block"`
+	RulesActive        bool                   `json:"rulesActive"`
+	Hardfork           int                    `json:"hardfork"`
+	Destination        *common.Address        `json:"destination"`
+	DestinationAllowed *bool                  `json:"destinationAllowed,omitempty"`
+	FunctionSelector   string                 `json:"functionSelector,omitempty"`
+	FunctionAllowed    bool                   `json:"functionAllowed"`
+	AppRegistry        *KintoAppRegistryCheck `json:"appRegistry,omitempty"`
+	Allowed            bool                   `json:"allowed"`
+	Rule               string                 `json:"rule,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+}
+
+// KintoAppRegistryCheck is the verdict of the AppRegistry in a KintoCheckResult.
+type KintoAppRegistryCheck struct {
+	Allowed    bool          `json:"allowed"`
+	ReturnData hexutil.Bytes `json:"returnData"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// CheckTransaction reports how the Kinto rules of the block following the given
+// one apply to the transaction. Unlike eth_call and eth_estimateGas, which skip
+// the rules, it reports the rule a transaction would be rejected by once
+// submitted.
+//
+// The transaction is checked as the first one of the following block: against
+// the state of the given block, with the irregular state changes of the
+// following block applied. For a historical block, the transactions included
+// before it in the following block are not accounted, and the AppRegistry may
+// have answered differently on chain.
+func (api *KintoAPI) CheckTransaction(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*KintoCheckResult, error) {
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	statedb, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	msg, err := args.ToMessage(api.b.RPCGasCap(), header, statedb, core.MessageCommitMode)
+	if err != nil {
+		return nil, err
+	}
+	blockCtx := core.NewEVMBlockContext(header, api.b.BlockChain(), nil)
+	blockCtx.BlockNumber = new(big.Int).Add(header.Number, common.Big1)
+	core.ApplyIrregularStateChanges(api.b.ChainConfig(), blockCtx.BlockNumber, statedb)
+	evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, api.b.ChainConfig(), vm.Config{NoBaseFee: true})
+
+	exp := core.ExplainKintoTransaction(evm, msg)
+	result := &KintoCheckResult{
+		Block:              hexutil.Uint64(blockCtx.BlockNumber.Uint64()),
+		RulesActive:        exp.RulesActive,
+		Hardfork:           exp.Hardfork,
+		Destination:        msg.To,
+		DestinationAllowed: exp.DestinationAllowed,
+		FunctionAllowed:    exp.FunctionAllowed,
+		Allowed:            exp.Err == nil,
+	}
+	if exp.FunctionSelector != "" {
+		result.FunctionSelector = "0x" + exp.FunctionSelector
+	}
+	if verdict := exp.AppRegistry; verdict != nil {
+		result.AppRegistry = &KintoAppRegistryCheck{Allowed: verdict.Allowed, ReturnData: verdict.ReturnData}
+		if verdict.Err != nil {
+			result.AppRegistry.Error = verdict.Err.Error()
+		}
+	}
+	if exp.Err != nil {
+		result.Reason = exp.Err.Error()
+		var rejection *core.KintoRuleError
+		if errors.As(exp.Err, &rejection) {
+			result.Rule = rejection.Rule
+		}
+	}
+	return result, nil
+}
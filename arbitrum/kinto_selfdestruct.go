@@ -0,0 +1,119 @@
+package arbitrum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxSelfDestructRedirectRange is the maximum number of blocks replayed by
+// debug_kintoSelfDestructRedirects.
+const maxSelfDestructRedirectRange = 1000
+
+// KintoDebugAPI provides the Kinto methods replaying blocks, which are too
+// costly for the public kinto namespace.
+type KintoDebugAPI struct {
+	b *APIBackend
+}
+
+func NewKintoDebugAPI(b *APIBackend) *KintoDebugAPI {
+	return &KintoDebugAPI{b}
+}
+
+// KintoSelfDestructRedirect is a selfdestruct whose balance was sent to the
+// SelfDestructWallet by the Kinto rules instead of the requested beneficiary.
+type KintoSelfDestructRedirect struct {
+	BlockNumber          hexutil.Uint64 `json:"blockNumber"`
+	BlockHash            common.Hash    `json:"blockHash"`
+	TransactionHash      common.Hash    `json:"transactionHash"`
+	TransactionIndex     hexutil.Uint   `json:"transactionIndex"`
+	Contract             common.Address `json:"contract"`
+	RequestedBeneficiary common.Address `json:"requestedBeneficiary"`
+	Beneficiary          common.Address `json:"beneficiary"`
+	Value                *hexutil.Big   `json:"value"`
+}
+
+// KintoSelfDestructRedirects lists the selfdestructs of the given block range
+// whose balance was redirected to the SelfDestructWallet, tracing the blocks
+// with the kintoSelfDestructTracer. The selfdestructs of reverted calls are
+// left out, their balance having not moved.
+func (api *KintoDebugAPI) KintoSelfDestructRedirects(ctx context.Context, fromBlock, toBlock rpc.BlockNumber) ([]*KintoSelfDestructRedirect, error) {
+	from, err := api.b.blockNumberToUint(ctx, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.b.blockNumberToUint(ctx, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	if to-from >= maxSelfDestructRedirectRange {
+		return nil, fmt.Errorf("block range %d-%d exceeds the maximum of %d blocks", from, to, maxSelfDestructRedirectRange)
+	}
+	if head := api.b.CurrentBlock().Number.Uint64(); to > head {
+		to = head
+	}
+	// The balances are redirected from hardfork 2 on
+	if first := api.b.ChainConfig().Kinto().Hardfork2Block + 1; from < first {
+		from = first
+	}
+	redirects := []*KintoSelfDestructRedirect{}
+	for number := from; number <= to; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := api.b.BlockChain().GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		if len(block.Transactions()) == 0 {
+			continue
+		}
+		blockRedirects, err := api.selfDestructRedirects(ctx, block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay block #%d: %w", number, err)
+		}
+		redirects = append(redirects, blockRedirects...)
+	}
+	return redirects, nil
+}
+
+// selfDestructRedirects traces the block with the kintoSelfDestructTracer and
+// returns its redirected selfdestructs.
+func (api *KintoDebugAPI) selfDestructRedirects(ctx context.Context, block *types.Block) ([]*KintoSelfDestructRedirect, error) {
+	tracer := "kintoSelfDestructTracer"
+	results, err := tracers.NewAPI(api.b).TraceBlockByHash(ctx, block.Hash(), &tracers.TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	var redirects []*KintoSelfDestructRedirect
+	for i, result := range results {
+		if result.Error != "" {
+			return nil, fmt.Errorf("transaction %x failed: %s", result.TxHash, result.Error)
+		}
+		encoded, err := json.Marshal(result.Result)
+		if err != nil {
+			return nil, err
+		}
+		var txRedirects []*KintoSelfDestructRedirect
+		if err := json.Unmarshal(encoded, &txRedirects); err != nil {
+			return nil, err
+		}
+		for _, redirect := range txRedirects {
+			redirect.BlockNumber = hexutil.Uint64(block.NumberU64())
+			redirect.BlockHash = block.Hash()
+			redirect.TransactionHash = result.TxHash
+			redirect.TransactionIndex = hexutil.Uint(i)
+		}
+		redirects = append(redirects, txRedirects...)
+	}
+	return redirects, nil
+}
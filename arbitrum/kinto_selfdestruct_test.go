@@ -0,0 +1,23 @@
+package arbitrum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestKintoSelfDestructRedirectsRange(t *testing.T) {
+	api := NewKintoDebugAPI(&APIBackend{})
+	for _, tt := range []struct {
+		from, to rpc.BlockNumber
+	}{
+		{from: 5, to: 4},
+		{from: 0, to: maxSelfDestructRedirectRange},
+		{from: 1, to: 1 + maxSelfDestructRedirectRange},
+	} {
+		if _, err := api.KintoSelfDestructRedirects(context.Background(), tt.from, tt.to); err == nil {
+			t.Errorf("range %d-%d accepted", tt.from, tt.to)
+		}
+	}
+}
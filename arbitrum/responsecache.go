@@ -0,0 +1,199 @@
+package arbitrum
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"runtime"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	responseCacheHitMeter         = metrics.NewRegisteredMeter("arb/rpc/cache/hit", nil)
+	responseCacheMissMeter        = metrics.NewRegisteredMeter("arb/rpc/cache/miss", nil)
+	responseCacheInvalidatedMeter = metrics.NewRegisteredMeter("arb/rpc/cache/invalidated", nil)
+)
+
+// responseCacheMethods are the methods whose results the response cache stores,
+// once the block they depend on is finalized.
+var responseCacheMethods = map[string]bool{
+	"eth_getBlockByNumber":      true,
+	"eth_getBlockByHash":        true,
+	"eth_getTransactionByHash":  true,
+	"eth_getTransactionReceipt": true,
+	"eth_getLogs":               true,
+	"debug_traceTransaction":    true,
+	"debug_traceBlockByNumber":  true,
+	"debug_traceBlockByHash":    true,
+}
+
+// responseCache is an rpc.ResponseCache storing the results of the calls which
+// depend only on finalized blocks. Every entry records the block it depends on,
+// and is dropped when served if that block was reorged out meanwhile.
+type responseCache struct {
+	backend *APIBackend
+	cache   *fastcache.Cache
+	journal string
+}
+
+func newResponseCache(backend *APIBackend, config ResponseCacheConfig) *responseCache {
+	c := &responseCache{backend: backend, journal: config.Journal}
+	if config.Journal != "" {
+		c.cache = fastcache.LoadFromFileOrNew(config.Journal, config.Size*1024*1024)
+	} else {
+		c.cache = fastcache.New(config.Size * 1024 * 1024)
+	}
+	return c
+}
+
+// Cacheable implements rpc.ResponseCache.
+func (c *responseCache) Cacheable(method string) bool {
+	return responseCacheMethods[method]
+}
+
+// Get implements rpc.ResponseCache.
+func (c *responseCache) Get(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, bool) {
+	key := append([]byte(method), params...)
+	enc := c.cache.GetBig(nil, key)
+	if len(enc) < 8+common.HashLength {
+		responseCacheMissMeter.Mark(1)
+		return nil, false
+	}
+	number, hash := binary.BigEndian.Uint64(enc), common.BytesToHash(enc[8:8+common.HashLength])
+	if c.backend.BlockChain().GetCanonicalHash(number) != hash {
+		// The block was reorged out, deeper than finality
+		c.cache.Del(key)
+		responseCacheInvalidatedMeter.Mark(1)
+		responseCacheMissMeter.Mark(1)
+		return nil, false
+	}
+	responseCacheHitMeter.Mark(1)
+	return enc[8+common.HashLength:], true
+}
+
+// Put implements rpc.ResponseCache.
+func (c *responseCache) Put(ctx context.Context, method string, params json.RawMessage, result json.RawMessage) {
+	header := c.dependency(ctx, method, params, result)
+	if header == nil {
+		return
+	}
+	finalized, err := c.backend.blockNumberToUint(ctx, rpc.FinalizedBlockNumber)
+	if err != nil || header.Number.Uint64() > finalized {
+		return
+	}
+	if c.backend.BlockChain().GetCanonicalHash(header.Number.Uint64()) != header.Hash() {
+		return
+	}
+	enc := make([]byte, 8+common.HashLength+len(result))
+	binary.BigEndian.PutUint64(enc, header.Number.Uint64())
+	copy(enc[8:], header.Hash().Bytes())
+	copy(enc[8+common.HashLength:], result)
+	c.cache.SetBig(append([]byte(method), params...), enc)
+}
+
+// dependency returns the header of the block the result of the call depends on,
+// or nil if the result can't be cached.
+func (c *responseCache) dependency(ctx context.Context, method string, params json.RawMessage, result json.RawMessage) *types.Header {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil
+	}
+	chain := c.backend.BlockChain()
+	switch method {
+	case "eth_getBlockByNumber":
+		// Block tags fail to decode, the block they resolve to changes
+		var number hexutil.Uint64
+		if err := json.Unmarshal(args[0], &number); err != nil {
+			return nil
+		}
+		var block struct {
+			Hash *common.Hash `json:"hash"`
+		}
+		if err := json.Unmarshal(result, &block); err != nil || block.Hash == nil {
+			return nil
+		}
+		header := chain.GetHeaderByNumber(uint64(number))
+		if header == nil || header.Hash() != *block.Hash {
+			return nil
+		}
+		return header
+
+	case "eth_getBlockByHash":
+		var hash common.Hash
+		if err := json.Unmarshal(args[0], &hash); err != nil {
+			return nil
+		}
+		return chain.GetHeaderByHash(hash)
+
+	case "eth_getTransactionByHash", "eth_getTransactionReceipt":
+		var tx struct {
+			BlockHash *common.Hash `json:"blockHash"`
+		}
+		if err := json.Unmarshal(result, &tx); err != nil || tx.BlockHash == nil {
+			return nil
+		}
+		return chain.GetHeaderByHash(*tx.BlockHash)
+
+	case "eth_getLogs":
+		var crit struct {
+			BlockHash *common.Hash    `json:"blockHash"`
+			FromBlock *hexutil.Uint64 `json:"fromBlock"`
+			ToBlock   *hexutil.Uint64 `json:"toBlock"`
+		}
+		// Block tags fail to decode, their ranges are not fixed
+		if err := json.Unmarshal(args[0], &crit); err != nil {
+			return nil
+		}
+		if crit.BlockHash != nil {
+			return chain.GetHeaderByHash(*crit.BlockHash)
+		}
+		if crit.FromBlock == nil || crit.ToBlock == nil {
+			return nil
+		}
+		// The hash of the last block commits to the whole range
+		return chain.GetHeaderByNumber(uint64(*crit.ToBlock))
+
+	case "debug_traceTransaction":
+		var hash common.Hash
+		if err := json.Unmarshal(args[0], &hash); err != nil {
+			return nil
+		}
+		found, _, blockHash, _, _, err := c.backend.GetTransaction(ctx, hash)
+		if err != nil || !found {
+			return nil
+		}
+		return chain.GetHeaderByHash(blockHash)
+
+	case "debug_traceBlockByNumber":
+		var number hexutil.Uint64
+		if err := json.Unmarshal(args[0], &number); err != nil {
+			return nil
+		}
+		return chain.GetHeaderByNumber(uint64(number))
+
+	case "debug_traceBlockByHash":
+		var hash common.Hash
+		if err := json.Unmarshal(args[0], &hash); err != nil {
+			return nil
+		}
+		return chain.GetHeaderByHash(hash)
+	}
+	return nil
+}
+
+// close persists the cache to its journal, if configured.
+func (c *responseCache) close() {
+	if c.journal == "" {
+		return
+	}
+	if err := c.cache.SaveToFileConcurrent(c.journal, runtime.GOMAXPROCS(0)); err != nil {
+		log.Warn("Failed to persist RPC response cache", "journal", c.journal, "err", err)
+	}
+}
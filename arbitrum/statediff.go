@@ -0,0 +1,68 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var errStateDiffNotFound = errors.New("state diff not found, it was either not recorded or already pruned")
+
+// StateDiffAPI serves the per-block state changesets recorded by the
+// blockchain when CacheConfig.StateChangesets is enabled.
+type StateDiffAPI struct {
+	b *APIBackend
+}
+
+func NewStateDiffAPI(b *APIBackend) *StateDiffAPI {
+	return &StateDiffAPI{b}
+}
+
+// GetStateDiff returns the accounts and storage slots modified by the given block.
+func (s *StateDiffAPI) GetStateDiff(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.StateChangeset, error) {
+	header, err := s.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("block not found")
+	}
+	changeset := s.b.BlockChain().GetStateChangeset(header.Hash(), header.Number.Uint64())
+	if changeset == nil {
+		return nil, errStateDiffNotFound
+	}
+	return changeset, nil
+}
+
+// StateDiffs creates a subscription that fires with the state changeset of
+// every new canonical block.
+func (s *StateDiffAPI) StateDiffs(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		changesets := make(chan core.StateChangesetEvent, 16)
+		changesetsSub := s.b.BlockChain().SubscribeStateChangesetEvent(changesets)
+		defer changesetsSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-changesets:
+				notifier.Notify(rpcSub.ID, ev.Changeset)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
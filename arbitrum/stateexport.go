@@ -0,0 +1,88 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	defaultStateChunkSize = 4 * 1024 * 1024
+	maxStateChunkSize     = 64 * 1024 * 1024
+)
+
+// StateExportAPI streams the state of a block in the binary export format of
+// core/state/snapshot, one chunk per call.
+type StateExportAPI struct {
+	b *APIBackend
+}
+
+func NewStateExportAPI(b *APIBackend) *StateExportAPI {
+	return &StateExportAPI{b}
+}
+
+// StateChunk is a chunk of an exported state.
+type StateChunk struct {
+	Root     common.Hash            `json:"root"`
+	Data     hexutil.Bytes          `json:"data"`
+	Hash     common.Hash            `json:"hash"` // keccak256 of data
+	Accounts uint64                 `json:"accounts"`
+	Slots    uint64                 `json:"slots"`
+	Codes    uint64                 `json:"codes"`
+	Next     *snapshot.ExportCursor `json:"next"` // nil once the whole state was exported
+}
+
+// ExportStateChunk exports the state of the given block starting at the cursor,
+// or at the first account if none is given, into a chunk of roughly maxBytes,
+// 4 MiB if not given. A zero maxBytes is rejected. The state must be covered by
+// the snapshot. Contract codes are only deduplicated within a chunk.
+func (s *StateExportAPI) ExportStateChunk(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, start *snapshot.ExportCursor, maxBytes *hexutil.Uint64) (*StateChunk, error) {
+	limit := uint64(defaultStateChunkSize)
+	if maxBytes != nil {
+		limit = uint64(*maxBytes)
+	}
+	if limit == 0 {
+		return nil, errors.New("zero chunk size")
+	}
+	if limit > maxStateChunkSize {
+		return nil, fmt.Errorf("chunk size %d exceeds the maximum of %d", limit, maxStateChunkSize)
+	}
+	header, err := s.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("block not found")
+	}
+	snaps := s.b.BlockChain().Snapshots()
+	if snaps == nil {
+		return nil, errors.New("state snapshot is not enabled")
+	}
+	if snaps.Snapshot(header.Root) == nil {
+		return nil, fmt.Errorf("state %x is not covered by the snapshot", header.Root)
+	}
+	if start == nil {
+		start = new(snapshot.ExportCursor)
+	}
+	var buf bytes.Buffer
+	next, stats, err := snapshot.NewStateExporter(snaps, header.Root, s.b.ChainDb()).ExportChunk(&buf, *start, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &StateChunk{
+		Root:     header.Root,
+		Data:     buf.Bytes(),
+		Hash:     crypto.Keccak256Hash(buf.Bytes()),
+		Accounts: stats.Accounts,
+		Slots:    stats.Slots,
+		Codes:    stats.Codes,
+		Next:     next,
+	}, nil
+}
@@ -0,0 +1,18 @@
+package arbitrum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestExportStateChunkSize(t *testing.T) {
+	api := NewStateExportAPI(&APIBackend{})
+	for _, size := range []hexutil.Uint64{0, maxStateChunkSize + 1} {
+		if _, err := api.ExportStateChunk(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, &size); err == nil {
+			t.Errorf("chunk size %d accepted", size)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package arbitrum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// UserOperation is an ERC-4337 user operation, in the RPC format of the
+// EntryPoint it's sent to: v0.6 operations have initCode and paymasterAndData,
+// v0.7 ones have them split in factory and paymaster fields.
+type UserOperation struct {
+	Sender                        common.Address  `json:"sender"`
+	Nonce                         *hexutil.Big    `json:"nonce"`
+	InitCode                      hexutil.Bytes   `json:"initCode,omitempty"`
+	Factory                       *common.Address `json:"factory,omitempty"`
+	FactoryData                   hexutil.Bytes   `json:"factoryData,omitempty"`
+	CallData                      hexutil.Bytes   `json:"callData"`
+	CallGasLimit                  *hexutil.Big    `json:"callGasLimit"`
+	VerificationGasLimit          *hexutil.Big    `json:"verificationGasLimit"`
+	PreVerificationGas            *hexutil.Big    `json:"preVerificationGas"`
+	MaxFeePerGas                  *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas          *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	PaymasterAndData              hexutil.Bytes   `json:"paymasterAndData,omitempty"`
+	Paymaster                     *common.Address `json:"paymaster,omitempty"`
+	PaymasterVerificationGasLimit *hexutil.Big    `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       *hexutil.Big    `json:"paymasterPostOpGasLimit,omitempty"`
+	PaymasterData                 hexutil.Bytes   `json:"paymasterData,omitempty"`
+	Signature                     hexutil.Bytes   `json:"signature"`
+}
+
+// EntryPoint versions of the user operations.
+const (
+	entryPointV6 = 6
+	entryPointV7 = 7
+)
+
+// validate checks the fields of the user operation required by the EntryPoint
+// version are present.
+func (op *UserOperation) validate(version int) error {
+	switch {
+	case op.Nonce == nil:
+		return errors.New("missing nonce")
+	case op.CallGasLimit == nil, op.VerificationGasLimit == nil, op.PreVerificationGas == nil:
+		return errors.New("missing gas limits")
+	case op.MaxFeePerGas == nil, op.MaxPriorityFeePerGas == nil:
+		return errors.New("missing gas fees")
+	case op.MaxPriorityFeePerGas.ToInt().Cmp(op.MaxFeePerGas.ToInt()) > 0:
+		return errors.New("maxPriorityFeePerGas above maxFeePerGas")
+	}
+	if version == entryPointV6 {
+		if op.Factory != nil || op.Paymaster != nil {
+			return errors.New("factory and paymaster fields are not supported by EntryPoint v0.6, use initCode and paymasterAndData")
+		}
+		return nil
+	}
+	if len(op.InitCode) > 0 || len(op.PaymasterAndData) > 0 {
+		return errors.New("initCode and paymasterAndData are not supported by EntryPoint v0.7, use the factory and paymaster fields")
+	}
+	for _, limit := range []*hexutil.Big{op.CallGasLimit, op.VerificationGasLimit, op.PaymasterVerificationGasLimit, op.PaymasterPostOpGasLimit, op.MaxFeePerGas, op.MaxPriorityFeePerGas} {
+		if limit != nil && limit.ToInt().BitLen() > 128 {
+			return errors.New("gas limits and fees must fit in 128 bits")
+		}
+	}
+	return nil
+}
+
+// initCode returns the code deploying the sender.
+func (op *UserOperation) initCode() []byte {
+	if op.Factory == nil {
+		return op.InitCode
+	}
+	return append(op.Factory.Bytes(), op.FactoryData...)
+}
+
+// paymasterAndData returns the paymaster of the user operation with its data.
+func (op *UserOperation) paymasterAndData() []byte {
+	if op.Paymaster == nil {
+		return op.PaymasterAndData
+	}
+	data := op.Paymaster.Bytes()
+	data = append(data, common.LeftPadBytes(bigOrZero(op.PaymasterVerificationGasLimit).Bytes(), 16)...)
+	data = append(data, common.LeftPadBytes(bigOrZero(op.PaymasterPostOpGasLimit).Bytes(), 16)...)
+	return append(data, op.PaymasterData...)
+}
+
+// paymaster returns the address of the paymaster, zero if there is none.
+func (op *UserOperation) paymaster() common.Address {
+	if data := op.paymasterAndData(); len(data) >= common.AddressLength {
+		return common.BytesToAddress(data[:common.AddressLength])
+	}
+	return common.Address{}
+}
+
+// packUint128s packs two 128 bits values in a word.
+func packUint128s(high, low *hexutil.Big) [32]byte {
+	var word [32]byte
+	copy(word[:16], common.LeftPadBytes(bigOrZero(high).Bytes(), 16))
+	copy(word[16:], common.LeftPadBytes(bigOrZero(low).Bytes(), 16))
+	return word
+}
+
+// userOpV6 is a user operation as encoded by EntryPoint v0.6.
+type userOpV6 struct {
+	Sender               common.Address
+	Nonce                *big.Int
+	InitCode             []byte
+	CallData             []byte
+	CallGasLimit         *big.Int
+	VerificationGasLimit *big.Int
+	PreVerificationGas   *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	PaymasterAndData     []byte
+	Signature            []byte
+}
+
+// userOpV7 is a user operation as encoded by EntryPoint v0.7.
+type userOpV7 struct {
+	Sender             common.Address
+	Nonce              *big.Int
+	InitCode           []byte
+	CallData           []byte
+	AccountGasLimits   [32]byte
+	PreVerificationGas *big.Int
+	GasFees            [32]byte
+	PaymasterAndData   []byte
+	Signature          []byte
+}
+
+func (op *UserOperation) packV6() userOpV6 {
+	return userOpV6{
+		Sender:               op.Sender,
+		Nonce:                op.Nonce.ToInt(),
+		InitCode:             op.initCode(),
+		CallData:             op.CallData,
+		CallGasLimit:         op.CallGasLimit.ToInt(),
+		VerificationGasLimit: op.VerificationGasLimit.ToInt(),
+		PreVerificationGas:   op.PreVerificationGas.ToInt(),
+		MaxFeePerGas:         op.MaxFeePerGas.ToInt(),
+		MaxPriorityFeePerGas: op.MaxPriorityFeePerGas.ToInt(),
+		PaymasterAndData:     op.paymasterAndData(),
+		Signature:            op.Signature,
+	}
+}
+
+func (op *UserOperation) packV7() userOpV7 {
+	return userOpV7{
+		Sender:             op.Sender,
+		Nonce:              op.Nonce.ToInt(),
+		InitCode:           op.initCode(),
+		CallData:           op.CallData,
+		AccountGasLimits:   packUint128s(op.VerificationGasLimit, op.CallGasLimit),
+		PreVerificationGas: op.PreVerificationGas.ToInt(),
+		GasFees:            packUint128s(op.MaxPriorityFeePerGas, op.MaxFeePerGas),
+		PaymasterAndData:   op.paymasterAndData(),
+		Signature:          op.Signature,
+	}
+}
+
+// Hash returns the hash of the user operation sent to the EntryPoint of the
+// given version on the chain, as computed by EntryPoint.getUserOpHash.
+func (op *UserOperation) Hash(entryPoint common.Address, version int, chainID *big.Int) common.Hash {
+	var fields [][]byte
+	switch version {
+	case entryPointV6:
+		fields = [][]byte{
+			common.LeftPadBytes(op.Sender.Bytes(), 32),
+			math256(op.Nonce),
+			crypto.Keccak256(op.initCode()),
+			crypto.Keccak256(op.CallData),
+			math256(op.CallGasLimit),
+			math256(op.VerificationGasLimit),
+			math256(op.PreVerificationGas),
+			math256(op.MaxFeePerGas),
+			math256(op.MaxPriorityFeePerGas),
+			crypto.Keccak256(op.paymasterAndData()),
+		}
+	default:
+		accountGasLimits, gasFees := packUint128s(op.VerificationGasLimit, op.CallGasLimit), packUint128s(op.MaxPriorityFeePerGas, op.MaxFeePerGas)
+		fields = [][]byte{
+			common.LeftPadBytes(op.Sender.Bytes(), 32),
+			math256(op.Nonce),
+			crypto.Keccak256(op.initCode()),
+			crypto.Keccak256(op.CallData),
+			accountGasLimits[:],
+			math256(op.PreVerificationGas),
+			gasFees[:],
+			crypto.Keccak256(op.paymasterAndData()),
+		}
+	}
+	return crypto.Keccak256Hash(
+		crypto.Keccak256(fields...),
+		common.LeftPadBytes(entryPoint.Bytes(), 32),
+		common.LeftPadBytes(chainID.Bytes(), 32),
+	)
+}
+
+// userOpFromV6 converts a user operation decoded from a v0.6 handleOps call.
+func userOpFromV6(op userOpV6) *UserOperation {
+	return &UserOperation{
+		Sender:               op.Sender,
+		Nonce:                (*hexutil.Big)(op.Nonce),
+		InitCode:             op.InitCode,
+		CallData:             op.CallData,
+		CallGasLimit:         (*hexutil.Big)(op.CallGasLimit),
+		VerificationGasLimit: (*hexutil.Big)(op.VerificationGasLimit),
+		PreVerificationGas:   (*hexutil.Big)(op.PreVerificationGas),
+		MaxFeePerGas:         (*hexutil.Big)(op.MaxFeePerGas),
+		MaxPriorityFeePerGas: (*hexutil.Big)(op.MaxPriorityFeePerGas),
+		PaymasterAndData:     op.PaymasterAndData,
+		Signature:            op.Signature,
+	}
+}
+
+// userOpFromV7 converts a user operation decoded from a v0.7 handleOps call.
+func userOpFromV7(op userOpV7) *UserOperation {
+	uint128 := func(b []byte) *hexutil.Big { return (*hexutil.Big)(new(big.Int).SetBytes(b)) }
+	userOp := &UserOperation{
+		Sender:               op.Sender,
+		Nonce:                (*hexutil.Big)(op.Nonce),
+		CallData:             op.CallData,
+		VerificationGasLimit: uint128(op.AccountGasLimits[:16]),
+		CallGasLimit:         uint128(op.AccountGasLimits[16:]),
+		PreVerificationGas:   (*hexutil.Big)(op.PreVerificationGas),
+		MaxPriorityFeePerGas: uint128(op.GasFees[:16]),
+		MaxFeePerGas:         uint128(op.GasFees[16:]),
+		Signature:            op.Signature,
+	}
+	if len(op.InitCode) >= common.AddressLength {
+		factory := common.BytesToAddress(op.InitCode[:common.AddressLength])
+		userOp.Factory, userOp.FactoryData = &factory, op.InitCode[common.AddressLength:]
+	}
+	if data := op.PaymasterAndData; len(data) >= common.AddressLength+32 {
+		paymaster := common.BytesToAddress(data[:common.AddressLength])
+		userOp.Paymaster = &paymaster
+		userOp.PaymasterVerificationGasLimit = uint128(data[common.AddressLength : common.AddressLength+16])
+		userOp.PaymasterPostOpGasLimit = uint128(data[common.AddressLength+16 : common.AddressLength+32])
+		userOp.PaymasterData = data[common.AddressLength+32:]
+	}
+	return userOp
+}
+
+func bigOrZero(b *hexutil.Big) *big.Int {
+	if b == nil {
+		return new(big.Int)
+	}
+	return b.ToInt()
+}
+
+func math256(b *hexutil.Big) []byte {
+	return common.LeftPadBytes(bigOrZero(b).Bytes(), 32)
+}
+
+// entryPointABI holds the functions, events and errors of the EntryPoint v0.6
+// and v0.7 used by the user operation pool. Their handleOps functions differ by
+// the types of the operations, so the v0.7 one is declared as handleOpsV7.
+var entryPointABI = mustParseABI(`[
+	{"type":"function","name":"handleOps","inputs":[{"name":"ops","type":"tuple[]","components":[
+		{"name":"sender","type":"address"},{"name":"nonce","type":"uint256"},{"name":"initCode","type":"bytes"},
+		{"name":"callData","type":"bytes"},{"name":"callGasLimit","type":"uint256"},{"name":"verificationGasLimit","type":"uint256"},
+		{"name":"preVerificationGas","type":"uint256"},{"name":"maxFeePerGas","type":"uint256"},{"name":"maxPriorityFeePerGas","type":"uint256"},
+		{"name":"paymasterAndData","type":"bytes"},{"name":"signature","type":"bytes"}]},
+		{"name":"beneficiary","type":"address"}],"outputs":[]},
+	{"type":"function","name":"simulateHandleOp","inputs":[{"name":"op","type":"tuple","components":[
+		{"name":"sender","type":"address"},{"name":"nonce","type":"uint256"},{"name":"initCode","type":"bytes"},
+		{"name":"callData","type":"bytes"},{"name":"callGasLimit","type":"uint256"},{"name":"verificationGasLimit","type":"uint256"},
+		{"name":"preVerificationGas","type":"uint256"},{"name":"maxFeePerGas","type":"uint256"},{"name":"maxPriorityFeePerGas","type":"uint256"},
+		{"name":"paymasterAndData","type":"bytes"},{"name":"signature","type":"bytes"}]},
+		{"name":"target","type":"address"},{"name":"targetCallData","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"handleOpsV7","inputs":[{"name":"ops","type":"tuple[]","components":[
+		{"name":"sender","type":"address"},{"name":"nonce","type":"uint256"},{"name":"initCode","type":"bytes"},
+		{"name":"callData","type":"bytes"},{"name":"accountGasLimits","type":"bytes32"},{"name":"preVerificationGas","type":"uint256"},
+		{"name":"gasFees","type":"bytes32"},{"name":"paymasterAndData","type":"bytes"},{"name":"signature","type":"bytes"}]},
+		{"name":"beneficiary","type":"address"}],"outputs":[]},
+	{"type":"event","name":"UserOperationEvent","inputs":[
+		{"name":"userOpHash","type":"bytes32","indexed":true},{"name":"sender","type":"address","indexed":true},
+		{"name":"paymaster","type":"address","indexed":true},{"name":"nonce","type":"uint256"},{"name":"success","type":"bool"},
+		{"name":"actualGasCost","type":"uint256"},{"name":"actualGasUsed","type":"uint256"}]},
+	{"type":"event","name":"UserOperationRevertReason","inputs":[
+		{"name":"userOpHash","type":"bytes32","indexed":true},{"name":"sender","type":"address","indexed":true},
+		{"name":"nonce","type":"uint256"},{"name":"revertReason","type":"bytes"}]},
+	{"type":"event","name":"BeforeExecution","inputs":[]},
+	{"type":"error","name":"FailedOp","inputs":[{"name":"opIndex","type":"uint256"},{"name":"reason","type":"string"}]},
+	{"type":"error","name":"FailedOpWithRevert","inputs":[{"name":"opIndex","type":"uint256"},{"name":"reason","type":"string"},{"name":"inner","type":"bytes"}]},
+	{"type":"error","name":"ExecutionResult","inputs":[{"name":"preOpGas","type":"uint256"},{"name":"paid","type":"uint256"},
+		{"name":"validAfter","type":"uint48"},{"name":"validUntil","type":"uint48"},{"name":"targetSuccess","type":"bool"},{"name":"targetResult","type":"bytes"}]}
+]`)
+
+// nodeInterfaceABI holds the method of the NodeInterface of ArbOS estimating
+// the L1 data cost of a transaction.
+var nodeInterfaceABI = mustParseABI(`[
+	{"type":"function","name":"gasEstimateL1Component","stateMutability":"payable","inputs":[
+		{"name":"to","type":"address"},{"name":"contractCreation","type":"bool"},{"name":"data","type":"bytes"}],"outputs":[
+		{"name":"gasEstimateForL1","type":"uint64"},{"name":"baseFee","type":"uint256"},{"name":"l1BaseFeeEstimate","type":"uint256"}]}
+]`)
+
+// handleOpsV7Selector is the selector of the v0.7 handleOps, declared under
+// another name in entryPointABI.
+var handleOpsV7Selector = crypto.Keccak256([]byte("handleOps((address,uint256,bytes,bytes,bytes32,uint256,bytes32,bytes,bytes)[],address)"))[:4]
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// packHandleOps returns the calldata of a handleOps call of the EntryPoint of
+// the given version.
+func packHandleOps(version int, ops []*UserOperation, beneficiary common.Address) ([]byte, error) {
+	if version == entryPointV6 {
+		packed := make([]userOpV6, len(ops))
+		for i, op := range ops {
+			packed[i] = op.packV6()
+		}
+		return entryPointABI.Pack("handleOps", packed, beneficiary)
+	}
+	packed := make([]userOpV7, len(ops))
+	for i, op := range ops {
+		packed[i] = op.packV7()
+	}
+	data, err := entryPointABI.Pack("handleOpsV7", packed, beneficiary)
+	if err != nil {
+		return nil, err
+	}
+	copy(data, handleOpsV7Selector)
+	return data, nil
+}
+
+// unpackHandleOps returns the user operations of a handleOps calldata.
+func unpackHandleOps(data []byte) ([]*UserOperation, error) {
+	if len(data) < 4 {
+		return nil, errors.New("missing selector")
+	}
+	var (
+		method  = entryPointABI.Methods["handleOps"]
+		version = entryPointV6
+	)
+	switch {
+	case string(data[:4]) == string(handleOpsV7Selector):
+		method, version = entryPointABI.Methods["handleOpsV7"], entryPointV7
+	case string(data[:4]) != string(method.ID):
+		return nil, fmt.Errorf("not a handleOps call: %x", data[:4])
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	var ops []*UserOperation
+	if version == entryPointV6 {
+		var packed []userOpV6
+		if err := method.Inputs.Copy(&[]interface{}{&packed, new(common.Address)}, args); err != nil {
+			return nil, err
+		}
+		for _, op := range packed {
+			ops = append(ops, userOpFromV6(op))
+		}
+		return ops, nil
+	}
+	var packed []userOpV7
+	if err := method.Inputs.Copy(&[]interface{}{&packed, new(common.Address)}, args); err != nil {
+		return nil, err
+	}
+	for _, op := range packed {
+		ops = append(ops, userOpFromV7(op))
+	}
+	return ops, nil
+}
+
+// failedOpReason returns the reason of a FailedOp or FailedOpWithRevert revert
+// of the EntryPoint.
+func failedOpReason(revert []byte) (string, bool) {
+	for _, name := range []string{"FailedOp", "FailedOpWithRevert"} {
+		e := entryPointABI.Errors[name]
+		if len(revert) < 4 || string(revert[:4]) != string(e.ID[:4]) {
+			continue
+		}
+		args, err := e.Inputs.Unpack(revert[4:])
+		if err != nil || len(args) < 2 {
+			return "", false
+		}
+		reason, _ := args[1].(string)
+		return reason, true
+	}
+	return "", false
+}
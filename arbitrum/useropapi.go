@@ -0,0 +1,153 @@
+package arbitrum
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// UserOperationAPI provides the ERC-4337 bundler endpoints, backed by the user
+// operation pool of the node.
+type UserOperationAPI struct {
+	pool *userOpPool
+}
+
+func NewUserOperationAPI(pool *userOpPool) *UserOperationAPI {
+	return &UserOperationAPI{pool}
+}
+
+// UserOperationGasEstimate is the result of eth_estimateUserOperationGas. The
+// paymaster gas limits are only set for v0.7 operations with a paymaster.
+type UserOperationGasEstimate struct {
+	PreVerificationGas            *hexutil.Big `json:"preVerificationGas"`
+	VerificationGasLimit          *hexutil.Big `json:"verificationGasLimit"`
+	CallGasLimit                  *hexutil.Big `json:"callGasLimit"`
+	PaymasterVerificationGasLimit *hexutil.Big `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       *hexutil.Big `json:"paymasterPostOpGasLimit,omitempty"`
+}
+
+// UserOperationByHash is the result of eth_getUserOperationByHash. The block and
+// transaction fields are nil while the operation is pending.
+type UserOperationByHash struct {
+	UserOperation   *UserOperation `json:"userOperation"`
+	EntryPoint      common.Address `json:"entryPoint"`
+	TransactionHash *common.Hash   `json:"transactionHash"`
+	BlockHash       *common.Hash   `json:"blockHash"`
+	BlockNumber     *hexutil.Big   `json:"blockNumber"`
+}
+
+// UserOperationReceipt is the result of eth_getUserOperationReceipt.
+type UserOperationReceipt struct {
+	UserOpHash    common.Hash            `json:"userOpHash"`
+	EntryPoint    common.Address         `json:"entryPoint"`
+	Sender        common.Address         `json:"sender"`
+	Nonce         *hexutil.Big           `json:"nonce"`
+	Paymaster     common.Address         `json:"paymaster"`
+	ActualGasCost *hexutil.Big           `json:"actualGasCost"`
+	ActualGasUsed *hexutil.Big           `json:"actualGasUsed"`
+	Success       bool                   `json:"success"`
+	Reason        hexutil.Bytes          `json:"reason,omitempty"`
+	Logs          []*types.Log           `json:"logs"`
+	Receipt       map[string]interface{} `json:"receipt"`
+}
+
+// SendUserOperation validates the user operation against the latest state and
+// adds it to the pool, returning its hash.
+func (api *UserOperationAPI) SendUserOperation(ctx context.Context, op UserOperation, entryPoint common.Address) (common.Hash, error) {
+	return api.pool.add(ctx, &op, entryPoint)
+}
+
+// EstimateUserOperationGas returns the gas limits of the user operation, the
+// preVerificationGas including the L1 data cost of its bundle.
+func (api *UserOperationAPI) EstimateUserOperationGas(ctx context.Context, op UserOperation, entryPoint common.Address) (*UserOperationGasEstimate, error) {
+	return api.pool.estimate(ctx, &op, entryPoint)
+}
+
+// SupportedEntryPoints returns the EntryPoints the pool accepts operations for,
+// v0.6 first.
+func (api *UserOperationAPI) SupportedEntryPoints() []common.Address {
+	contracts := api.pool.backend.ChainConfig().Kinto().Contracts
+	return []common.Address{contracts.EntryPoint, contracts.EntryPointV7}
+}
+
+// GetUserOperationByHash returns the user operation with the given hash, either
+// pending in the pool or included in a recent block.
+func (api *UserOperationAPI) GetUserOperationByHash(ctx context.Context, hash common.Hash) (*UserOperationByHash, error) {
+	p := api.pool
+	p.mu.Lock()
+	pending, ok := p.pending[hash]
+	p.mu.Unlock()
+	if ok {
+		return &UserOperationByHash{UserOperation: pending.op, EntryPoint: pending.entryPoint}, nil
+	}
+	event, err := p.findEvent(ctx, hash)
+	if err != nil || event == nil {
+		return nil, err
+	}
+	found, tx, blockHash, blockNumber, _, err := p.backend.GetTransaction(ctx, event.log.TxHash)
+	if err != nil || !found {
+		return nil, err
+	}
+	ops, err := unpackHandleOps(tx.Data())
+	if err != nil {
+		// The bundle wasn't a direct handleOps call
+		return nil, nil
+	}
+	version, err := p.entryPointVersion(event.log.Address)
+	if err != nil {
+		return nil, err
+	}
+	chainID := p.backend.ChainConfig().ChainID
+	for _, op := range ops {
+		if op.Hash(event.log.Address, version, chainID) != hash {
+			continue
+		}
+		txHash := tx.Hash()
+		return &UserOperationByHash{
+			UserOperation:   op,
+			EntryPoint:      event.log.Address,
+			TransactionHash: &txHash,
+			BlockHash:       &blockHash,
+			BlockNumber:     (*hexutil.Big)(new(big.Int).SetUint64(blockNumber)),
+		}, nil
+	}
+	return nil, nil
+}
+
+// GetUserOperationReceipt returns the receipt of the user operation with the
+// given hash, nil if it isn't included in a recent block.
+func (api *UserOperationAPI) GetUserOperationReceipt(ctx context.Context, hash common.Hash) (*UserOperationReceipt, error) {
+	p := api.pool
+	event, err := p.findEvent(ctx, hash)
+	if err != nil || event == nil {
+		return nil, err
+	}
+	receipt, err := p.receipt(ctx, event.log.TxHash)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	txReceipt, err := p.backend.transactionReceipt(ctx, event.log.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	logs := opLogs(receipt, event)
+	result := &UserOperationReceipt{
+		UserOpHash:    hash,
+		EntryPoint:    event.log.Address,
+		Sender:        event.sender,
+		Nonce:         (*hexutil.Big)(event.nonce),
+		Paymaster:     event.paymaster,
+		ActualGasCost: (*hexutil.Big)(event.actualGasCost),
+		ActualGasUsed: (*hexutil.Big)(event.actualGasUsed),
+		Success:       event.success,
+		Logs:          logs,
+		Receipt:       txReceipt,
+	}
+	if !event.success {
+		result.Reason = revertReason(logs, hash, event.log.Address)
+	}
+	return result, nil
+}
@@ -0,0 +1,939 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native" // userOpTracer
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// UserOpConfig configures the ERC-4337 user operation pool and its bundler.
+type UserOpConfig struct {
+	Enable              bool          `koanf:"enable"`
+	BundlerAccount      string        `koanf:"bundler-account"`
+	BundlerPasswordFile string        `koanf:"bundler-password-file"`
+	BundleInterval      time.Duration `koanf:"bundle-interval"`
+	MaxBundleSize       int           `koanf:"max-bundle-size"`
+	MaxOpsPerSender     int           `koanf:"max-ops-per-sender"`
+	MaxPoolSize         int           `koanf:"max-pool-size"`
+	LogLookback         uint64        `koanf:"log-lookback"`
+	Unsafe              bool          `koanf:"unsafe"`
+}
+
+// User operation RPC error codes, from ERC-4337.
+const (
+	errcodeUserOpInvalidFields = -32602
+	errcodeUserOpRejected      = -32500
+	errcodeUserOpPaymaster     = -32501
+	errcodeUserOpThrottled     = -32504
+	errcodeUserOpReverted      = -32521
+)
+
+const (
+	userOpReplacementBump = 10            // percentage a replacing operation must raise its fees by
+	userOpBundleTimeout   = time.Minute   // time after which the operations of an unmined bundle are bundled again
+	userOpBundledCache    = 4096          // number of bundled operations remembered
+	userOpCallOverhead    = 21000 + 18300 // gas paid outside of the operation by a bundle of one
+	userOpEstimateLimit   = 10_000_000    // gas limits of the operations simulated for estimation
+	userOpGasMargin       = 120           // percentage of the estimated gas set as limit
+	userOpEstimateBalance = 1e18          // sender balance set for estimation, the fees being one wei
+	userOpV7Verification  = 30000         // gas of the v0.7 validation outside of the validation calls, the nonce update included
+)
+
+// userOpError is the RPC error of a rejected user operation.
+type userOpError struct {
+	code int
+	msg  string
+}
+
+func (e *userOpError) Error() string  { return e.msg }
+func (e *userOpError) ErrorCode() int { return e.code }
+
+func userOpErrorf(code int, format string, args ...interface{}) error {
+	return &userOpError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// pendingUserOp is a user operation waiting in the pool.
+type pendingUserOp struct {
+	op         *UserOperation
+	hash       common.Hash
+	entryPoint common.Address
+	version    int
+
+	bundle    common.Hash // bundle transaction the operation was submitted in, zero if not submitted
+	submitted time.Time   // time the bundle was submitted
+}
+
+// userOpBackend is the chain the user operation pool validates and submits the
+// operations on, an apiUserOpBackend out of tests.
+type userOpBackend interface {
+	ChainConfig() *params.ChainConfig
+	CurrentHeader() *types.Header
+	GetTransaction(ctx context.Context, txHash common.Hash) (bool, *types.Transaction, common.Hash, uint64, uint64, error)
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
+	SendTx(ctx context.Context, signedTx *types.Transaction) error
+
+	// call executes a call on the latest state.
+	call(ctx context.Context, args ethapi.TransactionArgs, overrides *ethapi.StateOverride) (*core.ExecutionResult, error)
+	// traceUserOps executes a call on the latest state with the userOpTracer,
+	// returning its result.
+	traceUserOps(ctx context.Context, args ethapi.TransactionArgs, overrides *ethapi.StateOverride) (json.RawMessage, error)
+	// estimateGas estimates the gas of a transaction on the latest state.
+	estimateGas(ctx context.Context, args ethapi.TransactionArgs) (uint64, error)
+	// latestNonce returns the nonce of the account in the latest state, with the
+	// latest header.
+	latestNonce(ctx context.Context, address common.Address) (uint64, *types.Header, error)
+	// filterLogs returns the logs of the blocks in the range matching the query.
+	filterLogs(ctx context.Context, begin, end int64, addresses []common.Address, topics [][]common.Hash) ([]*types.Log, error)
+	// lookupUserOp returns the index entry of the user operation, nil if the
+	// operation or the index is missing.
+	lookupUserOp(hash common.Hash) *rawdb.UserOpLookupEntry
+	// transactionReceipt returns the RPC receipt of the transaction.
+	transactionReceipt(ctx context.Context, txHash common.Hash) (map[string]interface{}, error)
+}
+
+// apiUserOpBackend serves the user operation pool from the APIBackend.
+type apiUserOpBackend struct {
+	*APIBackend
+	filterSystem *filters.FilterSystem
+}
+
+func (b *apiUserOpBackend) call(ctx context.Context, args ethapi.TransactionArgs, overrides *ethapi.StateOverride) (*core.ExecutionResult, error) {
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	return ethapi.DoCall(ctx, b, args, latest, overrides, nil, b.RPCEVMTimeout(), b.RPCGasCap(), core.MessageEthcallMode)
+}
+
+func (b *apiUserOpBackend) traceUserOps(ctx context.Context, args ethapi.TransactionArgs, overrides *ethapi.StateOverride) (json.RawMessage, error) {
+	tracer := "userOpTracer"
+	config := &tracers.TraceCallConfig{TraceConfig: tracers.TraceConfig{Tracer: &tracer}, StateOverrides: overrides}
+	result, err := tracers.NewAPI(b.APIBackend).TraceCall(ctx, args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+func (b *apiUserOpBackend) estimateGas(ctx context.Context, args ethapi.TransactionArgs) (uint64, error) {
+	gas, err := ethapi.DoEstimateGas(ctx, b, args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, b.RPCGasCap())
+	return uint64(gas), err
+}
+
+func (b *apiUserOpBackend) latestNonce(ctx context.Context, address common.Address) (uint64, *types.Header, error) {
+	statedb, header, err := b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return 0, nil, err
+	}
+	return statedb.GetNonce(address), header, nil
+}
+
+func (b *apiUserOpBackend) filterLogs(ctx context.Context, begin, end int64, addresses []common.Address, topics [][]common.Hash) ([]*types.Log, error) {
+	return b.filterSystem.NewRangeFilter(begin, end, addresses, topics).Logs(ctx)
+}
+
+func (b *apiUserOpBackend) lookupUserOp(hash common.Hash) *rawdb.UserOpLookupEntry {
+	if indexer := b.b.userOpIndexer; indexer != nil {
+		return indexer.Lookup(hash)
+	}
+	return nil
+}
+
+func (b *apiUserOpBackend) transactionReceipt(ctx context.Context, txHash common.Hash) (map[string]interface{}, error) {
+	return ethapi.NewTransactionAPI(b, nil).GetTransactionReceipt(ctx, txHash)
+}
+
+// userOpPool holds the user operations sent to the node until the bundler
+// submits them to their EntryPoint in handleOps transactions, and until their
+// UserOperationEvent is found in the receipt of the bundle.
+type userOpPool struct {
+	backend userOpBackend
+	config  *UserOpConfig
+
+	wallet     accounts.Wallet  // wallet signing the bundles
+	account    accounts.Account // bundler account, beneficiary of the bundles
+	passphrase string           // passphrase of the account, empty if unlocked
+	bundler    common.Address
+
+	entryPoints map[common.Address]int // EntryPoint version by address
+
+	mu         sync.Mutex
+	pending    map[common.Hash]*pendingUserOp
+	bundled    *lru.Cache[common.Hash, common.Hash] // bundle transaction by operation hash
+	lastNonce  uint64
+	lastBundle time.Time
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newUserOpPool creates the user operation pool, signing its bundles with the
+// bundler account of the account manager.
+//
+// The pool doesn't enforce the ERC-7562 rules restricting the opcodes and the
+// storage the validation of an operation may use: an operation passing
+// validation may be invalidated by a state change before its bundle is mined,
+// the bundler paying for the reverted bundle. The pool only runs in this
+// unsafe mode, acknowledged by the configuration.
+func newUserOpPool(backend userOpBackend, am *accounts.Manager, config *UserOpConfig) (*userOpPool, error) {
+	if !config.Unsafe {
+		return nil, errors.New("the user operation pool doesn't enforce the ERC-7562 validation rules, it requires the unsafe mode (user-ops.unsafe)")
+	}
+	if !common.IsHexAddress(config.BundlerAccount) {
+		return nil, fmt.Errorf("invalid user operation bundler account %q", config.BundlerAccount)
+	}
+	account := accounts.Account{Address: common.HexToAddress(config.BundlerAccount)}
+	wallet, err := am.Find(account)
+	if err != nil {
+		return nil, fmt.Errorf("user operation bundler account %v: %w", account.Address, err)
+	}
+	var passphrase string
+	if config.BundlerPasswordFile != "" {
+		text, err := os.ReadFile(config.BundlerPasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read user operation bundler password file: %w", err)
+		}
+		passphrase = strings.TrimRight(strings.SplitN(string(text), "\n", 2)[0], "\r")
+	}
+	contracts := backend.ChainConfig().Kinto().Contracts
+	return &userOpPool{
+		backend:    backend,
+		config:     config,
+		wallet:     wallet,
+		account:    account,
+		passphrase: passphrase,
+		bundler:    account.Address,
+		entryPoints: map[common.Address]int{
+			contracts.EntryPoint:   entryPointV6,
+			contracts.EntryPointV7: entryPointV7,
+		},
+		pending: make(map[common.Hash]*pendingUserOp),
+		bundled: lru.NewCache[common.Hash, common.Hash](userOpBundledCache),
+		quit:    make(chan struct{}),
+	}, nil
+}
+
+func (p *userOpPool) start() {
+	log.Warn("User operation pool running in unsafe mode, the ERC-7562 validation rules aren't enforced", "bundler", p.bundler)
+	p.wg.Add(1)
+	go p.loop()
+}
+
+func (p *userOpPool) stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+func (p *userOpPool) loop() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.config.BundleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), p.config.BundleInterval)
+			if err := p.bundle(ctx); err != nil {
+				log.Warn("Failed to submit user operation bundle", "err", err)
+			}
+			cancel()
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// entryPointVersion returns the version of the EntryPoint, or an RPC error if
+// it isn't supported.
+func (p *userOpPool) entryPointVersion(entryPoint common.Address) (int, error) {
+	version, ok := p.entryPoints[entryPoint]
+	if !ok {
+		return 0, userOpErrorf(errcodeUserOpInvalidFields, "unsupported entry point %v", entryPoint)
+	}
+	return version, nil
+}
+
+// add validates the user operation against the latest state and adds it to the
+// pool, returning its hash. The pending operations of the sender preceding it
+// are run before it, for the operations following them to be accepted.
+func (p *userOpPool) add(ctx context.Context, op *UserOperation, entryPoint common.Address) (common.Hash, error) {
+	version, err := p.entryPointVersion(entryPoint)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := op.validate(version); err != nil {
+		return common.Hash{}, userOpErrorf(errcodeUserOpInvalidFields, "invalid user operation: %v", err)
+	}
+	preVerificationGas, err := p.preVerificationGas(ctx, op, entryPoint, version)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if op.PreVerificationGas.ToInt().Cmp(new(big.Int).SetUint64(preVerificationGas)) < 0 {
+		return common.Hash{}, userOpErrorf(errcodeUserOpInvalidFields, "preVerificationGas below %d", preVerificationGas)
+	}
+	p.mu.Lock()
+	ops := append(p.preceding(op, entryPoint), op)
+	p.mu.Unlock()
+	if err := p.simulate(ctx, ops, entryPoint, version); err != nil {
+		return common.Hash{}, err
+	}
+	pending := &pendingUserOp{
+		op:         op,
+		hash:       op.Hash(entryPoint, version, p.backend.ChainConfig().ChainID),
+		entryPoint: entryPoint,
+		version:    version,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.pending[pending.hash]; ok {
+		return pending.hash, nil
+	}
+	var replaced *pendingUserOp
+	senderOps := 0
+	for _, other := range p.pending {
+		if other.op.Sender != op.Sender {
+			continue
+		}
+		senderOps++
+		if other.entryPoint == entryPoint && other.op.Nonce.ToInt().Cmp(op.Nonce.ToInt()) == 0 {
+			replaced = other
+		}
+	}
+	if replaced != nil {
+		if !feeBumped(replaced.op.MaxFeePerGas, op.MaxFeePerGas) || !feeBumped(replaced.op.MaxPriorityFeePerGas, op.MaxPriorityFeePerGas) {
+			return common.Hash{}, userOpErrorf(errcodeUserOpInvalidFields, "replacement user operation must raise its fees by %d%%", userOpReplacementBump)
+		}
+		delete(p.pending, replaced.hash)
+	} else {
+		if senderOps >= p.config.MaxOpsPerSender {
+			return common.Hash{}, userOpErrorf(errcodeUserOpThrottled, "sender %v has %d pending user operations", op.Sender, senderOps)
+		}
+		if len(p.pending) >= p.config.MaxPoolSize {
+			return common.Hash{}, userOpErrorf(errcodeUserOpThrottled, "user operation pool is full")
+		}
+	}
+	p.pending[pending.hash] = pending
+	log.Debug("Added user operation", "hash", pending.hash, "sender", op.Sender, "nonce", op.Nonce, "entrypoint", entryPoint)
+	return pending.hash, nil
+}
+
+// preceding returns the pending operations of the sender of the operation to
+// the same EntryPoint with the same nonce key and a lower nonce, in nonce order.
+func (p *userOpPool) preceding(op *UserOperation, entryPoint common.Address) []*UserOperation {
+	var (
+		nonce = op.Nonce.ToInt()
+		key   = new(big.Int).Rsh(nonce, 64)
+		ops   []*UserOperation
+	)
+	for _, other := range p.pending {
+		otherNonce := other.op.Nonce.ToInt()
+		if other.op.Sender != op.Sender || other.entryPoint != entryPoint || otherNonce.Cmp(nonce) >= 0 || new(big.Int).Rsh(otherNonce, 64).Cmp(key) != 0 {
+			continue
+		}
+		ops = append(ops, other.op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].Nonce.ToInt().Cmp(ops[j].Nonce.ToInt()) < 0
+	})
+	return ops
+}
+
+func feeBumped(oldFee, newFee *hexutil.Big) bool {
+	threshold := new(big.Int).Mul(oldFee.ToInt(), big.NewInt(100+userOpReplacementBump))
+	return new(big.Int).Mul(newFee.ToInt(), big.NewInt(100)).Cmp(threshold) >= 0
+}
+
+// simulate runs a handleOps of the user operations by the bundler against the
+// latest state, rejecting the operations if the validation of one fails.
+func (p *userOpPool) simulate(ctx context.Context, ops []*UserOperation, entryPoint common.Address, version int) error {
+	data, err := packHandleOps(version, ops, p.bundler)
+	if err != nil {
+		return userOpErrorf(errcodeUserOpInvalidFields, "invalid user operation: %v", err)
+	}
+	result, err := p.call(ctx, entryPoint, data, nil)
+	if err != nil {
+		return err
+	}
+	if result.Err != nil {
+		return revertError(result)
+	}
+	return nil
+}
+
+// call executes a call of the EntryPoint by the bundler on the latest state.
+func (p *userOpPool) call(ctx context.Context, entryPoint common.Address, data []byte, overrides *ethapi.StateOverride) (*core.ExecutionResult, error) {
+	input := hexutil.Bytes(data)
+	return p.backend.call(ctx, ethapi.TransactionArgs{From: &p.bundler, To: &entryPoint, Input: &input}, overrides)
+}
+
+// revertError returns the RPC error of a reverted EntryPoint call.
+func revertError(result *core.ExecutionResult) error {
+	if reason, ok := failedOpReason(result.Revert()); ok {
+		if len(reason) >= 3 && reason[:3] == "AA3" {
+			return userOpErrorf(errcodeUserOpPaymaster, "user operation rejected by paymaster: %s", reason)
+		}
+		return userOpErrorf(errcodeUserOpRejected, "user operation rejected: %s", reason)
+	}
+	return userOpErrorf(errcodeUserOpRejected, "user operation rejected: %v", result.Err)
+}
+
+// bundle submits a handleOps transaction for the pending operations of each
+// EntryPoint, unless the previous bundle isn't mined yet, once the operations
+// of the previous bundles are settled.
+func (p *userOpPool) bundle(ctx context.Context) error {
+	p.settle(ctx)
+
+	nonce, header, err := p.backend.latestNonce(ctx, p.bundler)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	if nonce <= p.lastNonce && !p.lastBundle.IsZero() && time.Since(p.lastBundle) < userOpBundleTimeout {
+		p.mu.Unlock()
+		return nil
+	}
+	// The operations following the submitted ones of their sender wait for them
+	submitted := make(map[common.Address]bool)
+	for _, pending := range p.pending {
+		if pending.bundle != (common.Hash{}) {
+			submitted[pending.op.Sender] = true
+		}
+	}
+	byEntryPoint := make(map[common.Address][]*pendingUserOp)
+	for _, pending := range p.pending {
+		if !submitted[pending.op.Sender] {
+			byEntryPoint[pending.entryPoint] = append(byEntryPoint[pending.entryPoint], pending)
+		}
+	}
+	p.mu.Unlock()
+
+	for entryPoint, candidates := range byEntryPoint {
+		ops := p.selectOps(ctx, candidates)
+		if len(ops) == 0 {
+			continue
+		}
+		if err := p.submit(ctx, entryPoint, ops, nonce, header); err != nil {
+			return err
+		}
+		nonce++
+	}
+	return nil
+}
+
+// selectOps returns the operations to bundle: the operation with the lowest
+// nonce of each sender, by decreasing priority fee and then by hash, dropping
+// the ones which don't pass validation anymore.
+func (p *userOpPool) selectOps(ctx context.Context, candidates []*pendingUserOp) []*pendingUserOp {
+	first := make(map[common.Address]*pendingUserOp)
+	for _, pending := range candidates {
+		if other := first[pending.op.Sender]; other == nil || pending.op.Nonce.ToInt().Cmp(other.op.Nonce.ToInt()) < 0 {
+			first[pending.op.Sender] = pending
+		}
+	}
+	heads := make([]*pendingUserOp, 0, len(first))
+	for _, pending := range first {
+		heads = append(heads, pending)
+	}
+	sort.Slice(heads, func(i, j int) bool {
+		if c := heads[i].op.MaxPriorityFeePerGas.ToInt().Cmp(heads[j].op.MaxPriorityFeePerGas.ToInt()); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(heads[i].hash[:], heads[j].hash[:]) < 0
+	})
+	var selected []*pendingUserOp
+	for _, pending := range heads {
+		if len(selected) >= p.config.MaxBundleSize {
+			break
+		}
+		if err := p.simulate(ctx, []*UserOperation{pending.op}, pending.entryPoint, pending.version); err != nil {
+			log.Debug("Dropping invalid user operation", "hash", pending.hash, "err", err)
+			p.mu.Lock()
+			delete(p.pending, pending.hash)
+			p.mu.Unlock()
+			continue
+		}
+		selected = append(selected, pending)
+	}
+	return selected
+}
+
+// submit signs and sends a handleOps transaction of the operations, subject to
+// the Kinto admission checks of the transactions sent to the node. The
+// operations stay in the pool until settled.
+func (p *userOpPool) submit(ctx context.Context, entryPoint common.Address, ops []*pendingUserOp, nonce uint64, header *types.Header) error {
+	userOps := make([]*UserOperation, len(ops))
+	for i, pending := range ops {
+		userOps[i] = pending.op
+	}
+	data, err := packHandleOps(ops[0].version, userOps, p.bundler)
+	if err != nil {
+		return err
+	}
+	input := hexutil.Bytes(data)
+	gas, err := p.backend.estimateGas(ctx, ethapi.TransactionArgs{From: &p.bundler, To: &entryPoint, Input: &input})
+	if err != nil {
+		return fmt.Errorf("estimating bundle gas: %w", err)
+	}
+	chainID := p.backend.ChainConfig().ChainID
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: new(big.Int),
+		GasFeeCap: new(big.Int).Mul(header.BaseFee, common.Big2),
+		Gas:       gas * userOpGasMargin / 100,
+		To:        &entryPoint,
+		Data:      data,
+	})
+	if p.passphrase != "" {
+		tx, err = p.wallet.SignTxWithPassphrase(p.account, p.passphrase, tx, chainID)
+	} else {
+		tx, err = p.wallet.SignTx(p.account, tx, chainID)
+	}
+	if err != nil {
+		return fmt.Errorf("signing bundle: %w", err)
+	}
+	if err := p.backend.SendTx(ctx, tx); err != nil {
+		return err
+	}
+	log.Info("Submitted user operation bundle", "hash", tx.Hash(), "entrypoint", entryPoint, "ops", len(ops), "nonce", nonce)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for _, pending := range ops {
+		pending.bundle, pending.submitted = tx.Hash(), now
+		p.bundled.Add(pending.hash, tx.Hash())
+	}
+	p.lastNonce, p.lastBundle = nonce, now
+	return nil
+}
+
+// settle removes the submitted operations whose UserOperationEvent is in the
+// receipt of their bundle from the pool. The other operations of the mined
+// bundles, and the operations of the bundles unmined after userOpBundleTimeout,
+// are bundled again.
+func (p *userOpPool) settle(ctx context.Context) {
+	p.mu.Lock()
+	bundles := make(map[common.Hash][]*pendingUserOp)
+	for _, pending := range p.pending {
+		if pending.bundle != (common.Hash{}) {
+			bundles[pending.bundle] = append(bundles[pending.bundle], pending)
+		}
+	}
+	p.mu.Unlock()
+
+	for txHash, ops := range bundles {
+		receipt, err := p.receipt(ctx, txHash)
+		if err != nil {
+			log.Warn("Failed to get user operation bundle receipt", "hash", txHash, "err", err)
+			continue
+		}
+		included := make(map[common.Hash]bool)
+		if receipt != nil {
+			for _, l := range receipt.Logs {
+				if hash, ok := p.userOpEventHash(l); ok {
+					included[hash] = true
+				}
+			}
+		}
+		p.mu.Lock()
+		for _, pending := range ops {
+			if p.pending[pending.hash] != pending || pending.bundle != txHash {
+				continue // replaced or settled meanwhile
+			}
+			switch {
+			case included[pending.hash]:
+				delete(p.pending, pending.hash)
+			case receipt != nil || time.Since(pending.submitted) >= userOpBundleTimeout:
+				log.Debug("Bundling user operation again", "hash", pending.hash, "bundle", txHash, "mined", receipt != nil)
+				pending.bundle, pending.submitted = common.Hash{}, time.Time{}
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// userOpEventHash returns the operation hash of a UserOperationEvent log of an
+// EntryPoint of the pool.
+func (p *userOpPool) userOpEventHash(l *types.Log) (common.Hash, bool) {
+	if _, ok := p.entryPoints[l.Address]; !ok || len(l.Topics) != 4 || l.Topics[0] != entryPointABI.Events["UserOperationEvent"].ID {
+		return common.Hash{}, false
+	}
+	return l.Topics[1], true
+}
+
+// userOpEvent is a UserOperationEvent emitted by an EntryPoint.
+type userOpEvent struct {
+	log           *types.Log
+	sender        common.Address
+	paymaster     common.Address
+	nonce         *big.Int
+	success       bool
+	actualGasCost *big.Int
+	actualGasUsed *big.Int
+}
+
+// findEvent returns the UserOperationEvent of the operation, looking it up in
+// the user operation index if enabled, in the bundle the pool submitted it in,
+// or else in the logs of the recent blocks.
+func (p *userOpPool) findEvent(ctx context.Context, hash common.Hash) (*userOpEvent, error) {
+	if entry := p.backend.lookupUserOp(hash); entry != nil {
+		return p.indexedEvent(ctx, entry)
+	}
+	event := entryPointABI.Events["UserOperationEvent"]
+	var logs []*types.Log
+	if txHash, ok := p.bundled.Peek(hash); ok {
+		receipt, err := p.receipt(ctx, txHash)
+		if err != nil || receipt == nil {
+			return nil, err
+		}
+		logs = receipt.Logs
+	} else {
+		head := p.backend.CurrentHeader().Number.Int64()
+		begin := head - int64(p.config.LogLookback)
+		if begin < 0 {
+			begin = 0
+		}
+		addresses := make([]common.Address, 0, len(p.entryPoints))
+		for entryPoint := range p.entryPoints {
+			addresses = append(addresses, entryPoint)
+		}
+		var err error
+		logs, err = p.backend.filterLogs(ctx, begin, head, addresses, [][]common.Hash{{event.ID}, {hash}})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, l := range logs {
+		if opHash, ok := p.userOpEventHash(l); !ok || opHash != hash {
+			continue
+		}
+		values, err := event.Inputs.NonIndexed().Unpack(l.Data)
+		if err != nil {
+			return nil, err
+		}
+		return &userOpEvent{
+			log:           l,
+			sender:        common.BytesToAddress(l.Topics[2].Bytes()),
+			paymaster:     common.BytesToAddress(l.Topics[3].Bytes()),
+			nonce:         values[0].(*big.Int),
+			success:       values[1].(bool),
+			actualGasCost: values[2].(*big.Int),
+			actualGasUsed: values[3].(*big.Int),
+		}, nil
+	}
+	return nil, nil
+}
+
+// indexedEvent returns the UserOperationEvent of an index entry.
+func (p *userOpPool) indexedEvent(ctx context.Context, entry *rawdb.UserOpLookupEntry) (*userOpEvent, error) {
+	receipts, err := p.backend.GetReceipts(ctx, entry.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if entry.TxIndex < uint64(len(receipts)) {
+		for _, l := range receipts[entry.TxIndex].Logs {
+			if uint64(l.Index) == entry.LogIndex {
+				return &userOpEvent{
+					log:           l,
+					sender:        entry.Sender,
+					paymaster:     entry.Paymaster,
+					nonce:         entry.Nonce,
+					success:       entry.Success,
+					actualGasCost: entry.ActualGasCost,
+					actualGasUsed: entry.ActualGasUsed,
+				}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("missing indexed user operation event of block %v", entry.BlockHash)
+}
+
+// receipt returns the receipt of a mined transaction, nil if it isn't mined.
+func (p *userOpPool) receipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	found, _, blockHash, _, index, err := p.backend.GetTransaction(ctx, txHash)
+	if err != nil || !found {
+		return nil, err
+	}
+	receipts, err := p.backend.GetReceipts(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if index >= uint64(len(receipts)) {
+		return nil, fmt.Errorf("missing receipt of transaction %v", txHash)
+	}
+	return receipts[index], nil
+}
+
+// opLogs returns the logs emitted during the execution of the operation, which
+// are the logs of the bundle between the previous UserOperationEvent, or the
+// BeforeExecution event, and the operation's UserOperationEvent.
+func opLogs(receipt *types.Receipt, event *userOpEvent) []*types.Log {
+	var (
+		boundaries = []common.Hash{entryPointABI.Events["UserOperationEvent"].ID, entryPointABI.Events["BeforeExecution"].ID}
+		start      = 0
+	)
+	for i, l := range receipt.Logs {
+		if l.Index == event.log.Index {
+			return receipt.Logs[start:i]
+		}
+		if l.Address == event.log.Address && len(l.Topics) > 0 && (l.Topics[0] == boundaries[0] || l.Topics[0] == boundaries[1]) {
+			start = i + 1
+		}
+	}
+	return nil
+}
+
+// revertReason returns the revert reason of a failed operation from its
+// UserOperationRevertReason event.
+func revertReason(logs []*types.Log, hash common.Hash, entryPoint common.Address) hexutil.Bytes {
+	event := entryPointABI.Events["UserOperationRevertReason"]
+	for _, l := range logs {
+		if l.Address != entryPoint || len(l.Topics) < 2 || l.Topics[0] != event.ID || l.Topics[1] != hash {
+			continue
+		}
+		values, err := event.Inputs.NonIndexed().Unpack(l.Data)
+		if err != nil || len(values) < 2 {
+			return nil
+		}
+		reason, _ := values[1].([]byte)
+		return reason
+	}
+	return nil
+}
+
+// estimate returns the gas limits of the operation, simulated at a fee of one
+// wei: with simulateHandleOp for v0.6 operations, and with a traced handleOps
+// for v0.7 ones, their EntryPoint lacking simulateHandleOp.
+func (p *userOpPool) estimate(ctx context.Context, op *UserOperation, entryPoint common.Address) (*UserOperationGasEstimate, error) {
+	version, err := p.entryPointVersion(entryPoint)
+	if err != nil {
+		return nil, err
+	}
+	if op.Nonce == nil {
+		return nil, userOpErrorf(errcodeUserOpInvalidFields, "invalid user operation: missing nonce")
+	}
+	sim := *op
+	limit := (*hexutil.Big)(big.NewInt(userOpEstimateLimit))
+	oneWei := (*hexutil.Big)(common.Big1)
+	sim.CallGasLimit, sim.VerificationGasLimit, sim.PreVerificationGas = limit, limit, (*hexutil.Big)(new(big.Int))
+	sim.MaxFeePerGas, sim.MaxPriorityFeePerGas = oneWei, oneWei
+	if sim.Paymaster != nil {
+		sim.PaymasterVerificationGasLimit, sim.PaymasterPostOpGasLimit = limit, limit
+	}
+	if err := sim.validate(version); err != nil {
+		return nil, userOpErrorf(errcodeUserOpInvalidFields, "invalid user operation: %v", err)
+	}
+	balance := (*hexutil.Big)(big.NewInt(userOpEstimateBalance))
+	overrides := ethapi.StateOverride{op.Sender: ethapi.OverrideAccount{Balance: &balance}}
+
+	var estimate *UserOperationGasEstimate
+	if version == entryPointV6 {
+		estimate, err = p.estimateV6(ctx, &sim, entryPoint, &overrides)
+	} else {
+		estimate, err = p.estimateV7(ctx, &sim, entryPoint, &overrides)
+	}
+	if err != nil {
+		return nil, err
+	}
+	preVerificationGas, err := p.preVerificationGas(ctx, &sim, entryPoint, version)
+	if err != nil {
+		return nil, err
+	}
+	estimate.PreVerificationGas = (*hexutil.Big)(withMargin(new(big.Int).SetUint64(preVerificationGas)))
+	return estimate, nil
+}
+
+// estimateV6 returns the verification and call gas limits of the v0.6
+// operation, from the ExecutionResult of its simulateHandleOp.
+func (p *userOpPool) estimateV6(ctx context.Context, sim *UserOperation, entryPoint common.Address, overrides *ethapi.StateOverride) (*UserOperationGasEstimate, error) {
+	data, err := entryPointABI.Pack("simulateHandleOp", sim.packV6(), common.Address{}, []byte{})
+	if err != nil {
+		return nil, userOpErrorf(errcodeUserOpInvalidFields, "invalid user operation: %v", err)
+	}
+	result, err := p.call(ctx, entryPoint, data, overrides)
+	if err != nil {
+		return nil, err
+	}
+	revert := result.Revert()
+	executionResult := entryPointABI.Errors["ExecutionResult"]
+	if len(revert) < 4 || string(revert[:4]) != string(executionResult.ID[:4]) {
+		return nil, revertError(result)
+	}
+	values, err := executionResult.Inputs.Unpack(revert[4:])
+	if err != nil {
+		return nil, err
+	}
+	preOpGas, paid := values[0].(*big.Int), values[1].(*big.Int)
+	callGas := new(big.Int).Sub(paid, preOpGas)
+	if callGas.Sign() < 0 {
+		callGas.SetUint64(0)
+	}
+	return &UserOperationGasEstimate{
+		VerificationGasLimit: (*hexutil.Big)(withMargin(preOpGas)),
+		CallGasLimit:         (*hexutil.Big)(withMargin(callGas)),
+	}, nil
+}
+
+// tracedUserOp is a user operation reported by the userOpTracer, with the call
+// frames made by the EntryPoint for it.
+type tracedUserOp struct {
+	Paymaster    common.Address `json:"paymaster"`
+	Success      bool           `json:"success"`
+	RevertReason hexutil.Bytes  `json:"revertReason"`
+	Validation   []tracedCall   `json:"validation"`
+	Execution    []tracedCall   `json:"execution"`
+}
+
+// tracedCall is a call frame of the callTracer.
+type tracedCall struct {
+	To      common.Address `json:"to"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Calls   []tracedCall   `json:"calls"`
+}
+
+// estimateV7 returns the gas limits of the v0.7 operation from the gas used by
+// the calls of a traced handleOps: the deployment and the validation by the
+// sender, the validation and the postOp of the paymaster, and the execution
+// by the sender.
+func (p *userOpPool) estimateV7(ctx context.Context, sim *UserOperation, entryPoint common.Address, overrides *ethapi.StateOverride) (*UserOperationGasEstimate, error) {
+	data, err := packHandleOps(entryPointV7, []*UserOperation{sim}, p.bundler)
+	if err != nil {
+		return nil, userOpErrorf(errcodeUserOpInvalidFields, "invalid user operation: %v", err)
+	}
+	result, err := p.call(ctx, entryPoint, data, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if result.Err != nil {
+		return nil, revertError(result)
+	}
+	input := hexutil.Bytes(data)
+	trace, err := p.backend.traceUserOps(ctx, ethapi.TransactionArgs{From: &p.bundler, To: &entryPoint, Input: &input}, overrides)
+	if err != nil {
+		return nil, err
+	}
+	var bundles []struct {
+		UserOps []tracedUserOp `json:"userOps"`
+	}
+	if err := json.Unmarshal(trace, &bundles); err != nil {
+		return nil, err
+	}
+	if len(bundles) != 1 || len(bundles[0].UserOps) != 1 {
+		return nil, errors.New("user operation missing from the handleOps trace")
+	}
+	traced := bundles[0].UserOps[0]
+	if !traced.Success {
+		return nil, userOpErrorf(errcodeUserOpReverted, "user operation execution reverted: %v", traced.RevertReason)
+	}
+	isPaymaster := func(address common.Address) bool {
+		return traced.Paymaster != (common.Address{}) && address == traced.Paymaster
+	}
+	verificationGas, paymasterVerificationGas := uint64(userOpV7Verification), uint64(0)
+	for _, call := range traced.Validation {
+		if isPaymaster(call.To) {
+			paymasterVerificationGas += uint64(call.GasUsed)
+		} else {
+			verificationGas += uint64(call.GasUsed)
+		}
+	}
+	// The execution is the inner call of the EntryPoint calling the sender,
+	// then the paymaster
+	var callGas, postOpGas uint64
+	for _, inner := range traced.Execution {
+		for _, call := range inner.Calls {
+			switch {
+			case call.To == sim.Sender:
+				callGas += uint64(call.GasUsed)
+			case isPaymaster(call.To):
+				postOpGas += uint64(call.GasUsed)
+			}
+		}
+	}
+	estimate := &UserOperationGasEstimate{
+		VerificationGasLimit: (*hexutil.Big)(withMargin(new(big.Int).SetUint64(verificationGas))),
+		CallGasLimit:         (*hexutil.Big)(withMargin(new(big.Int).SetUint64(callGas))),
+	}
+	if sim.Paymaster != nil {
+		estimate.PaymasterVerificationGasLimit = (*hexutil.Big)(withMargin(new(big.Int).SetUint64(paymasterVerificationGas)))
+		estimate.PaymasterPostOpGasLimit = (*hexutil.Big)(withMargin(new(big.Int).SetUint64(postOpGas)))
+	}
+	return estimate, nil
+}
+
+func withMargin(gas *big.Int) *big.Int {
+	gas = new(big.Int).Mul(gas, big.NewInt(userOpGasMargin))
+	return gas.Div(gas, big.NewInt(100))
+}
+
+// preVerificationGas returns the gas of the bundle transaction not metered by
+// the EntryPoint: the intrinsic gas of a bundle of the operation alone, and the
+// gas paying for the L1 data of that bundle. The L1 cost of the data shared by
+// the operations of a bundle is charged to each of them.
+func (p *userOpPool) preVerificationGas(ctx context.Context, op *UserOperation, entryPoint common.Address, version int) (uint64, error) {
+	data, err := packHandleOps(version, []*UserOperation{op}, p.bundler)
+	if err != nil {
+		return 0, userOpErrorf(errcodeUserOpInvalidFields, "invalid user operation: %v", err)
+	}
+	gas := uint64(userOpCallOverhead)
+	for _, b := range data {
+		if b == 0 {
+			gas += 4
+		} else {
+			gas += 16
+		}
+	}
+	l1Gas, err := p.l1Gas(ctx, entryPoint, data)
+	if err != nil {
+		return 0, err
+	}
+	return gas + l1Gas, nil
+}
+
+// l1Gas returns the gas paying for the L1 data of a transaction of the bundler
+// calling the EntryPoint with the data, at the current L2 base fee, as
+// estimated by the NodeInterface of ArbOS. It's zero out of ArbOS, where the
+// NodeInterface has no code.
+func (p *userOpPool) l1Gas(ctx context.Context, entryPoint common.Address, data []byte) (uint64, error) {
+	packed, err := nodeInterfaceABI.Pack("gasEstimateL1Component", entryPoint, false, data)
+	if err != nil {
+		return 0, err
+	}
+	input, to := hexutil.Bytes(packed), types.NodeInterfaceAddress
+	result, err := p.backend.call(ctx, ethapi.TransactionArgs{From: &p.bundler, To: &to, Input: &input}, nil)
+	if err != nil {
+		return 0, err
+	}
+	if result.Err != nil {
+		return 0, fmt.Errorf("estimating L1 gas: %w", result.Err)
+	}
+	if len(result.ReturnData) == 0 {
+		return 0, nil
+	}
+	values, err := nodeInterfaceABI.Unpack("gasEstimateL1Component", result.ReturnData)
+	if err != nil {
+		return 0, fmt.Errorf("estimating L1 gas: %w", err)
+	}
+	return values[0].(uint64), nil
+}
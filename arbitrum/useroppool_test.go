@@ -0,0 +1,525 @@
+package arbitrum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Gas used by the calls of the user operations traced by the testUserOpBackend.
+const (
+	testValidationGas          = 30000
+	testCallGas                = 50000
+	testPaymasterValidationGas = 20000
+	testPostOpGas              = 10000
+)
+
+// testUserOpBackend is a chain whose EntryPoints only check the nonces of the
+// user operations, and whose blocks are mined one bundle at a time.
+type testUserOpBackend struct {
+	config       *params.ChainConfig
+	head         *types.Header
+	nonces       map[common.Address]uint64 // EntryPoint nonces of the senders
+	l1Gas        uint64                    // L1 gas estimated by the NodeInterface
+	reverts      bool                      // whether the executions of the traced operations revert
+	bundlerNonce uint64
+	sent         []*types.Transaction
+	mined        map[common.Hash]*types.Receipt // receipts by transaction and block hash
+}
+
+func newTestUserOpBackend() *testUserOpBackend {
+	return &testUserOpBackend{
+		config: params.TestChainConfig,
+		head:   &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(params.InitialBaseFee)},
+		nonces: make(map[common.Address]uint64),
+		mined:  make(map[common.Hash]*types.Receipt),
+	}
+}
+
+func (b *testUserOpBackend) ChainConfig() *params.ChainConfig { return b.config }
+func (b *testUserOpBackend) CurrentHeader() *types.Header     { return b.head }
+
+func (b *testUserOpBackend) GetTransaction(ctx context.Context, txHash common.Hash) (bool, *types.Transaction, common.Hash, uint64, uint64, error) {
+	receipt, ok := b.mined[txHash]
+	if !ok {
+		return false, nil, common.Hash{}, 0, 0, nil
+	}
+	for _, tx := range b.sent {
+		if tx.Hash() == txHash {
+			return true, tx, receipt.BlockHash, receipt.BlockNumber.Uint64(), 0, nil
+		}
+	}
+	return false, nil, common.Hash{}, 0, 0, nil
+}
+
+func (b *testUserOpBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	if receipt, ok := b.mined[hash]; ok {
+		return types.Receipts{receipt}, nil
+	}
+	return nil, nil
+}
+
+func (b *testUserOpBackend) SendTx(ctx context.Context, tx *types.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func (b *testUserOpBackend) call(ctx context.Context, args ethapi.TransactionArgs, overrides *ethapi.StateOverride) (*core.ExecutionResult, error) {
+	if *args.To == types.NodeInterfaceAddress {
+		data, err := nodeInterfaceABI.Methods["gasEstimateL1Component"].Outputs.Pack(b.l1Gas, new(big.Int), new(big.Int))
+		return &core.ExecutionResult{ReturnData: data}, err
+	}
+	ops, err := unpackHandleOps(*args.Input)
+	if err != nil {
+		return nil, err
+	}
+	nonces := make(map[common.Address]uint64)
+	for i, op := range ops {
+		nonce, ok := nonces[op.Sender]
+		if !ok {
+			nonce = b.nonces[op.Sender]
+		}
+		if op.Nonce.ToInt().Uint64() != nonce {
+			failedOp := entryPointABI.Errors["FailedOp"]
+			data, err := failedOp.Inputs.Pack(big.NewInt(int64(i)), "AA25 invalid account nonce")
+			if err != nil {
+				return nil, err
+			}
+			return &core.ExecutionResult{Err: vm.ErrExecutionReverted, ReturnData: append(common.CopyBytes(failedOp.ID[:4]), data...)}, nil
+		}
+		nonces[op.Sender] = nonce + 1
+	}
+	return &core.ExecutionResult{}, nil
+}
+
+func (b *testUserOpBackend) traceUserOps(ctx context.Context, args ethapi.TransactionArgs, overrides *ethapi.StateOverride) (json.RawMessage, error) {
+	ops, err := unpackHandleOps(*args.Input)
+	if err != nil {
+		return nil, err
+	}
+	traced := make([]tracedUserOp, len(ops))
+	for i, op := range ops {
+		traced[i] = tracedUserOp{
+			Paymaster:  op.paymaster(),
+			Success:    !b.reverts,
+			Validation: []tracedCall{{To: op.Sender, GasUsed: testValidationGas}},
+			Execution:  []tracedCall{{To: *args.To, Calls: []tracedCall{{To: op.Sender, GasUsed: testCallGas}}}},
+		}
+		if op.Paymaster != nil {
+			traced[i].Validation = append(traced[i].Validation, tracedCall{To: *op.Paymaster, GasUsed: testPaymasterValidationGas})
+			traced[i].Execution[0].Calls = append(traced[i].Execution[0].Calls, tracedCall{To: *op.Paymaster, GasUsed: testPostOpGas})
+		}
+	}
+	return json.Marshal([]map[string]interface{}{{"entryPoint": *args.To, "userOps": traced}})
+}
+
+func (b *testUserOpBackend) estimateGas(ctx context.Context, args ethapi.TransactionArgs) (uint64, error) {
+	return 100000, nil
+}
+
+func (b *testUserOpBackend) latestNonce(ctx context.Context, address common.Address) (uint64, *types.Header, error) {
+	return b.bundlerNonce, b.head, nil
+}
+
+func (b *testUserOpBackend) filterLogs(ctx context.Context, begin, end int64, addresses []common.Address, topics [][]common.Hash) ([]*types.Log, error) {
+	return nil, nil
+}
+
+func (b *testUserOpBackend) lookupUserOp(hash common.Hash) *rawdb.UserOpLookupEntry { return nil }
+
+func (b *testUserOpBackend) transactionReceipt(ctx context.Context, txHash common.Hash) (map[string]interface{}, error) {
+	return map[string]interface{}{"transactionHash": txHash}, nil
+}
+
+// mine includes the bundle in a block of its own, emitting the events of its
+// operations unless the bundle reverts.
+func (b *testUserOpBackend) mine(t *testing.T, tx *types.Transaction, reverted bool) {
+	t.Helper()
+	ops, err := unpackHandleOps(tx.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.head = &types.Header{Number: new(big.Int).Add(b.head.Number, common.Big1), BaseFee: b.head.BaseFee}
+	receipt := &types.Receipt{TxHash: tx.Hash(), BlockHash: tx.Hash(), BlockNumber: b.head.Number}
+	event := entryPointABI.Events["UserOperationEvent"]
+	for _, op := range ops {
+		if reverted {
+			break
+		}
+		b.nonces[op.Sender]++
+		data, err := event.Inputs.NonIndexed().Pack(op.Nonce.ToInt(), true, big.NewInt(1), big.NewInt(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipt.Logs = append(receipt.Logs, &types.Log{
+			Address: *tx.To(),
+			Topics:  []common.Hash{event.ID, op.Hash(*tx.To(), entryPointV6, b.config.ChainID), common.BytesToHash(op.Sender.Bytes()), {}},
+			Data:    data,
+			TxHash:  tx.Hash(),
+			Index:   uint(len(receipt.Logs)),
+		})
+	}
+	b.mined[tx.Hash()] = receipt
+	b.bundlerNonce++
+}
+
+func newTestUserOpPool(t *testing.T) (*userOpPool, *testUserOpBackend, accounts.Account) {
+	t.Helper()
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	password := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(password, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig.UserOps
+	config.BundlerAccount = account.Address.Hex()
+	config.BundlerPasswordFile = password
+	config.Unsafe = true
+
+	backend := newTestUserOpBackend()
+	pool, err := newUserOpPool(backend, accounts.NewManager(&accounts.Config{}, ks), &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool, backend, account
+}
+
+func testUserOp(sender common.Address, nonce, fee int64) *UserOperation {
+	big := func(n int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(n)) }
+	return &UserOperation{
+		Sender:               sender,
+		Nonce:                big(nonce),
+		CallData:             hexutil.Bytes{0x01},
+		CallGasLimit:         big(100000),
+		VerificationGasLimit: big(100000),
+		PreVerificationGas:   big(50000),
+		MaxFeePerGas:         big(fee),
+		MaxPriorityFeePerGas: big(fee),
+		Signature:            hexutil.Bytes{0x02},
+	}
+}
+
+func userOpErrorCode(err error) int {
+	var opErr *userOpError
+	if errors.As(err, &opErr) {
+		return opErr.code
+	}
+	return 0
+}
+
+func TestUserOpPoolAdd(t *testing.T) {
+	pool, _, _ := newTestUserOpPool(t)
+	pool.config.MaxOpsPerSender = 2
+	var (
+		ctx        = context.Background()
+		entryPoint = params.TestChainConfig.Kinto().Contracts.EntryPoint
+		alice      = common.HexToAddress("0xa11ce")
+		bob        = common.HexToAddress("0xb0b")
+	)
+	if _, err := pool.add(ctx, testUserOp(alice, 0, 10), common.HexToAddress("0x1")); userOpErrorCode(err) != errcodeUserOpInvalidFields {
+		t.Errorf("unsupported entry point: error %v", err)
+	}
+	if _, err := pool.add(ctx, testUserOp(alice, 0, 10), entryPoint); err != nil {
+		t.Fatalf("first operation rejected: %v", err)
+	}
+	// The next nonce is simulated after the pending operation
+	hash, err := pool.add(ctx, testUserOp(alice, 1, 10), entryPoint)
+	if err != nil {
+		t.Fatalf("next operation rejected: %v", err)
+	}
+	if _, err := pool.add(ctx, testUserOp(bob, 1, 10), entryPoint); userOpErrorCode(err) != errcodeUserOpRejected {
+		t.Errorf("nonce gap: error %v, want rejection", err)
+	}
+	if _, err := pool.add(ctx, testUserOp(alice, 2, 10), entryPoint); userOpErrorCode(err) != errcodeUserOpThrottled {
+		t.Errorf("operation above the sender limit: error %v, want throttling", err)
+	}
+	// Replacements must raise the fees
+	if _, err := pool.add(ctx, testUserOp(alice, 1, 10), entryPoint); err != nil {
+		t.Errorf("same operation added again: %v", err)
+	}
+	if _, err := pool.add(ctx, testUserOp(alice, 1, 12), entryPoint); err != nil {
+		t.Errorf("replacement rejected: %v", err)
+	}
+	if _, ok := pool.pending[hash]; ok || len(pool.pending) != 2 {
+		t.Errorf("replaced operation still pending, %d operations", len(pool.pending))
+	}
+	if _, err := pool.add(ctx, testUserOp(alice, 1, 12), entryPoint); err != nil {
+		t.Errorf("same replacement added again: %v", err)
+	}
+	if _, err := pool.add(ctx, testUserOp(alice, 1, 13), entryPoint); userOpErrorCode(err) != errcodeUserOpInvalidFields {
+		t.Errorf("underpriced replacement: error %v", err)
+	}
+	pool.config.MaxPoolSize = 2
+	if _, err := pool.add(ctx, testUserOp(bob, 0, 10), entryPoint); userOpErrorCode(err) != errcodeUserOpThrottled {
+		t.Errorf("full pool: error %v, want throttling", err)
+	}
+}
+
+func TestUserOpPoolPreVerificationGas(t *testing.T) {
+	pool, backend, _ := newTestUserOpPool(t)
+	var (
+		ctx        = context.Background()
+		entryPoint = params.TestChainConfig.Kinto().Contracts.EntryPoint
+		op         = testUserOp(common.HexToAddress("0xa11ce"), 0, 10)
+	)
+	gas, err := pool.preVerificationGas(ctx, op, entryPoint, entryPointV6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The L1 data cost is charged on top of the intrinsic gas
+	backend.l1Gas = 50000
+	if withL1, err := pool.preVerificationGas(ctx, op, entryPoint, entryPointV6); err != nil || withL1 != gas+backend.l1Gas {
+		t.Errorf("preVerificationGas with L1 cost %d (%v), want %d", withL1, err, gas+backend.l1Gas)
+	}
+	if _, err := pool.add(ctx, op, entryPoint); userOpErrorCode(err) != errcodeUserOpInvalidFields {
+		t.Errorf("preVerificationGas not covering the L1 cost: error %v", err)
+	}
+	// The preVerificationGas is part of the calldata it pays for
+	op.PreVerificationGas = (*hexutil.Big)(new(big.Int).SetUint64(gas + backend.l1Gas + 100))
+	if _, err := pool.add(ctx, op, entryPoint); err != nil {
+		t.Errorf("preVerificationGas covering the L1 cost rejected: %v", err)
+	}
+}
+
+func TestUserOpPoolEstimateV7(t *testing.T) {
+	pool, backend, _ := newTestUserOpPool(t)
+	backend.l1Gas = 1000
+	var (
+		ctx        = context.Background()
+		entryPoint = params.TestChainConfig.Kinto().Contracts.EntryPointV7
+		paymaster  = common.HexToAddress("0x9a")
+		margin     = func(gas uint64) uint64 { return gas * userOpGasMargin / 100 }
+	)
+	op := testUserOp(common.HexToAddress("0xa11ce"), 0, 10)
+	estimate, err := pool.estimate(ctx, op, entryPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := estimate.VerificationGasLimit.ToInt().Uint64(), margin(userOpV7Verification+testValidationGas); have != want {
+		t.Errorf("verificationGasLimit %d, want %d", have, want)
+	}
+	if have, want := estimate.CallGasLimit.ToInt().Uint64(), margin(testCallGas); have != want {
+		t.Errorf("callGasLimit %d, want %d", have, want)
+	}
+	if estimate.PaymasterVerificationGasLimit != nil || estimate.PaymasterPostOpGasLimit != nil {
+		t.Error("paymaster gas limits estimated without paymaster")
+	}
+	if estimate.PreVerificationGas.ToInt().Uint64() <= margin(userOpCallOverhead+backend.l1Gas) {
+		t.Errorf("preVerificationGas %v without the calldata and L1 costs", estimate.PreVerificationGas)
+	}
+	op.Paymaster = &paymaster
+	if estimate, err = pool.estimate(ctx, op, entryPoint); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := estimate.PaymasterVerificationGasLimit.ToInt().Uint64(), margin(testPaymasterValidationGas); have != want {
+		t.Errorf("paymasterVerificationGasLimit %d, want %d", have, want)
+	}
+	if have, want := estimate.PaymasterPostOpGasLimit.ToInt().Uint64(), margin(testPostOpGas); have != want {
+		t.Errorf("paymasterPostOpGasLimit %d, want %d", have, want)
+	}
+	backend.reverts = true
+	if _, err := pool.estimate(ctx, op, entryPoint); userOpErrorCode(err) != errcodeUserOpReverted {
+		t.Errorf("reverted execution: error %v", err)
+	}
+}
+
+func TestUserOpPoolSelectOps(t *testing.T) {
+	pool, _, _ := newTestUserOpPool(t)
+	pool.config.MaxBundleSize = 3
+	var (
+		ctx        = context.Background()
+		entryPoint = params.TestChainConfig.Kinto().Contracts.EntryPoint
+		ops        = []*UserOperation{
+			testUserOp(common.HexToAddress("0xa"), 1, 50), // waits for the nonce 0
+			testUserOp(common.HexToAddress("0xa"), 0, 1),
+			testUserOp(common.HexToAddress("0xb"), 0, 30),
+			testUserOp(common.HexToAddress("0xc"), 0, 30),
+			testUserOp(common.HexToAddress("0xd"), 0, 40),
+			testUserOp(common.HexToAddress("0xe"), 5, 60), // invalid nonce
+		}
+		candidates []*pendingUserOp
+	)
+	for _, op := range ops {
+		pending := &pendingUserOp{op: op, hash: op.Hash(entryPoint, entryPointV6, params.TestChainConfig.ChainID), entryPoint: entryPoint, version: entryPointV6}
+		pool.pending[pending.hash] = pending
+		candidates = append(candidates, pending)
+	}
+	selected := pool.selectOps(ctx, candidates)
+	if len(selected) != 3 {
+		t.Fatalf("%d operations selected, want 3", len(selected))
+	}
+	if selected[0].op != ops[4] {
+		t.Errorf("first operation from %v, want the highest fee", selected[0].op.Sender)
+	}
+	// Equal fees are ordered by hash, whatever the order of the candidates
+	first, second := selected[1], selected[2]
+	reversed := make([]*pendingUserOp, len(candidates))
+	for i, pending := range candidates {
+		reversed[len(candidates)-1-i] = pending
+	}
+	if again := pool.selectOps(ctx, reversed); again[1] != first || again[2] != second {
+		t.Error("selection depends on the order of the candidates")
+	}
+	if _, ok := pool.pending[candidates[5].hash]; ok {
+		t.Error("invalid operation not dropped")
+	}
+	// The lowest nonce of a sender is selected, whatever its fee
+	pool.config.MaxBundleSize = 10
+	for _, pending := range pool.selectOps(ctx, candidates[:5]) {
+		if pending.op == ops[0] {
+			t.Error("operation selected before the preceding one of its sender")
+		}
+	}
+}
+
+func TestUserOpPoolBundle(t *testing.T) {
+	pool, backend, account := newTestUserOpPool(t)
+	var (
+		ctx        = context.Background()
+		api        = NewUserOperationAPI(pool)
+		entryPoint = params.TestChainConfig.Kinto().Contracts.EntryPoint
+		alice      = common.HexToAddress("0xa11ce")
+		bob        = common.HexToAddress("0xb0b")
+		hashes     []common.Hash
+	)
+	for _, op := range []*UserOperation{testUserOp(alice, 0, 10), testUserOp(alice, 1, 10), testUserOp(bob, 0, 10)} {
+		hash, err := api.SendUserOperation(ctx, *op, entryPoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+	if err := pool.bundle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.sent) != 1 {
+		t.Fatalf("%d bundles sent, want 1", len(backend.sent))
+	}
+	tx := backend.sent[0]
+	if sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); err != nil || sender != account.Address {
+		t.Errorf("bundle signed by %v (%v), want %v", sender, err, account.Address)
+	}
+	if ops, _ := unpackHandleOps(tx.Data()); len(ops) != 2 {
+		t.Errorf("%d operations bundled, want 2", len(ops))
+	}
+	// The operations stay pending until mined
+	if len(pool.pending) != 3 {
+		t.Errorf("%d operations pending after the bundle, want 3", len(pool.pending))
+	}
+	if op, err := api.GetUserOperationByHash(ctx, hashes[0]); err != nil || op == nil || op.TransactionHash != nil {
+		t.Errorf("bundled operation %+v (%v), want pending", op, err)
+	}
+	if err := pool.bundle(ctx); err != nil || len(backend.sent) != 1 {
+		t.Fatalf("bundle sent before the previous one is mined (%v)", err)
+	}
+
+	backend.mine(t, tx, false)
+	if err := pool.bundle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(pool.pending) != 1 {
+		t.Errorf("%d operations pending after the bundle is mined, want 1", len(pool.pending))
+	}
+	if len(backend.sent) != 2 {
+		t.Fatalf("%d bundles sent, want the following operation's", len(backend.sent))
+	}
+	if ops, _ := unpackHandleOps(backend.sent[1].Data()); len(ops) != 1 || ops[0].Nonce.ToInt().Uint64() != 1 {
+		t.Errorf("second bundle operations %v", ops)
+	}
+	op, err := api.GetUserOperationByHash(ctx, hashes[0])
+	if err != nil || op == nil || op.TransactionHash == nil || *op.TransactionHash != tx.Hash() {
+		t.Fatalf("mined operation %+v (%v), want included in %v", op, err, tx.Hash())
+	}
+	receipt, err := api.GetUserOperationReceipt(ctx, hashes[2])
+	if err != nil || receipt == nil || !receipt.Success || receipt.Sender != bob {
+		t.Errorf("receipt %+v (%v)", receipt, err)
+	}
+}
+
+func TestUserOpPoolRebundle(t *testing.T) {
+	pool, backend, _ := newTestUserOpPool(t)
+	var (
+		ctx        = context.Background()
+		entryPoint = params.TestChainConfig.Kinto().Contracts.EntryPoint
+	)
+	hash, err := pool.add(ctx, testUserOp(common.HexToAddress("0xa11ce"), 0, 10), entryPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.bundle(ctx); err != nil || len(backend.sent) != 1 {
+		t.Fatalf("bundle not sent (%v)", err)
+	}
+	// An unmined bundle is sent again after the timeout, with the same nonce
+	expired := time.Now().Add(-userOpBundleTimeout)
+	pool.pending[hash].submitted, pool.lastBundle = expired, expired
+	if err := pool.bundle(ctx); err != nil || len(backend.sent) != 2 {
+		t.Fatalf("expired bundle not sent again (%v)", err)
+	}
+	if backend.sent[1].Nonce() != backend.sent[0].Nonce() {
+		t.Errorf("bundle sent again with nonce %d, want %d", backend.sent[1].Nonce(), backend.sent[0].Nonce())
+	}
+	// A mined bundle without the operation's event bundles it again
+	backend.mine(t, backend.sent[1], true)
+	if err := pool.bundle(ctx); err != nil || len(backend.sent) != 3 {
+		t.Fatalf("operation of a reverted bundle not sent again (%v)", err)
+	}
+	if pending := pool.pending[hash]; pending == nil || pending.bundle != backend.sent[2].Hash() {
+		t.Error("operation not pending in the last bundle")
+	}
+}
+
+func TestUserOpPoolBundlerAccount(t *testing.T) {
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	am := accounts.NewManager(&accounts.Config{}, ks)
+	config := DefaultConfig.UserOps
+
+	config.BundlerAccount = account.Address.Hex()
+	if _, err := newUserOpPool(newTestUserOpBackend(), am, &config); err == nil {
+		t.Error("pool enabled without the unsafe mode")
+	}
+	config.Unsafe = true
+	config.BundlerAccount = "0x1234"
+	if _, err := newUserOpPool(newTestUserOpBackend(), am, &config); err == nil {
+		t.Error("invalid bundler account accepted")
+	}
+	config.BundlerAccount = common.HexToAddress("0x1234").Hex()
+	if _, err := newUserOpPool(newTestUserOpBackend(), am, &config); !errors.Is(err, accounts.ErrUnknownAccount) {
+		t.Errorf("unknown bundler account: error %v", err)
+	}
+	// A locked account without password can't sign the bundles
+	config.BundlerAccount = account.Address.Hex()
+	backend := newTestUserOpBackend()
+	pool, err := newUserOpPool(backend, am, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.add(context.Background(), testUserOp(common.HexToAddress("0xa11ce"), 0, 10), params.TestChainConfig.Kinto().Contracts.EntryPoint); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.bundle(context.Background()); !errors.Is(err, keystore.ErrLocked) || len(backend.sent) != 0 {
+		t.Errorf("bundle of a locked account: error %v, %d sent", err, len(backend.sent))
+	}
+}
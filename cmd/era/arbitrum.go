@@ -0,0 +1,251 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/era/arbera"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	chainDataFlag = &cli.StringFlag{
+		Name:     "chaindata",
+		Usage:    "path of the (stopped) node's chaindata database to export from",
+		Required: true,
+	}
+	genesisFlag = &cli.Uint64Flag{
+		Name:  "genesis",
+		Usage: "Nitro genesis block number of the chain, used to locate its chain config",
+	}
+)
+
+var (
+	arbBlockCommand = &cli.Command{
+		Name:      "arb-block",
+		Usage:     "get block data from arbera files",
+		ArgsUsage: "<number>",
+		Action:    arbBlock,
+		Flags: []cli.Flag{
+			txsFlag,
+		},
+	}
+	arbInfoCommand = &cli.Command{
+		Name:      "arb-info",
+		ArgsUsage: "<epoch>",
+		Usage:     "get arbera epoch information",
+		Action:    arbInfo,
+	}
+	arbVerifyCommand = &cli.Command{
+		Name:   "arb-verify",
+		Usage:  "verifies each arbera file against its checksum and accumulator",
+		Action: arbVerify,
+	}
+	arbExportCommand = &cli.Command{
+		Name:      "arb-export",
+		ArgsUsage: "<first> <last>",
+		Usage:     "exports Nitro blocks and receipts from a database into arbera files",
+		Action:    arbExport,
+		Flags: []cli.Flag{
+			chainDataFlag,
+			genesisFlag,
+		},
+	}
+)
+
+// arbBlock prints the specified block from an arbera store.
+func arbBlock(ctx *cli.Context) error {
+	num, err := strconv.ParseUint(ctx.Args().First(), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block number: %w", err)
+	}
+	e, err := openArb(ctx, num/uint64(arbera.MaxSize))
+	if err != nil {
+		return fmt.Errorf("error opening arbera: %w", err)
+	}
+	defer e.Close()
+	block, err := e.GetBlockByNumber(num)
+	if err != nil {
+		return fmt.Errorf("error reading block %d: %w", num, err)
+	}
+	id, err := e.ChainID()
+	if err != nil {
+		return fmt.Errorf("error reading chain id: %w", err)
+	}
+	config := &params.ChainConfig{ChainID: id}
+	val := ethapi.RPCMarshalBlock(block, ctx.Bool(txsFlag.Name), ctx.Bool(txsFlag.Name), config)
+	b, err := json.MarshalIndent(val, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling json: %w", err)
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// arbInfo prints some high-level information about the arbera file.
+func arbInfo(ctx *cli.Context) error {
+	epoch, err := strconv.ParseUint(ctx.Args().First(), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid epoch number: %w", err)
+	}
+	e, err := openArb(ctx, epoch)
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+	acc, err := e.Accumulator()
+	if err != nil {
+		return fmt.Errorf("error reading accumulator: %w", err)
+	}
+	id, err := e.ChainID()
+	if err != nil {
+		return fmt.Errorf("error reading chain id: %w", err)
+	}
+	info := struct {
+		Accumulator common.Hash `json:"accumulator"`
+		ChainID     *big.Int    `json:"chainId"`
+		StartBlock  uint64      `json:"startBlock"`
+		Count       uint64      `json:"count"`
+	}{
+		acc, id, e.Start(), e.Count(),
+	}
+	b, _ := json.MarshalIndent(info, "", "  ")
+	fmt.Println(string(b))
+	return nil
+}
+
+// arbVerify checks each arbera file in a directory to ensure it is well-formed
+// and matches its checksum.
+func arbVerify(ctx *cli.Context) error {
+	return utils.VerifyArbitrumHistory(ctx.String(dirFlag.Name), ctx.String(networkFlag.Name))
+}
+
+// arbExport exports a block range of a stopped node's database into arbera
+// files. Importing is only supported through geth, since it requires the full
+// header chain validation.
+func arbExport(ctx *cli.Context) error {
+	if ctx.Args().Len() != 2 {
+		return fmt.Errorf("usage: %s", ctx.Command.ArgsUsage)
+	}
+	first, ferr := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	last, lerr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	if ferr != nil || lerr != nil {
+		return fmt.Errorf("invalid block range: block number not an integer")
+	}
+	chaindata := ctx.String(chainDataFlag.Name)
+	db, err := rawdb.Open(rawdb.OpenOptions{
+		Directory:         chaindata,
+		AncientsDirectory: filepath.Join(chaindata, "ancient"),
+		ReadOnly:          true,
+	})
+	if err != nil {
+		return fmt.Errorf("error opening database: %w", err)
+	}
+	defer db.Close()
+
+	source, err := newRawHistory(db, ctx.Uint64(genesisFlag.Name))
+	if err != nil {
+		return err
+	}
+	return utils.ExportArbitrumHistory(source, ctx.String(dirFlag.Name), first, last, uint64(arbera.MaxSize))
+}
+
+// openArb opens an arbera file at a certain epoch.
+func openArb(ctx *cli.Context, epoch uint64) (*arbera.Era, error) {
+	var (
+		dir     = ctx.String(dirFlag.Name)
+		network = ctx.String(networkFlag.Name)
+	)
+	entries, err := arbera.ReadDir(dir, network)
+	if err != nil {
+		return nil, fmt.Errorf("error reading arbera dir: %w", err)
+	}
+	for _, entry := range entries {
+		e, err := arbera.Open(path.Join(dir, entry))
+		if err != nil {
+			return nil, err
+		}
+		if e.Start()/uint64(arbera.MaxSize) == epoch {
+			return e, nil
+		}
+		e.Close()
+	}
+	return nil, fmt.Errorf("epoch %d not found", epoch)
+}
+
+// rawHistory reads the canonical chain straight out of a database, so that
+// history can be exported without starting a node.
+type rawHistory struct {
+	db     ethdb.Database
+	config *params.ChainConfig
+}
+
+func newRawHistory(db ethdb.Database, genesis uint64) (*rawHistory, error) {
+	hash := rawdb.ReadCanonicalHash(db, genesis)
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("genesis block %d not found", genesis)
+	}
+	config := rawdb.ReadChainConfig(db, hash)
+	if config == nil {
+		return nil, fmt.Errorf("chain config not found for genesis %d [%x]", genesis, hash)
+	}
+	return &rawHistory{db: db, config: config}, nil
+}
+
+func (h *rawHistory) Config() *params.ChainConfig { return h.config }
+
+func (h *rawHistory) CurrentBlock() *types.Header {
+	hash := rawdb.ReadHeadBlockHash(h.db)
+	number := rawdb.ReadHeaderNumber(h.db, hash)
+	if number == nil {
+		return &types.Header{Number: new(big.Int)}
+	}
+	return rawdb.ReadHeader(h.db, hash, *number)
+}
+
+func (h *rawHistory) GetBlockByNumber(number uint64) *types.Block {
+	hash := rawdb.ReadCanonicalHash(h.db, number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return rawdb.ReadBlock(h.db, hash, number)
+}
+
+func (h *rawHistory) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	number := rawdb.ReadHeaderNumber(h.db, hash)
+	if number == nil {
+		return nil
+	}
+	header := rawdb.ReadHeader(h.db, hash, *number)
+	if header == nil {
+		return nil
+	}
+	return rawdb.ReadReceipts(h.db, hash, *number, header.Time, h.config)
+}
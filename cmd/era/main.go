@@ -89,6 +89,10 @@ func init() {
 		blockCommand,
 		infoCommand,
 		verifyCommand,
+		arbBlockCommand,
+		arbInfoCommand,
+		arbVerifyCommand,
+		arbExportCommand,
 	}
 	app.Flags = []cli.Flag{
 		dirFlag,
@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/holiman/uint256"
@@ -91,6 +92,8 @@ type stEnv struct {
 	ParentExcessBlobGas   *uint64                             `json:"parentExcessBlobGas,omitempty"`
 	ParentBlobGasUsed     *uint64                             `json:"parentBlobGasUsed,omitempty"`
 	ParentBeaconBlockRoot *common.Hash                        `json:"parentBeaconBlockRoot"`
+	L1BlockNumber         *uint64                             `json:"currentL1BlockNumber,omitempty"`
+	ArbOSVersion          *uint64                             `json:"currentArbOSVersion,omitempty"`
 }
 
 type stEnvMarshaling struct {
@@ -109,6 +112,8 @@ type stEnvMarshaling struct {
 	ExcessBlobGas       *math.HexOrDecimal64
 	ParentExcessBlobGas *math.HexOrDecimal64
 	ParentBlobGasUsed   *math.HexOrDecimal64
+	L1BlockNumber       *math.HexOrDecimal64
+	ArbOSVersion        *math.HexOrDecimal64
 }
 
 type rejectedTx struct {
@@ -120,9 +125,6 @@ type rejectedTx struct {
 func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 	txIt txIterator, miningReward int64,
 	getTracerFn func(txIndex int, txHash common.Hash) (vm.EVMLogger, error)) (*state.StateDB, *ExecutionResult, []byte, error) {
-	if chainConfig.IsArbitrum() {
-		return nil, nil, nil, NewError(ErrorConfig, fmt.Errorf("chain config has arbitrum enabled"))
-	}
 	// Capture errors for BLOCKHASH operation, if we haven't been supplied the
 	// required blockhashes
 	var hashError error
@@ -169,6 +171,16 @@ func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 		rnd := common.BigToHash(pre.Env.Random)
 		vmContext.Random = &rnd
 	}
+	// Arbitrum: the ArbOS version selects the EVM rules, and the difficulty is
+	// the random value from the first ArbOS version on, like in
+	// core.NewEVMBlockContext.
+	if chainConfig.IsArbitrum() {
+		vmContext.ArbOSVersion = *pre.Env.ArbOSVersion
+		if vmContext.ArbOSVersion > 0 {
+			rnd := common.BigToHash(vmContext.Difficulty)
+			vmContext.Random = &rnd
+		}
+	}
 	// Calculate the BlobBaseFee
 	var excessBlobGas uint64
 	if pre.Env.ExcessBlobGas != nil {
@@ -191,6 +203,7 @@ func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 		chainConfig.DAOForkBlock.Cmp(new(big.Int).SetUint64(pre.Env.Number)) == 0 {
 		misc.ApplyDAOHardFork(statedb)
 	}
+	core.ApplyIrregularStateChanges(chainConfig, vmContext.BlockNumber, statedb)
 	if beaconRoot := pre.Env.ParentBeaconBlockRoot; beaconRoot != nil {
 		evm := vm.NewEVM(vmContext, vm.TxContext{}, statedb, chainConfig, vmConfig)
 		core.ProcessBeaconBlockRoot(*beaconRoot, evm, statedb)
@@ -238,6 +251,9 @@ func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 			prevGas   = gaspool.Gas()
 		)
 		evm := vm.NewEVM(vmContext, txContext, statedb, chainConfig, vmConfig)
+		if chainConfig.IsArbitrum() {
+			evm.ProcessingHook = tests.L1BlockTxProcessor{TxProcessingHook: evm.ProcessingHook, L1Block: *pre.Env.L1BlockNumber}
+		}
 
 		// (ret []byte, usedGas uint64, failed bool, err error)
 		msgResult, err := core.ApplyMessage(evm, msg, gaspool)
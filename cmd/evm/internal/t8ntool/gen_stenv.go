@@ -37,6 +37,8 @@ func (s stEnv) MarshalJSON() ([]byte, error) {
 		ParentExcessBlobGas   *math.HexOrDecimal64                `json:"parentExcessBlobGas,omitempty"`
 		ParentBlobGasUsed     *math.HexOrDecimal64                `json:"parentBlobGasUsed,omitempty"`
 		ParentBeaconBlockRoot *common.Hash                        `json:"parentBeaconBlockRoot"`
+		L1BlockNumber         *math.HexOrDecimal64                `json:"currentL1BlockNumber,omitempty"`
+		ArbOSVersion          *math.HexOrDecimal64                `json:"currentArbOSVersion,omitempty"`
 	}
 	var enc stEnv
 	enc.Coinbase = common.UnprefixedAddress(s.Coinbase)
@@ -59,6 +61,8 @@ func (s stEnv) MarshalJSON() ([]byte, error) {
 	enc.ParentExcessBlobGas = (*math.HexOrDecimal64)(s.ParentExcessBlobGas)
 	enc.ParentBlobGasUsed = (*math.HexOrDecimal64)(s.ParentBlobGasUsed)
 	enc.ParentBeaconBlockRoot = s.ParentBeaconBlockRoot
+	enc.L1BlockNumber = (*math.HexOrDecimal64)(s.L1BlockNumber)
+	enc.ArbOSVersion = (*math.HexOrDecimal64)(s.ArbOSVersion)
 	return json.Marshal(&enc)
 }
 
@@ -85,6 +89,8 @@ func (s *stEnv) UnmarshalJSON(input []byte) error {
 		ParentExcessBlobGas   *math.HexOrDecimal64                `json:"parentExcessBlobGas,omitempty"`
 		ParentBlobGasUsed     *math.HexOrDecimal64                `json:"parentBlobGasUsed,omitempty"`
 		ParentBeaconBlockRoot *common.Hash                        `json:"parentBeaconBlockRoot"`
+		L1BlockNumber         *math.HexOrDecimal64                `json:"currentL1BlockNumber,omitempty"`
+		ArbOSVersion          *math.HexOrDecimal64                `json:"currentArbOSVersion,omitempty"`
 	}
 	var dec stEnv
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -154,5 +160,11 @@ func (s *stEnv) UnmarshalJSON(input []byte) error {
 	if dec.ParentBeaconBlockRoot != nil {
 		s.ParentBeaconBlockRoot = dec.ParentBeaconBlockRoot
 	}
+	if dec.L1BlockNumber != nil {
+		s.L1BlockNumber = (*uint64)(dec.L1BlockNumber)
+	}
+	if dec.ArbOSVersion != nil {
+		s.ArbOSVersion = (*uint64)(dec.ArbOSVersion)
+	}
 	return nil
 }
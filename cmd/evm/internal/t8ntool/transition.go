@@ -182,6 +182,9 @@ func Transition(ctx *cli.Context) error {
 	if err := applyCancunChecks(&prestate.Env, chainConfig); err != nil {
 		return err
 	}
+	if err := applyArbitrumChecks(&prestate.Env, chainConfig); err != nil {
+		return err
+	}
 	// Run the test and aggregate the result
 	s, result, body, err := prestate.Apply(vmConfig, chainConfig, txIt, ctx.Int64(RewardFlag.Name), getTracer)
 	if err != nil {
@@ -271,6 +274,21 @@ func applyCancunChecks(env *stEnv, chainConfig *params.ChainConfig) error {
 	return nil
 }
 
+func applyArbitrumChecks(env *stEnv, chainConfig *params.ChainConfig) error {
+	if !chainConfig.IsArbitrum() {
+		return nil
+	}
+	// The NUMBER opcode returns the L1 block number on Arbitrum
+	if env.L1BlockNumber == nil {
+		return NewError(ErrorConfig, errors.New("Arbitrum config but missing 'currentL1BlockNumber' in env section"))
+	}
+	if env.ArbOSVersion == nil {
+		version := chainConfig.ArbitrumChainParams.InitialArbOSVersion
+		env.ArbOSVersion = &version
+	}
+	return nil
+}
+
 type Alloc map[common.Address]types.Account
 
 func (g Alloc) OnRoot(common.Hash) {}
@@ -284,6 +284,22 @@ func TestT8n(t *testing.T) {
 			output: t8nOutput{alloc: true, result: true},
 			expOut: "exp.json",
 		},
+		{ // Arbitrum test, with the L1 block number and the Kinto rules
+			base: "./testdata/31",
+			input: t8nInput{
+				"alloc.json", "txs.json", "env.json", "Arbitrum", "",
+			},
+			output: t8nOutput{alloc: true, result: true},
+			expOut: "exp.json",
+		},
+		{ // Arbitrum test where input is missing the L1 block number
+			base: "./testdata/31",
+			input: t8nInput{
+				"alloc.json", "txs.json", "env-missingl1block.json", "Arbitrum", "",
+			},
+			output:      t8nOutput{alloc: false, result: false},
+			expExitCode: 3,
+		},
 	} {
 		args := []string{"t8n"}
 		args = append(args, tc.output.get()...)
@@ -321,6 +337,54 @@ func TestT8n(t *testing.T) {
 	}
 }
 
+func TestStatetest(t *testing.T) {
+	t.Parallel()
+	tt := new(testT8n)
+	tt.TestCmd = cmdtest.NewTestCmd(t, tt)
+	for i, tc := range []struct {
+		base   string
+		input  string
+		expOut string
+	}{
+		{ // Arbitrum test calling a Kinto contract
+			base:   "./testdata/32",
+			input:  "statetest.json",
+			expOut: "exp.json",
+		},
+		{ // Arbitrum test rejected by the Kinto rules
+			base:   "./testdata/32",
+			input:  "statetest-rejected.json",
+			expOut: "exp-rejected.json",
+		},
+		{ // Arbitrum test where the env is missing the L1 block number
+			base:   "./testdata/32",
+			input:  "statetest-missingl1block.json",
+			expOut: "exp-missingl1block.json",
+		},
+	} {
+		args := []string{"statetest", fmt.Sprintf("%v/%v", tc.base, tc.input)}
+		tt.Run("evm-test", args...)
+		tt.Logf("args:\n go run . %v\n", strings.Join(args, " "))
+		// Compare the expected output
+		want, err := os.ReadFile(fmt.Sprintf("%v/%v", tc.base, tc.expOut))
+		if err != nil {
+			t.Fatalf("test %d: could not read expected output: %v", i, err)
+		}
+		have := tt.Output()
+		ok, err := cmpJson(have, want)
+		switch {
+		case err != nil:
+			t.Fatalf("test %d: json parsing failed: %v", i, err)
+		case !ok:
+			t.Fatalf("test %d: output wrong, have \n%v\nwant\n%v\n", i, string(have), string(want))
+		}
+		tt.WaitExit()
+		if have := tt.ExitStatus(); have != 0 {
+			t.Fatalf("test %d: wrong exit code, have %d, want 0", i, have)
+		}
+	}
+}
+
 type t9nInput struct {
 	inTxs  string
 	stFork string
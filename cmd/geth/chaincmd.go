@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/internal/era/arbera"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -150,6 +151,57 @@ from Era archives.
 		Description: `
 The export-history command will export blocks and their corresponding receipts
 into Era archives. Eras are typically packaged in steps of 8192 blocks.
+`,
+	}
+	importArbHistoryCommand = &cli.Command{
+		Action:    importArbHistory,
+		Name:      "import-arb-history",
+		Usage:     "Import an ArbEra archive of Nitro history",
+		ArgsUsage: "<dir>",
+		Flags: flags.Merge([]cli.Flag{
+			utils.TxLookupLimitFlag,
+		},
+			utils.DatabaseFlags,
+		),
+		Description: `
+The import-arb-history command will import Nitro blocks and their corresponding
+receipts from ArbEra archives. Each archive is verified before it is imported.
+`,
+	}
+	exportArbHistoryCommand = &cli.Command{
+		Action:    exportArbHistory,
+		Name:      "export-arb-history",
+		Usage:     "Export Nitro blockchain history to ArbEra archives",
+		ArgsUsage: "<dir> <first> <last>",
+		Flags:     flags.Merge(utils.DatabaseFlags),
+		Description: `
+The export-arb-history command will export Nitro blocks and their corresponding
+receipts, including the Arbitrum specific receipt fields, into ArbEra archives.
+Archives are aligned on epochs of 8192 blocks.
+`,
+	}
+	verifyArbHistoryCommand = &cli.Command{
+		Action:    verifyArbHistory,
+		Name:      "verify-arb-history",
+		Usage:     "Verify ArbEra archives against their checksums and accumulators",
+		ArgsUsage: "<dir> <network>",
+		Description: `
+The verify-arb-history command checks every ArbEra archive of the given network
+in a directory: checksums, transaction and receipt roots, block linkage and
+the accumulator.
+`,
+	}
+	verifyIrregularStateCommand = &cli.Command{
+		Action: verifyIrregularState,
+		Name:   "verify-irregular-state",
+		Usage:  "Verify the irregular state changes of the chain against the database",
+		Flags:  flags.Merge([]cli.Flag{utils.CacheFlag}, utils.DatabaseFlags),
+		Description: `
+The verify-irregular-state command checks that the code hashes and storage of
+the accounts changed outside of transactions, like the bytecode replacements of
+the Kinto hardforks, are the expected ones in the state of the blocks of the
+changes, and for the latest change of every account in the head state.
+The states of the past blocks are only available on archive nodes.
 `,
 	}
 	importPreimagesCommand = &cli.Command{
@@ -491,6 +543,124 @@ func exportHistory(ctx *cli.Context) error {
 	return nil
 }
 
+func importArbHistory(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("usage: %s", ctx.Command.ArgsUsage)
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, false)
+	defer db.Close()
+
+	start := time.Now()
+	if err := utils.ImportArbitrumHistory(chain, ctx.Args().Get(0), utils.ArbitrumHistoryNetwork(chain.Config())); err != nil {
+		return err
+	}
+	fmt.Printf("Import done in %v\n", time.Since(start))
+	return nil
+}
+
+// exportArbHistory exports Nitro chain history in ArbEra archives at a
+// specified directory.
+func exportArbHistory(ctx *cli.Context) error {
+	if ctx.Args().Len() != 3 {
+		utils.Fatalf("usage: %s", ctx.Command.ArgsUsage)
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, _ := utils.MakeChain(ctx, stack, true)
+	start := time.Now()
+
+	var (
+		dir         = ctx.Args().Get(0)
+		first, ferr = strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		last, lerr  = strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+	)
+	if ferr != nil || lerr != nil {
+		utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+	}
+	if head := chain.CurrentBlock(); last > head.Number.Uint64() {
+		utils.Fatalf("Export error: block number %d larger than head block %d\n", last, head.Number.Uint64())
+	}
+	if err := utils.ExportArbitrumHistory(chain, dir, first, last, uint64(arbera.MaxSize)); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+// verifyArbHistory verifies the ArbEra archives of a network in a directory.
+func verifyArbHistory(ctx *cli.Context) error {
+	if ctx.Args().Len() != 2 {
+		utils.Fatalf("usage: %s", ctx.Command.ArgsUsage)
+	}
+	start := time.Now()
+	if err := utils.VerifyArbitrumHistory(ctx.Args().Get(0), ctx.Args().Get(1)); err != nil {
+		return err
+	}
+	fmt.Printf("Verification done in %v\n", time.Since(start))
+	return nil
+}
+
+func verifyIrregularState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, _ := utils.MakeChain(ctx, stack, true)
+	defer chain.Stop()
+
+	var (
+		head     = chain.CurrentBlock()
+		changes  = core.IrregularStateChanges(chain.Config())
+		latest   = make(map[common.Address]*core.IrregularStateChange)
+		failures int
+	)
+	for i := range changes {
+		change := &changes[i]
+		if change.Block > head.Number.Uint64() {
+			log.Info("Irregular state change pending", "change", change.Name, "block", change.Block)
+			continue
+		}
+		if prev := latest[change.Address]; prev == nil || prev.Block <= change.Block {
+			latest[change.Address] = change
+		}
+		header := chain.GetHeaderByNumber(change.Block)
+		if header == nil {
+			return fmt.Errorf("header of block %d not found", change.Block)
+		}
+		statedb, err := chain.StateAt(header.Root)
+		if err != nil {
+			log.Warn("State of irregular state change unavailable", "change", change.Name, "block", change.Block, "err", err)
+			continue
+		}
+		if err := change.Verify(statedb); err != nil {
+			log.Error("Irregular state change mismatch", "block", change.Block, "err", err)
+			failures++
+			continue
+		}
+		log.Info("Irregular state change verified", "change", change.Name, "block", change.Block, "address", change.Address)
+	}
+	statedb, err := chain.StateAt(head.Root)
+	if err != nil {
+		return fmt.Errorf("head state unavailable: %v", err)
+	}
+	for _, change := range latest {
+		if err := change.Verify(statedb); err != nil {
+			log.Error("Irregular state change mismatch in head state", "block", head.Number, "err", err)
+			failures++
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d irregular state changes differ", failures)
+	}
+	fmt.Printf("Verified %d irregular state changes at head block %d\n", len(latest), head.Number)
+	return nil
+}
+
 // importPreimages imports preimage data from the specified file.
 // it is deprecated, and the export function has been removed, but
 // the import function is kept around for the time being so that
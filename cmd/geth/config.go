@@ -18,6 +18,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -43,6 +44,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/naoina/toml"
 	"github.com/urfave/cli/v2"
 )
@@ -56,6 +58,16 @@ var (
 		Flags:       flags.Merge(nodeFlags, rpcFlags),
 		Description: `Export configuration values in TOML format (to stdout by default).`,
 	}
+	openRPCCommand = &cli.Command{
+		Action:    dumpOpenRPC,
+		Name:      "openrpc",
+		Usage:     "Export the OpenRPC document of the RPC API",
+		ArgsUsage: "<dumpfile (optional)>",
+		Flags:     flags.Merge(nodeFlags, rpcFlags),
+		Description: `Export the OpenRPC document describing the methods of the RPC API, as served
+by rpc_discover, in JSON format (to stdout by default). The methods of all the
+modules are documented, regardless of the modules exposed by the endpoints.`,
+	}
 
 	configFileFlag = &cli.StringFlag{
 		Name:     "config",
@@ -259,6 +271,36 @@ func dumpConfig(ctx *cli.Context) error {
 	return nil
 }
 
+// dumpOpenRPC is the openrpc command.
+func dumpOpenRPC(ctx *cli.Context) error {
+	stack, _ := makeFullNode(ctx)
+	defer stack.Close()
+
+	srv := rpc.NewServer()
+	defer srv.Stop()
+	if err := node.RegisterApis(stack.APIs(), nil, srv); err != nil {
+		return err
+	}
+	doc := srv.OpenRPC()
+	doc.Info.Title = "Geth JSON-RPC API"
+	doc.Info.Version = stack.Config().Version
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	dump := os.Stdout
+	if ctx.NArg() > 0 {
+		dump, err = os.OpenFile(ctx.Args().Get(0), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		defer dump.Close()
+	}
+	_, err = dump.Write(append(out, '\n'))
+	return err
+}
+
 func applyMetricConfig(ctx *cli.Context, cfg *gethConfig) {
 	if ctx.IsSet(utils.MetricsEnabledFlag.Name) {
 		cfg.Metrics.Enabled = ctx.Bool(utils.MetricsEnabledFlag.Name)
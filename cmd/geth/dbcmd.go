@@ -18,6 +18,8 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -31,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/console/prompt"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -51,6 +54,10 @@ var (
 		Name:  "remove.chain",
 		Usage: "If set, selects the state data for removal",
 	}
+	inspectJSONFlag = &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the inspection report as JSON instead of a table",
+	}
 
 	removedbCommand = &cli.Command{
 		Action:    removeDB,
@@ -79,6 +86,8 @@ Remove blockchain and state databases`,
 			dbExportCmd,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
+			dbIndexLogsCmd,
+			dbIndexUserOpsCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -87,9 +96,11 @@ Remove blockchain and state databases`,
 		ArgsUsage: "<prefix> <start>",
 		Flags: flags.Merge([]cli.Flag{
 			utils.SyncModeFlag,
+			inspectJSONFlag,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
-		Usage:       "Inspect the storage size for each type of data in the database",
-		Description: `This commands iterates the entire database. If the optional 'prefix' and 'start' arguments are provided, then the iteration is limited to the given subset of data.`,
+		Usage: "Inspect the storage size for each type of data in the database",
+		Description: `This commands iterates the entire database. If the optional 'prefix' and 'start' arguments are provided, then the iteration is limited to the given subset of data.
+With --json the report is printed as a single JSON object, suitable for tracking the database growth over time.`,
 	}
 	dbCheckStateContentCmd = &cli.Command{
 		Action:    checkStateContent,
@@ -100,6 +111,29 @@ Remove blockchain and state databases`,
 		Description: `This command iterates the entire database for 32-byte keys, looking for rlp-encoded trie nodes.
 For each trie node encountered, it checks that the key corresponds to the keccak256(value). If this is not true, this indicates
 a data corruption.`,
+	}
+	dbIndexLogsCmd = &cli.Command{
+		Action:    dbIndexLogs,
+		Name:      "index-logs",
+		ArgsUsage: "<from (optional)>",
+		Flags:     flags.Merge(utils.NetworkFlags, utils.DatabaseFlags),
+		Usage:     "Backfill the exact log index for existing history",
+		Description: `This command indexes the log addresses and topics of the canonical blocks from the
+given block number (genesis by default) up to the oldest block already indexed, or up to the
+chain head if the log index doesn't exist yet. The node keeps the index up to date on its own
+once the log index is enabled. The command can be interrupted and resumed later.`,
+	}
+	dbIndexUserOpsCmd = &cli.Command{
+		Action:    dbIndexUserOps,
+		Name:      "index-userops",
+		ArgsUsage: "<from (optional)>",
+		Flags:     flags.Merge(utils.NetworkFlags, utils.DatabaseFlags),
+		Usage:     "Backfill the user operation index for existing history",
+		Description: `This command indexes the ERC-4337 user operations included in the canonical blocks
+from the given block number (genesis by default) up to the oldest block already indexed, or up
+to the chain head if the user operation index doesn't exist yet. The node keeps the index up to
+date on its own once the user operation index is enabled. The command can be interrupted and
+resumed later.`,
 	}
 	dbStatCmd = &cli.Command{
 		Action: dbStats,
@@ -318,7 +352,14 @@ func inspect(ctx *cli.Context) error {
 	db := utils.MakeChainDatabase(ctx, stack, true)
 	defer db.Close()
 
-	return rawdb.InspectDatabase(db, prefix, start)
+	if !ctx.Bool(inspectJSONFlag.Name) {
+		return rawdb.InspectDatabase(db, prefix, start)
+	}
+	report, err := rawdb.InspectDatabaseReport(db, prefix, start)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(report)
 }
 
 func checkStateContent(ctx *cli.Context) error {
@@ -633,6 +674,56 @@ func importLDBdata(ctx *cli.Context) error {
 	return utils.ImportLDBData(db, fName, int64(start), stop)
 }
 
+func dbIndexLogs(ctx *cli.Context) error {
+	return indexHistory(ctx, "log", func(db ethdb.Database, from uint64, stop chan struct{}) error {
+		return core.IndexLogs(db, from, stop)
+	})
+}
+
+func dbIndexUserOps(ctx *cli.Context) error {
+	return indexHistory(ctx, "user operation", func(db ethdb.Database, from uint64, stop chan struct{}) error {
+		config := rawdb.ReadChainConfig(db, rawdb.ReadCanonicalHash(db, 0))
+		if config == nil {
+			return errors.New("missing chain config")
+		}
+		return core.IndexUserOps(db, config, from, stop)
+	})
+}
+
+// indexHistory runs the backfill of an index from the block number given as
+// argument, stopping at the next batch when interrupted.
+func indexHistory(ctx *cli.Context, name string, index func(db ethdb.Database, from uint64, stop chan struct{}) error) error {
+	if ctx.NArg() > 1 {
+		return fmt.Errorf("max 1 argument: %v", ctx.Command.ArgsUsage)
+	}
+	var from uint64
+	if ctx.NArg() == 1 {
+		n, err := strconv.ParseUint(ctx.Args().First(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid block number: %v", err)
+		}
+		from = n
+	}
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info(fmt.Sprintf("Interrupted during %s indexing, stopping at next batch", name))
+		}
+		close(stop)
+	}()
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+	return index(db, from, stop)
+}
+
 type preimageIterator struct {
 	iter ethdb.Iterator
 }
@@ -163,14 +163,20 @@ var (
 		utils.GraphQLVirtualHostsFlag,
 		utils.HTTPApiFlag,
 		utils.HTTPPathPrefixFlag,
+		utils.HTTPAllowMethodsFlag,
+		utils.HTTPDenyMethodsFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
 		utils.WSPathPrefixFlag,
+		utils.WSAllowMethodsFlag,
+		utils.WSDenyMethodsFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
+		utils.IPCAllowMethodsFlag,
+		utils.IPCDenyMethodsFlag,
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
@@ -178,6 +184,17 @@ var (
 		utils.AllowUnprotectedTxs,
 		utils.BatchRequestLimit,
 		utils.BatchResponseMaxSize,
+		utils.RPCRateLimitFlag,
+		utils.RPCRateLimitBurstFlag,
+		utils.RPCRateLimitKeyHeaderFlag,
+		utils.RPCRateLimitProxiesFlag,
+		utils.RPCRateLimitCostsFlag,
+		utils.RPCCaptureFlag,
+		utils.RPCCaptureSampleFlag,
+		utils.RPCCaptureMaxSizeFlag,
+		utils.RPCCaptureMaxFilesFlag,
+		utils.RPCCaptureRedactFlag,
+		utils.RPCExecutionLimitsFlag,
 	}
 
 	metricsFlags = []cli.Flag{
@@ -210,6 +227,10 @@ func init() {
 		exportCommand,
 		importHistoryCommand,
 		exportHistoryCommand,
+		importArbHistoryCommand,
+		exportArbHistoryCommand,
+		verifyArbHistoryCommand,
+		verifyIrregularStateCommand,
 		importPreimagesCommand,
 		removedbCommand,
 		dumpCommand,
@@ -227,6 +248,7 @@ func init() {
 		licenseCommand,
 		// See config.go
 		dumpConfigCommand,
+		openRPCCommand,
 		// see dbcmd.go
 		dbCommand,
 		// See cmd/utils/flags_legacy.go
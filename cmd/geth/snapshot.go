@@ -40,6 +40,12 @@ import (
 )
 
 var (
+	stateChunkSizeFlag = &cli.Uint64Flag{
+		Name:  "chunksize",
+		Usage: "Approximate size in bytes of the exported state chunks",
+		Value: 128 * 1024 * 1024,
+	}
+
 	snapshotCommand = &cli.Command{
 		Name:        "snapshot",
 		Usage:       "A set of commands based on the snapshot",
@@ -159,6 +165,32 @@ block is used.
 				Description: `
 The export-preimages command exports hash preimages to a flat file, in exactly
 the expected order for the overlay tree migration.
+`,
+			},
+			{
+				Action:    snapshotExportState,
+				Name:      "export-state",
+				Usage:     "Export the state in snapshot enumeration order into chunk files",
+				ArgsUsage: "<dir> [<root>]",
+				Flags: flags.Merge([]cli.Flag{
+					stateChunkSizeFlag,
+				}, utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+The export-state command streams all accounts, storage slots and contract codes
+of the given state, or the head state if no root is given, into binary chunk
+files. A manifest.json file lists the chunks along with their hashes.
+`,
+			},
+			{
+				Action:    snapshotImportState,
+				Name:      "import-state",
+				Usage:     "Import a state exported by export-state",
+				ArgsUsage: "<dir>",
+				Flags:     flags.Merge(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+The import-state command rebuilds the tries of a state exported by export-state
+and writes them into the database, verifying every chunk against the manifest
+and the rebuilt state against the exported root.
 `,
 			},
 		},
@@ -662,6 +694,72 @@ func snapshotExportPreimages(ctx *cli.Context) error {
 	return utils.ExportSnapshotPreimages(chaindb, snaptree, ctx.Args().First(), root)
 }
 
+// snapshotExportState exports the state of the given root, or of the head block,
+// into chunk files.
+func snapshotExportState(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		return errors.New("need <dir> [<root>] args")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, true)
+	defer chaindb.Close()
+
+	triedb := utils.MakeTrieDatabase(ctx, chaindb, false, true, false)
+	defer triedb.Close()
+
+	var root common.Hash
+	if ctx.NArg() > 1 {
+		var err error
+		if root, err = parseRoot(ctx.Args().Get(1)); err != nil {
+			return err
+		}
+	} else {
+		headBlock := rawdb.ReadHeadBlock(chaindb)
+		if headBlock == nil {
+			log.Error("Failed to load head block")
+			return errors.New("no head block")
+		}
+		root = headBlock.Root()
+	}
+	snapConfig := snapshot.Config{
+		CacheSize:  256,
+		Recovery:   false,
+		NoBuild:    true,
+		AsyncBuild: false,
+	}
+	snaptree, err := snapshot.New(snapConfig, chaindb, triedb, root)
+	if err != nil {
+		return err
+	}
+	return utils.ExportState(snaptree, chaindb, root, ctx.Args().First(), ctx.Uint64(stateChunkSizeFlag.Name))
+}
+
+// snapshotImportState imports an exported state into the database.
+func snapshotImportState(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("need <dir> arg")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, false)
+	defer chaindb.Close()
+
+	scheme, err := rawdb.ParseStateScheme(ctx.String(utils.StateSchemeFlag.Name), chaindb)
+	if err != nil {
+		return err
+	}
+	root, err := utils.ImportState(chaindb, scheme, ctx.Args().First())
+	if err != nil {
+		log.Error("Failed to import state", "err", err)
+		return err
+	}
+	log.Info("State imported", "root", root, "scheme", scheme)
+	return nil
+}
+
 // checkAccount iterates the snap data layers, and looks up the given account
 // across all layers.
 func checkAccount(ctx *cli.Context) error {
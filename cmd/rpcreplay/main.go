@@ -0,0 +1,340 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// rpcreplay replays the RPC calls recorded by a node with --rpc.capture against
+// an endpoint, and reports the calls whose responses differ and the latencies.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+var app = flags.NewApp("replays captured RPC traffic against an endpoint")
+
+var (
+	endpointFlag = &cli.StringFlag{
+		Name:     "endpoint",
+		Usage:    "RPC endpoint the calls are replayed against",
+		Required: true,
+	}
+	concurrencyFlag = &cli.IntFlag{
+		Name:  "concurrency",
+		Usage: "number of calls replayed in parallel",
+		Value: 1,
+	}
+	methodFlag = &cli.StringSliceFlag{
+		Name:  "method",
+		Usage: "replay only the calls of the given methods",
+	}
+	timeoutFlag = &cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "timeout of a replayed call",
+		Value: 30 * time.Second,
+	}
+	diffsFlag = &cli.IntFlag{
+		Name:  "diffs",
+		Usage: "maximum number of differing responses printed",
+		Value: 10,
+	}
+)
+
+func init() {
+	app.ArgsUsage = "<capture file>..."
+	app.Flags = []cli.Flag{
+		endpointFlag,
+		concurrencyFlag,
+		methodFlag,
+		timeoutFlag,
+		diffsFlag,
+	}
+	app.Action = replay
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// outcome is the result of replaying a recorded call.
+type outcome struct {
+	call     *rpc.RecordedCall
+	result   json.RawMessage
+	rpcErr   *rpc.RecordedError
+	err      error // failure to perform the call
+	duration time.Duration
+}
+
+// matches reports whether the replayed call had the same response as the
+// recorded one.
+func (o *outcome) matches() bool {
+	if o.call.Error != nil || o.rpcErr != nil {
+		return o.call.Error != nil && o.rpcErr != nil &&
+			o.call.Error.Code == o.rpcErr.Code && o.call.Error.Message == o.rpcErr.Message
+	}
+	return jsonEqual(o.call.Result, o.result)
+}
+
+func replay(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return errors.New("no capture files given")
+	}
+	client, err := rpc.DialContext(ctx.Context, ctx.String(endpointFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	methods := make(map[string]bool)
+	for _, method := range ctx.StringSlice(methodFlag.Name) {
+		methods[method] = true
+	}
+	var (
+		calls    = make(chan *rpc.RecordedCall)
+		outcomes = make(chan *outcome)
+		workers  sync.WaitGroup
+		readErr  = make(chan error, 1)
+		skipped  int
+	)
+	go func() {
+		defer close(calls)
+		for _, path := range ctx.Args().Slice() {
+			n, err := readCapture(path, func(call *rpc.RecordedCall) {
+				if call.Redacted || (len(methods) > 0 && !methods[call.Method]) {
+					skipped++
+					return
+				}
+				calls <- call
+			})
+			if err != nil {
+				readErr <- fmt.Errorf("%s:%d: %v", path, n, err)
+				return
+			}
+		}
+		readErr <- nil
+	}()
+	for i := 0; i < ctx.Int(concurrencyFlag.Name); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for call := range calls {
+				outcomes <- replayCall(ctx.Context, client, call, ctx.Duration(timeoutFlag.Name))
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(outcomes)
+	}()
+	report := newReport(ctx.Int(diffsFlag.Name))
+	for o := range outcomes {
+		report.add(ctx.App.Writer, o)
+	}
+	if err := <-readErr; err != nil {
+		return err
+	}
+	report.print(ctx.App.Writer, skipped)
+	if report.failed > 0 || report.mismatched > 0 {
+		return fmt.Errorf("%d calls failed, %d responses differ", report.failed, report.mismatched)
+	}
+	return nil
+}
+
+// readCapture calls fn with every call recorded in the capture file, returning
+// the number of lines read.
+func readCapture(path string, fn func(*rpc.RecordedCall)) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			call := new(rpc.RecordedCall)
+			if err := json.Unmarshal(line, call); err != nil {
+				return n, err
+			}
+			fn(call)
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// replayCall performs the recorded call.
+func replayCall(ctx context.Context, client *rpc.Client, call *rpc.RecordedCall, timeout time.Duration) *outcome {
+	var args []interface{}
+	if len(call.Params) > 0 {
+		var params []json.RawMessage
+		if err := json.Unmarshal(call.Params, &params); err != nil {
+			return &outcome{call: call, err: fmt.Errorf("invalid params: %v", err)}
+		}
+		for _, param := range params {
+			args = append(args, param)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		o     = &outcome{call: call}
+		start = time.Now()
+		err   = client.CallContext(ctx, &o.result, call.Method, args...)
+	)
+	o.duration = time.Since(start)
+
+	var rpcErr rpc.Error
+	switch {
+	case err == nil:
+	case errors.As(err, &rpcErr):
+		o.rpcErr = &rpc.RecordedError{Code: rpcErr.ErrorCode(), Message: err.Error()}
+	default:
+		o.err = err
+	}
+	return o
+}
+
+// jsonEqual reports whether the JSON values are equal, regardless of encoding.
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// report collects the outcomes of the replayed calls.
+type report struct {
+	maxDiffs   int
+	replayed   int
+	failed     int
+	mismatched int
+
+	methods map[string]*methodReport
+}
+
+type methodReport struct {
+	mismatched int
+	recorded   []time.Duration
+	replayed   []time.Duration
+}
+
+func newReport(maxDiffs int) *report {
+	return &report{maxDiffs: maxDiffs, methods: make(map[string]*methodReport)}
+}
+
+// add accounts an outcome, printing the failures and differences.
+func (r *report) add(w io.Writer, o *outcome) {
+	r.replayed++
+	if o.err != nil {
+		r.failed++
+		fmt.Fprintf(w, "FAIL %s %s: %v\n", o.call.Method, o.call.Params, o.err)
+		return
+	}
+	m := r.methods[o.call.Method]
+	if m == nil {
+		m = new(methodReport)
+		r.methods[o.call.Method] = m
+	}
+	m.recorded = append(m.recorded, o.call.Duration)
+	m.replayed = append(m.replayed, o.duration)
+	if !o.matches() {
+		r.mismatched++
+		m.mismatched++
+		if r.mismatched <= r.maxDiffs {
+			fmt.Fprintf(w, "DIFF %s %s\n  recorded: %s\n  replayed: %s\n", o.call.Method, o.call.Params, response(o.call.Result, o.call.Error), response(o.result, o.rpcErr))
+		}
+	}
+}
+
+func response(result json.RawMessage, err *rpc.RecordedError) string {
+	if err != nil {
+		return fmt.Sprintf("error %d: %s", err.Code, err.Message)
+	}
+	return string(result)
+}
+
+// print writes the summary and the latency percentiles of the replay.
+func (r *report) print(w io.Writer, skipped int) {
+	fmt.Fprintf(w, "\nreplayed %d calls (%d skipped): %d differ, %d failed\n\n", r.replayed, skipped, r.mismatched, r.failed)
+
+	names := make([]string, 0, len(r.methods))
+	for name := range r.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var all methodReport
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "method\tcalls\tdiffs\tp50\tp90\tp99\tmax\trecorded p50\trecorded p99\t")
+	for _, name := range names {
+		m := r.methods[name]
+		printLatencies(tw, name, m)
+		all.mismatched += m.mismatched
+		all.recorded = append(all.recorded, m.recorded...)
+		all.replayed = append(all.replayed, m.replayed...)
+	}
+	printLatencies(tw, "all", &all)
+	tw.Flush()
+}
+
+func printLatencies(w io.Writer, name string, m *methodReport) {
+	sort.Slice(m.recorded, func(i, j int) bool { return m.recorded[i] < m.recorded[j] })
+	sort.Slice(m.replayed, func(i, j int) bool { return m.replayed[i] < m.replayed[j] })
+	fmt.Fprintf(w, "%s\t%d\t%d\t%v\t%v\t%v\t%v\t%v\t%v\t\n", name, len(m.replayed), m.mismatched,
+		percentile(m.replayed, 0.5), percentile(m.replayed, 0.9), percentile(m.replayed, 0.99), percentile(m.replayed, 1),
+		percentile(m.recorded, 0.5), percentile(m.recorded, 0.99))
+}
+
+// percentile returns the p-th percentile of the sorted durations, using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testService struct{}
+
+func (testService) Echo(s string) string { return s }
+
+func (testService) Fail() error { return errors.New("failure") }
+
+func TestReplay(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("test", testService{}); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	// Record a capture with the server, then tamper with one of the results
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	recorder, err := rpc.NewRecorder(rpc.RecorderConfig{Path: path, Redact: []string{"test_fail"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetRecorder(recorder)
+	client := rpc.DialInProc(server)
+	for _, s := range []string{"a", "b"} {
+		if err := client.Call(nil, "test_echo", s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client.Call(nil, "test_fail")
+	client.Close()
+	recorder.Close()
+
+	var calls []*rpc.RecordedCall
+	if _, err := readCapture(path, func(call *rpc.RecordedCall) { calls = append(calls, call) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 || !calls[2].Redacted {
+		t.Fatalf("unexpected capture: %v", calls)
+	}
+	for _, call := range calls[:2] {
+		if o := replayCall(context.Background(), dial(t, httpsrv.URL), call, time.Second); o.err != nil || !o.matches() {
+			t.Fatalf("replayed call %s %s does not match: %+v", call.Method, call.Params, o)
+		}
+	}
+	calls[1].Result = []byte(`"c"`)
+	if o := replayCall(context.Background(), dial(t, httpsrv.URL), calls[1], time.Second); o.matches() {
+		t.Fatal("tampered result matches")
+	}
+	if o := replayCall(context.Background(), dial(t, httpsrv.URL), &rpc.RecordedCall{Method: "test_fail", Error: &rpc.RecordedError{Code: -32000, Message: "failure"}}, time.Second); !o.matches() {
+		t.Fatalf("error response does not match: %+v", o.rpcErr)
+	}
+
+	// Run the tool, skipping the redacted call
+	app.Writer = io.Discard
+	err = app.Run([]string{"rpcreplay", "--endpoint", httpsrv.URL, "--concurrency", "2", path})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+}
+
+func dial(t *testing.T, url string) *rpc.Client {
+	client, err := rpc.Dial(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestPercentile(t *testing.T) {
+	durations := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for p, want := range map[float64]time.Duration{0: 1, 0.5: 5, 0.9: 9, 0.99: 10, 1: 10} {
+		if have := percentile(durations, p); have != want {
+			t.Errorf("percentile %v: have %v, want %v", p, have, want)
+		}
+	}
+	if have := percentile(nil, 0.5); have != 0 {
+		t.Errorf("empty percentile: have %v", have)
+	}
+}
@@ -572,6 +572,16 @@ var (
 		Usage:    "Filename for IPC socket/pipe within the datadir (explicit paths escape it)",
 		Category: flags.APICategory,
 	}
+	IPCAllowMethodsFlag = &cli.StringFlag{
+		Name:     "ipc.allow-methods",
+		Usage:    "Comma separated list of methods served over IPC, '*' matches any characters (e.g. debug_*)",
+		Category: flags.APICategory,
+	}
+	IPCDenyMethodsFlag = &cli.StringFlag{
+		Name:     "ipc.deny-methods",
+		Usage:    "Comma separated list of methods not served over IPC, taking precedence over the allowed ones",
+		Category: flags.APICategory,
+	}
 	HTTPEnabledFlag = &cli.BoolFlag{
 		Name:     "http",
 		Usage:    "Enable the HTTP-RPC server",
@@ -613,6 +623,16 @@ var (
 		Value:    "",
 		Category: flags.APICategory,
 	}
+	HTTPAllowMethodsFlag = &cli.StringFlag{
+		Name:     "http.allow-methods",
+		Usage:    "Comma separated list of methods served over HTTP, '*' matches any characters (e.g. debug_*)",
+		Category: flags.APICategory,
+	}
+	HTTPDenyMethodsFlag = &cli.StringFlag{
+		Name:     "http.deny-methods",
+		Usage:    "Comma separated list of methods not served over HTTP, taking precedence over the allowed ones",
+		Category: flags.APICategory,
+	}
 	GraphQLEnabledFlag = &cli.BoolFlag{
 		Name:     "graphql",
 		Usage:    "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -653,6 +673,16 @@ var (
 		Value:    "",
 		Category: flags.APICategory,
 	}
+	WSAllowMethodsFlag = &cli.StringFlag{
+		Name:     "ws.allow-methods",
+		Usage:    "Comma separated list of methods served over WebSocket, '*' matches any characters (e.g. debug_*)",
+		Category: flags.APICategory,
+	}
+	WSDenyMethodsFlag = &cli.StringFlag{
+		Name:     "ws.deny-methods",
+		Usage:    "Comma separated list of methods not served over WebSocket, taking precedence over the allowed ones",
+		Category: flags.APICategory,
+	}
 	WSAllowedOriginsFlag = &cli.StringFlag{
 		Name:     "ws.origins",
 		Usage:    "Origins from which to accept websockets requests",
@@ -692,6 +722,65 @@ var (
 		Value:    node.DefaultConfig.BatchResponseMaxSize,
 		Category: flags.APICategory,
 	}
+	RPCRateLimitFlag = &cli.Float64Flag{
+		Name:     "rpc.ratelimit",
+		Usage:    "Number of call costs per second served to each HTTP and WebSocket client (0 = no limit)",
+		Category: flags.APICategory,
+	}
+	RPCRateLimitBurstFlag = &cli.IntFlag{
+		Name:     "rpc.ratelimit.burst",
+		Usage:    "Maximum call cost a client can spend at once (defaults to one second of --rpc.ratelimit)",
+		Category: flags.APICategory,
+	}
+	RPCRateLimitKeyHeaderFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit.keyheader",
+		Usage:    "HTTP header identifying the clients by API key, only to be set behind a proxy checking it",
+		Category: flags.APICategory,
+	}
+	RPCRateLimitProxiesFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit.trustedproxies",
+		Usage:    "Comma separated list of reverse proxy addresses or CIDR ranges whose X-Forwarded-For header identifies the clients",
+		Category: flags.APICategory,
+	}
+	RPCRateLimitCostsFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit.costs",
+		Usage:    "Comma separated list of method costs (e.g. eth_getLogs=20,eth_chainId=0), 1 if unlisted",
+		Category: flags.APICategory,
+	}
+	RPCCaptureFlag = &cli.StringFlag{
+		Name:     "rpc.capture",
+		Usage:    "File recording the HTTP and WebSocket calls for replay with rpcreplay (relative to datadir)",
+		Category: flags.APICategory,
+	}
+	RPCCaptureSampleFlag = &cli.Float64Flag{
+		Name:     "rpc.capture.sample",
+		Usage:    "Fraction of the calls recorded to the capture file",
+		Value:    1,
+		Category: flags.APICategory,
+	}
+	RPCCaptureMaxSizeFlag = &cli.IntFlag{
+		Name:     "rpc.capture.maxsize",
+		Usage:    "Size in megabytes at which the capture file is rotated (0 = no rotation)",
+		Value:    256,
+		Category: flags.APICategory,
+	}
+	RPCCaptureMaxFilesFlag = &cli.IntFlag{
+		Name:     "rpc.capture.maxfiles",
+		Usage:    "Number of rotated capture files kept",
+		Value:    4,
+		Category: flags.APICategory,
+	}
+	RPCCaptureRedactFlag = &cli.StringFlag{
+		Name:     "rpc.capture.redact",
+		Usage:    "Comma separated list of methods whose parameters are not recorded",
+		Value:    strings.Join(rpc.DefaultRedactedMethods, ","),
+		Category: flags.APICategory,
+	}
+	RPCExecutionLimitsFlag = &cli.StringFlag{
+		Name:     "rpc.limits",
+		Usage:    "Comma separated list of execution limits by namespace or method, as name=concurrent/queued/queuetimeout/timeout (e.g. debug=4/16/5s/1m, 0 = no limit)",
+		Category: flags.APICategory,
+	}
 	EnablePersonal = &cli.BoolFlag{
 		Name:     "rpc.enabledeprecatedpersonal",
 		Usage:    "Enables the (deprecated) personal namespace",
@@ -1120,6 +1209,12 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(HTTPApiFlag.Name) {
 		cfg.HTTPModules = SplitAndTrim(ctx.String(HTTPApiFlag.Name))
 	}
+	if ctx.IsSet(HTTPAllowMethodsFlag.Name) {
+		cfg.HTTPMethodRules.Allow = SplitAndTrim(ctx.String(HTTPAllowMethodsFlag.Name))
+	}
+	if ctx.IsSet(HTTPDenyMethodsFlag.Name) {
+		cfg.HTTPMethodRules.Deny = SplitAndTrim(ctx.String(HTTPDenyMethodsFlag.Name))
+	}
 
 	if ctx.IsSet(HTTPVirtualHostsFlag.Name) {
 		cfg.HTTPVirtualHosts = SplitAndTrim(ctx.String(HTTPVirtualHostsFlag.Name))
@@ -1139,6 +1234,85 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(BatchResponseMaxSize.Name) {
 		cfg.BatchResponseMaxSize = ctx.Int(BatchResponseMaxSize.Name)
 	}
+
+	if ctx.IsSet(RPCRateLimitFlag.Name) {
+		cfg.RPCRateLimit.Rate = ctx.Float64(RPCRateLimitFlag.Name)
+	}
+	if ctx.IsSet(RPCRateLimitBurstFlag.Name) {
+		cfg.RPCRateLimit.Burst = ctx.Int(RPCRateLimitBurstFlag.Name)
+	}
+	if cfg.RPCRateLimit.Rate > 0 && cfg.RPCRateLimit.Burst == 0 {
+		cfg.RPCRateLimit.Burst = int(math.Ceil(cfg.RPCRateLimit.Rate))
+	}
+	if ctx.IsSet(RPCRateLimitKeyHeaderFlag.Name) {
+		cfg.RPCRateLimit.KeyHeader = ctx.String(RPCRateLimitKeyHeaderFlag.Name)
+	}
+	if ctx.IsSet(RPCRateLimitProxiesFlag.Name) {
+		cfg.RPCRateLimit.TrustedProxies = SplitAndTrim(ctx.String(RPCRateLimitProxiesFlag.Name))
+	}
+	if ctx.IsSet(RPCRateLimitCostsFlag.Name) {
+		costs := make(map[string]int)
+		for _, entry := range SplitAndTrim(ctx.String(RPCRateLimitCostsFlag.Name)) {
+			method, value, ok := strings.Cut(entry, "=")
+			cost, err := strconv.Atoi(value)
+			if !ok || err != nil || cost < 0 {
+				Fatalf("Option %s: invalid method cost %q", RPCRateLimitCostsFlag.Name, entry)
+			}
+			costs[method] = cost
+		}
+		cfg.RPCRateLimit.MethodCosts = costs
+	}
+
+	if ctx.IsSet(RPCCaptureFlag.Name) {
+		cfg.RPCCapture = rpc.RecorderConfig{
+			Path:       ctx.String(RPCCaptureFlag.Name),
+			SampleRate: ctx.Float64(RPCCaptureSampleFlag.Name),
+			MaxSize:    int64(ctx.Int(RPCCaptureMaxSizeFlag.Name)) * 1024 * 1024,
+			MaxFiles:   ctx.Int(RPCCaptureMaxFilesFlag.Name),
+			Redact:     append([]string{}, SplitAndTrim(ctx.String(RPCCaptureRedactFlag.Name))...),
+		}
+	}
+	if ctx.IsSet(RPCExecutionLimitsFlag.Name) {
+		limits := make(map[string]rpc.ExecutionLimit)
+		for _, entry := range SplitAndTrim(ctx.String(RPCExecutionLimitsFlag.Name)) {
+			name, value, _ := strings.Cut(entry, "=")
+			limit, err := parseExecutionLimit(value)
+			if name == "" || err != nil {
+				Fatalf("Option %s: invalid execution limit %q", RPCExecutionLimitsFlag.Name, entry)
+			}
+			limits[name] = limit
+		}
+		cfg.RPCExecutionLimits = limits
+	}
+}
+
+// parseExecutionLimit parses an execution limit formatted as
+// concurrent/queued/queuetimeout/timeout, the trailing values being optional.
+func parseExecutionLimit(value string) (rpc.ExecutionLimit, error) {
+	var (
+		limit  rpc.ExecutionLimit
+		fields = strings.Split(value, "/")
+		err    error
+	)
+	if len(fields) > 4 {
+		return limit, errors.New("too many values")
+	}
+	for i, field := range fields {
+		switch i {
+		case 0:
+			limit.MaxConcurrent, err = strconv.Atoi(field)
+		case 1:
+			limit.MaxQueued, err = strconv.Atoi(field)
+		case 2:
+			limit.QueueTimeout, err = time.ParseDuration(field)
+		case 3:
+			limit.Timeout, err = time.ParseDuration(field)
+		}
+		if err != nil {
+			return limit, err
+		}
+	}
+	return limit, nil
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1174,6 +1348,12 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(WSApiFlag.Name) {
 		cfg.WSModules = SplitAndTrim(ctx.String(WSApiFlag.Name))
 	}
+	if ctx.IsSet(WSAllowMethodsFlag.Name) {
+		cfg.WSMethodRules.Allow = SplitAndTrim(ctx.String(WSAllowMethodsFlag.Name))
+	}
+	if ctx.IsSet(WSDenyMethodsFlag.Name) {
+		cfg.WSMethodRules.Deny = SplitAndTrim(ctx.String(WSDenyMethodsFlag.Name))
+	}
 
 	if ctx.IsSet(WSPathPrefixFlag.Name) {
 		cfg.WSPathPrefix = ctx.String(WSPathPrefixFlag.Name)
@@ -1190,6 +1370,12 @@ func setIPC(ctx *cli.Context, cfg *node.Config) {
 	case ctx.IsSet(IPCPathFlag.Name):
 		cfg.IPCPath = ctx.String(IPCPathFlag.Name)
 	}
+	if ctx.IsSet(IPCAllowMethodsFlag.Name) {
+		cfg.IPCMethodRules.Allow = SplitAndTrim(ctx.String(IPCAllowMethodsFlag.Name))
+	}
+	if ctx.IsSet(IPCDenyMethodsFlag.Name) {
+		cfg.IPCMethodRules.Deny = SplitAndTrim(ctx.String(IPCDenyMethodsFlag.Name))
+	}
 }
 
 // setLes shows the deprecation warnings for LES flags.
@@ -20,6 +20,9 @@ package utils
 import (
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func Test_SplitTagsFlag(t *testing.T) {
@@ -65,3 +68,31 @@ func Test_SplitTagsFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestParseExecutionLimit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value string
+		want  rpc.ExecutionLimit
+		fail  bool
+	}{
+		{value: "4", want: rpc.ExecutionLimit{MaxConcurrent: 4}},
+		{value: "4/16/5s/1m", want: rpc.ExecutionLimit{MaxConcurrent: 4, MaxQueued: 16, QueueTimeout: 5 * time.Second, Timeout: time.Minute}},
+		{value: "0/0/0/30s", want: rpc.ExecutionLimit{Timeout: 30 * time.Second}},
+		{value: "", fail: true},
+		{value: "4/x", fail: true},
+		{value: "1/2/3s/4s/5", fail: true},
+	}
+	for _, tt := range tests {
+		got, err := parseExecutionLimit(tt.value)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%q: expected error", tt.value)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %+v, %v, want %+v", tt.value, got, err, tt.want)
+		}
+	}
+}
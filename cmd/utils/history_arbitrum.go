@@ -0,0 +1,287 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era/arbera"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ArbitrumHistoryNetwork returns the network name used for the ArbEra archives
+// of the given chain.
+func ArbitrumHistoryNetwork(config *params.ChainConfig) string {
+	if name, ok := params.NetworkNames[config.ChainID.String()]; ok {
+		return name
+	}
+	return "chain" + config.ChainID.String()
+}
+
+// ArbitrumHistorySource is the chain data needed to export ArbEra archives. It
+// is implemented by core.BlockChain, but may also be backed by a raw database.
+type ArbitrumHistorySource interface {
+	Config() *params.ChainConfig
+	CurrentBlock() *types.Header
+	GetBlockByNumber(number uint64) *types.Block
+	GetReceiptsByHash(hash common.Hash) types.Receipts
+}
+
+// ExportArbitrumHistory exports Nitro blockchain history into the specified
+// directory, following the ArbEra format.
+func ExportArbitrumHistory(bc ArbitrumHistorySource, dir string, first, last, step uint64) error {
+	log.Info("Exporting Arbitrum blockchain history", "dir", dir)
+	if head := bc.CurrentBlock().Number.Uint64(); head < last {
+		log.Warn("Last block beyond head, setting last = head", "head", head, "last", last)
+		last = head
+	}
+	if genesis := bc.Config().ArbitrumChainParams.GenesisBlockNum; bc.Config().IsArbitrum() && first < genesis {
+		log.Warn("First block before Nitro genesis, setting first = genesis", "genesis", genesis, "first", first)
+		first = genesis
+	}
+	network := ArbitrumHistoryNetwork(bc.Config())
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	var (
+		start     = time.Now()
+		reported  = time.Now()
+		h         = sha256.New()
+		buf       = bytes.NewBuffer(nil)
+		checksums []string
+	)
+	// Align the archives on epoch boundaries, so that exports of different
+	// ranges of the same chain produce identical files.
+	for i := first; i <= last; i = (i/step + 1) * step {
+		err := func() error {
+			epoch := int(i / step)
+			filename := path.Join(dir, arbera.Filename(network, epoch, common.Hash{}))
+			f, err := os.Create(filename)
+			if err != nil {
+				return fmt.Errorf("could not create arbera file: %w", err)
+			}
+			defer f.Close()
+
+			w := arbera.NewBuilder(f, bc.Config().ChainID)
+			for n := i; n < (i/step+1)*step && n <= last; n++ {
+				block := bc.GetBlockByNumber(n)
+				if block == nil {
+					return fmt.Errorf("export failed on #%d: not found", n)
+				}
+				receipts := bc.GetReceiptsByHash(block.Hash())
+				if receipts == nil {
+					return fmt.Errorf("export failed on #%d: receipts not found", n)
+				}
+				if err := w.Add(block, receipts); err != nil {
+					return err
+				}
+			}
+			root, err := w.Finalize()
+			if err != nil {
+				return fmt.Errorf("export failed to finalize %d: %w", epoch, err)
+			}
+			// Set correct filename with root.
+			os.Rename(filename, path.Join(dir, arbera.Filename(network, epoch, root)))
+
+			// Compute checksum of entire ArbEra.
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			if _, err := io.Copy(h, f); err != nil {
+				return fmt.Errorf("unable to calculate checksum: %w", err)
+			}
+			checksums = append(checksums, common.BytesToHash(h.Sum(buf.Bytes()[:])).Hex())
+			h.Reset()
+			buf.Reset()
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Exporting blocks", "exported", i, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	os.WriteFile(path.Join(dir, "checksums.txt"), []byte(strings.Join(checksums, "\n")), os.ModePerm)
+
+	log.Info("Exported Arbitrum blockchain history", "dir", dir)
+	return nil
+}
+
+// ImportArbitrumHistory imports ArbEra files containing Nitro block history.
+// The chain must not have progressed past its genesis block. Every archive is
+// checked against checksums.txt and fully verified before any of its blocks
+// get imported.
+func ImportArbitrumHistory(chain *core.BlockChain, dir string, network string) error {
+	genesis := uint64(0)
+	if chain.Config().IsArbitrum() {
+		genesis = chain.Config().ArbitrumChainParams.GenesisBlockNum
+	}
+	if chain.CurrentSnapBlock().Number.Uint64() != genesis {
+		return fmt.Errorf("history import only supported when starting from genesis")
+	}
+	entries, err := arbera.ReadDir(dir, network)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	checksums, err := readList(path.Join(dir, "checksums.txt"))
+	if err != nil {
+		return fmt.Errorf("unable to read checksums.txt: %w", err)
+	}
+	if len(checksums) != len(entries) {
+		return fmt.Errorf("expected equal number of checksums and entries, have: %d checksums, %d entries", len(checksums), len(entries))
+	}
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		imported = 0
+		forker   = core.NewForkChoice(chain, nil)
+	)
+	for i, filename := range entries {
+		err := func() error {
+			e, err := openVerifiedArbEra(path.Join(dir, filename), checksums[i])
+			if err != nil {
+				return err
+			}
+			defer e.Close()
+
+			if id, err := e.ChainID(); err != nil {
+				return fmt.Errorf("error reading chain id: %w", err)
+			} else if id.Cmp(chain.Config().ChainID) != 0 {
+				return fmt.Errorf("chain id mismatch: have %v, want %v", id, chain.Config().ChainID)
+			}
+			it, err := arbera.NewIterator(e)
+			if err != nil {
+				return fmt.Errorf("error making arbera reader: %w", err)
+			}
+			for it.Next() {
+				if it.Number() <= genesis {
+					continue // skip genesis and anything before it
+				}
+				block, receipts, err := it.BlockAndReceipts()
+				if err != nil {
+					return fmt.Errorf("error reading block %d: %w", it.Number(), err)
+				}
+				if status, err := chain.HeaderChain().InsertHeaderChain([]*types.Header{block.Header()}, start, forker); err != nil {
+					return fmt.Errorf("error inserting header %d: %w", it.Number(), err)
+				} else if status != core.CanonStatTy {
+					return fmt.Errorf("error inserting header %d, not canon: %v", it.Number(), status)
+				}
+				if _, err := chain.InsertReceiptChain([]*types.Block{block}, []types.Receipts{receipts}, math.MaxUint64); err != nil {
+					return fmt.Errorf("error inserting body %d: %w", it.Number(), err)
+				}
+				imported += 1
+
+				// Give the user some feedback that something is happening.
+				if time.Since(reported) >= 8*time.Second {
+					log.Info("Importing ArbEra files", "head", it.Number(), "imported", imported, "elapsed", common.PrettyDuration(time.Since(start)))
+					imported = 0
+					reported = time.Now()
+				}
+			}
+			return it.Error()
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyArbitrumHistory checks every ArbEra file of the network in a directory
+// against checksums.txt and verifies its content and accumulator.
+func VerifyArbitrumHistory(dir string, network string) error {
+	entries, err := arbera.ReadDir(dir, network)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no arbera files found for network %s in %s", network, dir)
+	}
+	checksums, err := readList(path.Join(dir, "checksums.txt"))
+	if err != nil {
+		return fmt.Errorf("unable to read checksums.txt: %w", err)
+	}
+	if len(checksums) != len(entries) {
+		return fmt.Errorf("expected equal number of checksums and entries, have: %d checksums, %d entries", len(checksums), len(entries))
+	}
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		next     *uint64
+	)
+	for i, filename := range entries {
+		e, err := openVerifiedArbEra(path.Join(dir, filename), checksums[i])
+		if err != nil {
+			return err
+		}
+		if next != nil && e.Start() != *next {
+			e.Close()
+			return fmt.Errorf("gap between archives: %s starts at %d, want %d", filename, e.Start(), *next)
+		}
+		end := e.Start() + e.Count()
+		next = &end
+		e.Close()
+
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Verifying ArbEra files", "verified", i, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	return nil
+}
+
+// openVerifiedArbEra opens an ArbEra file, checks its checksum and verifies
+// its content.
+func openVerifiedArbEra(filename string, checksum string) (*arbera.Era, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open arbera: %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to recalculate checksum: %w", err)
+	}
+	if have := common.BytesToHash(h.Sum(nil)).Hex(); have != checksum {
+		f.Close()
+		return nil, fmt.Errorf("checksum mismatch for %s: have %s, want %s", filename, have, checksum)
+	}
+	e, err := arbera.From(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error opening arbera: %w", err)
+	}
+	if err := arbera.Verify(e); err != nil {
+		e.Close()
+		return nil, fmt.Errorf("error verifying %s: %w", filename, err)
+	}
+	return e, nil
+}
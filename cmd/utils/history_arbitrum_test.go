@@ -0,0 +1,120 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"math/big"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era/arbera"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+func TestArbitrumHistoryImportAndExport(t *testing.T) {
+	// Stay below the Kinto RulesBlockStart, the test chain has no Kinto contracts
+	const blocks = 72
+
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}},
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+	db, chainBlocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), blocks, func(i int, g *core.BlockGen) {
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   genesis.Config.ChainID,
+			Nonce:     uint64(i),
+			GasTipCap: common.Big0,
+			GasFeeCap: g.BaseFee(),
+			Gas:       50000,
+			To:        &common.Address{0xaa},
+			Value:     big.NewInt(int64(i)),
+		})
+		if err != nil {
+			t.Fatalf("error creating tx: %v", err)
+		}
+		g.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(db, nil, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	if _, err := chain.InsertChain(chainBlocks); err != nil {
+		t.Fatalf("error inserting chain: %v", err)
+	}
+	dir := t.TempDir()
+	network := ArbitrumHistoryNetwork(chain.Config())
+
+	// Export an unaligned range and check the epoch boundaries.
+	if err := ExportArbitrumHistory(chain, dir, 0, blocks, step); err != nil {
+		t.Fatalf("error exporting history: %v", err)
+	}
+	entries, err := arbera.ReadDir(dir, network)
+	if err != nil {
+		t.Fatalf("error reading dir: %v", err)
+	}
+	if want := blocks/int(step) + 1; len(entries) != want {
+		t.Fatalf("archive count mismatch: have %d, want %d", len(entries), want)
+	}
+	if err := VerifyArbitrumHistory(dir, network); err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+	// Import into a fresh database.
+	db2, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db2.Close()
+
+	genesis.MustCommit(db2, triedb.NewDatabase(db2, triedb.HashDefaults))
+	imported, err := core.NewBlockChain(db2, nil, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	if err := ImportArbitrumHistory(imported, dir, network); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	if have, want := imported.CurrentHeader(), chain.CurrentHeader(); have.Hash() != want.Hash() {
+		t.Fatalf("imported chain does not match expected, have (%d, %s) want (%d, %s)", have.Number, have.Hash(), want.Number, want.Hash())
+	}
+	// Corrupt an archive and ensure verification catches it.
+	name := path.Join(dir, entries[1])
+	blob, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+	blob[len(blob)/2] ^= 0xff
+	if err := os.WriteFile(name, blob, 0644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	if err := VerifyArbitrumHistory(dir, network); err == nil {
+		t.Fatal("verification of corrupted archive succeeded")
+	}
+}
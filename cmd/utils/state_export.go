@@ -0,0 +1,169 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// StateExportManifest describes a state exported into a directory of chunk
+// files. It is stored next to the chunks as manifest.json.
+type StateExportManifest struct {
+	Version  uint64             `json:"version"`
+	Root     common.Hash        `json:"root"`
+	Accounts uint64             `json:"accounts"`
+	Slots    uint64             `json:"slots"`
+	Codes    uint64             `json:"codes"`
+	Chunks   []StateExportChunk `json:"chunks"`
+}
+
+// StateExportChunk is a single chunk file of an exported state.
+type StateExportChunk struct {
+	Name string      `json:"name"`
+	Hash common.Hash `json:"hash"` // keccak256 of the file content
+	Size uint64      `json:"size"`
+}
+
+const stateExportManifest = "manifest.json"
+
+// ExportState exports the state with the given root from the snapshot into the
+// specified directory, split into chunk files of roughly chunkSize bytes.
+func ExportState(snaptree *snapshot.Tree, db ethdb.KeyValueReader, root common.Hash, dir string, chunkSize uint64) error {
+	log.Info("Exporting state", "root", root, "dir", dir)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		exporter = snapshot.NewStateExporter(snaptree, root, db)
+		manifest = &StateExportManifest{Version: snapshot.ExportVersion, Root: root}
+		cursor   = &snapshot.ExportCursor{}
+	)
+	for cursor != nil {
+		var (
+			name  = fmt.Sprintf("chunk-%05d", len(manifest.Chunks))
+			hash  = crypto.NewKeccakState()
+			stats snapshot.ExportStats
+			size  uint64
+		)
+		err := func() error {
+			f, err := os.Create(path.Join(dir, name))
+			if err != nil {
+				return fmt.Errorf("could not create chunk file: %w", err)
+			}
+			defer f.Close()
+
+			buf := bufio.NewWriter(io.MultiWriter(f, hash))
+			if cursor, stats, err = exporter.ExportChunk(buf, *cursor, chunkSize); err != nil {
+				return fmt.Errorf("export failed on %s: %w", name, err)
+			}
+			if err := buf.Flush(); err != nil {
+				return err
+			}
+			info, err := f.Stat()
+			if err != nil {
+				return err
+			}
+			size = uint64(info.Size())
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+		manifest.Chunks = append(manifest.Chunks, StateExportChunk{Name: name, Hash: common.BytesToHash(hash.Sum(nil)), Size: size})
+		manifest.Accounts += stats.Accounts
+		manifest.Slots += stats.Slots
+		manifest.Codes += stats.Codes
+
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Exporting state", "chunks", len(manifest.Chunks), "accounts", manifest.Accounts, "slots", manifest.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	blob, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path.Join(dir, stateExportManifest), blob, os.ModePerm); err != nil {
+		return err
+	}
+	log.Info("Exported state", "root", root, "chunks", len(manifest.Chunks), "accounts", manifest.Accounts, "slots", manifest.Slots, "codes", manifest.Codes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// ImportState imports a state exported by ExportState into the database. Each
+// chunk is checked against the manifest before it's imported, and the rebuilt
+// state is verified against the manifest root.
+func ImportState(db ethdb.KeyValueStore, scheme string, dir string) (common.Hash, error) {
+	blob, err := os.ReadFile(path.Join(dir, stateExportManifest))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("unable to read manifest: %w", err)
+	}
+	var manifest StateExportManifest
+	if err := json.Unmarshal(blob, &manifest); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.Version != snapshot.ExportVersion {
+		return common.Hash{}, fmt.Errorf("unsupported state export version %d", manifest.Version)
+	}
+	log.Info("Importing state", "root", manifest.Root, "chunks", len(manifest.Chunks))
+
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		importer = snapshot.NewStateImporter(db, scheme)
+	)
+	for i, chunk := range manifest.Chunks {
+		data, err := os.ReadFile(path.Join(dir, chunk.Name))
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("unable to read chunk: %w", err)
+		}
+		if have := crypto.Keccak256Hash(data); have != chunk.Hash {
+			return common.Hash{}, fmt.Errorf("hash mismatch for %s: have %x, want %x", chunk.Name, have, chunk.Hash)
+		}
+		if err := importer.Import(bytes.NewReader(data)); err != nil {
+			return common.Hash{}, fmt.Errorf("import failed on %s: %w", chunk.Name, err)
+		}
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Importing state", "chunks", i+1, "total", len(manifest.Chunks), "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	stats, err := importer.Finish(manifest.Root)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if stats.Accounts != manifest.Accounts || stats.Slots != manifest.Slots {
+		return common.Hash{}, fmt.Errorf("entry count mismatch: have %d accounts and %d slots, want %d and %d", stats.Accounts, stats.Slots, manifest.Accounts, manifest.Slots)
+	}
+	log.Info("Imported state", "root", manifest.Root, "accounts", stats.Accounts, "slots", stats.Slots, "codes", stats.Codes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return manifest.Root, nil
+}
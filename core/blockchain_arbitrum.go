@@ -21,7 +21,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -69,3 +72,63 @@ func (bc *BlockChain) RecoverState(block *types.Block) error {
 	_, err := bc.recoverAncestors(block)
 	return err
 }
+
+// maxChangesetPrunePerBlock bounds the number of block heights whose state
+// changesets are deleted while writing a single block, so that enabling a
+// retention on an existing database doesn't stall block import.
+const maxChangesetPrunePerBlock = 64
+
+// writeStateChangeset stores the state changeset of a freshly written block and
+// prunes the changesets that fell out of the configured retention window.
+func (bc *BlockChain) writeStateChangeset(block *types.Block, changeset *types.StateChangeset) {
+	if changeset == nil {
+		return
+	}
+	number := block.NumberU64()
+	changeset.BlockHash = block.Hash()
+	rawdb.WriteStateChangeset(bc.db, number, changeset.BlockHash, changeset)
+
+	tail := rawdb.ReadStateChangesetTail(bc.db)
+	if tail == nil {
+		rawdb.WriteStateChangesetTail(bc.db, number)
+		return
+	}
+	retention := bc.cacheConfig.StateChangesetRetention
+	if retention == 0 || number < retention {
+		return
+	}
+	limit := number - retention + 1 // oldest block to keep
+	if *tail >= limit {
+		return
+	}
+	next := *tail
+	for ; next < limit && next-*tail < maxChangesetPrunePerBlock; next++ {
+		rawdb.DeleteStateChangesets(bc.db, next)
+	}
+	rawdb.WriteStateChangesetTail(bc.db, next)
+}
+
+// GetStateChangeset retrieves the state changeset of the given block, or nil if
+// it was not recorded or was already pruned.
+func (bc *BlockChain) GetStateChangeset(hash common.Hash, number uint64) *types.StateChangeset {
+	return rawdb.ReadStateChangeset(bc.db, number, hash)
+}
+
+// startOnlinePruning starts the background pruning of the hash-based state.
+// Archive nodes keeping the state of every block have nothing to prune, while
+// sparse archive nodes retain the state of every block they persisted.
+func (bc *BlockChain) startOnlinePruning(config pruner.OnlineConfig) {
+	if bc.cacheConfig.TrieDirtyDisabled {
+		if bc.cacheConfig.MaxNumberOfBlocksToSkipStateSaving == 0 && bc.cacheConfig.MaxAmountOfGasToSkipStateSaving == 0 {
+			log.Warn("Online state pruning disabled in archive mode")
+			return
+		}
+		config.KeepCheckpoints = true
+	}
+	p, err := pruner.NewOnlinePruner(bc.db, bc.triedb, bc, config)
+	if err != nil {
+		log.Warn("Online state pruning disabled", "err", err)
+		return
+	}
+	bc.onlinePruner = p
+}
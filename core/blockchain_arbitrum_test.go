@@ -0,0 +1,118 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the state changeset of every block is stored when enabled, and
+// that changesets falling out of the retention window are pruned.
+func TestStateChangesets(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		dest     = common.HexToAddress("0xdead")
+		contract = common.HexToAddress("0xc0de")
+		gspec    = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// PUSH1 1, PUSH1 0, SSTORE
+				contract: {Balance: common.Big0, Code: common.FromHex("0x6001600055")},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, func(i int, gen *BlockGen) {
+		if i != 0 {
+			return
+		}
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr), dest, big.NewInt(1000), params.TxGas, gen.header.BaseFee, nil), signer, key)
+		gen.AddTx(tx)
+		tx, _ = types.SignTx(types.NewTransaction(gen.TxNonce(addr), contract, common.Big0, 100000, gen.header.BaseFee, nil), signer, key)
+		gen.AddTx(tx)
+	})
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.StateChangesets = true
+	cacheConfig.StateChangesetRetention = 2
+
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, cacheConfig, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	changesets := make(chan StateChangesetEvent, len(blocks))
+	sub := chain.SubscribeStateChangesetEvent(changesets)
+	defer sub.Unsubscribe()
+
+	// Insert the first block only and check its changeset
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	changeset := chain.GetStateChangeset(blocks[0].Hash(), 1)
+	if changeset == nil {
+		t.Fatal("state changeset of block #1 missing")
+	}
+	if changeset.BlockNumber != 1 || changeset.BlockHash != blocks[0].Hash() {
+		t.Fatalf("changeset block mismatch: have #%d [%x], want #1 [%x]", changeset.BlockNumber, changeset.BlockHash, blocks[0].Hash())
+	}
+	changes := make(map[common.Address]*types.AccountChange)
+	for _, change := range changeset.Accounts {
+		changes[change.Address] = change
+	}
+	if change := changes[addr]; change == nil || change.PrevNonce != 0 || change.Nonce != 2 || change.Balance.Cmp(change.PrevBalance) >= 0 {
+		t.Errorf("sender change mismatch: %+v", change)
+	}
+	if change := changes[dest]; change == nil || change.PrevBalance.Sign() != 0 || change.Balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("recipient change mismatch: %+v", change)
+	}
+	if change := changes[contract]; change == nil || len(change.Storage) != 1 {
+		t.Errorf("contract change mismatch: %+v", change)
+	} else if slot := change.Storage[0]; slot.Slot != (common.Hash{}) || slot.Prev != (common.Hash{}) || slot.Value != common.BigToHash(common.Big1) {
+		t.Errorf("contract slot mismatch: %+v", slot)
+	}
+	select {
+	case ev := <-changesets:
+		if ev.Changeset.BlockHash != blocks[0].Hash() {
+			t.Errorf("changeset event block mismatch: have %x, want %x", ev.Changeset.BlockHash, blocks[0].Hash())
+		}
+	default:
+		t.Error("no changeset event fired")
+	}
+	// Insert the rest of the chain and ensure old changesets get pruned
+	if _, err := chain.InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for i, block := range blocks {
+		have := chain.GetStateChangeset(block.Hash(), block.NumberU64()) != nil
+		if want := block.NumberU64() > uint64(len(blocks))-cacheConfig.StateChangesetRetention; have != want {
+			t.Errorf("block #%d: changeset presence mismatch: have %v, want %v", i+1, have, want)
+		}
+	}
+}
@@ -445,3 +445,8 @@ func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 func (bc *BlockChain) SubscribeBlockProcessingEvent(ch chan<- bool) event.Subscription {
 	return bc.scope.Track(bc.blockProcFeed.Subscribe(ch))
 }
+
+// SubscribeStateChangesetEvent registers a subscription of StateChangesetEvent.
+func (bc *BlockChain) SubscribeStateChangesetEvent(ch chan<- StateChangesetEvent) event.Subscription {
+	return bc.scope.Track(bc.changesetFeed.Subscribe(ch))
+}
@@ -0,0 +1,283 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var errIndexInterrupted = errors.New("indexing interrupted")
+
+// canonicalIndex is an index of the canonical chain built block by block. The
+// indexed blocks are the contiguous range between its tail and its head, the
+// head hash detecting the blocks dropped by reorgs.
+type canonicalIndex interface {
+	// name identifies the index in the logs.
+	name() string
+
+	readHead(db ethdb.KeyValueReader) (uint64, common.Hash, bool)
+	writeHead(db ethdb.KeyValueWriter, number uint64, hash common.Hash)
+	readTail(db ethdb.KeyValueReader) *uint64
+	writeTail(db ethdb.KeyValueWriter, number uint64)
+
+	// index writes the entries of the canonical block into the batch. Entries
+	// of newer blocks are kept when backfilling below the tail.
+	index(db ethdb.Reader, batch ethdb.KeyValueWriter, number uint64, hash common.Hash, backfill bool)
+
+	// unindex deletes the entries of the block dropped from the canonical chain.
+	unindex(db ethdb.Reader, batch ethdb.KeyValueWriter, number uint64, hash common.Hash)
+}
+
+// canonicalIndexer maintains a canonicalIndex as the chain head moves. It
+// indexes the new blocks and unindexes the blocks dropped by reorgs, blocks
+// older than the ones present when the index was first enabled being indexed
+// by backfillIndex.
+type canonicalIndexer struct {
+	db       ethdb.Database
+	index    canonicalIndex
+	quit     chan struct{}
+	quitOnce sync.Once
+	closed   chan struct{}
+}
+
+// newCanonicalIndexer starts maintaining the index of the given chain.
+func newCanonicalIndexer(chain *BlockChain, index canonicalIndex) *canonicalIndexer {
+	indexer := &canonicalIndexer{
+		db:     chain.db,
+		index:  index,
+		quit:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+	go indexer.loop(chain)
+	return indexer
+}
+
+// Range returns the range of indexed blocks, reporting whether any block is
+// indexed.
+func (indexer *canonicalIndexer) Range() (uint64, uint64, bool) {
+	return indexRange(indexer.db, indexer.index)
+}
+
+// indexRange returns the range of blocks of the index in the database,
+// reporting whether any block is indexed.
+func indexRange(db ethdb.KeyValueReader, index canonicalIndex) (uint64, uint64, bool) {
+	head, _, ok := index.readHead(db)
+	if !ok {
+		return 0, 0, false
+	}
+	tail := index.readTail(db)
+	if tail == nil || *tail > head {
+		return 0, 0, false
+	}
+	return *tail, head, true
+}
+
+// loop updates the index every time the chain head changes. The updates run
+// in the background, the heads received in the meantime being coalesced into
+// the next update.
+func (indexer *canonicalIndexer) loop(chain *BlockChain) {
+	defer close(indexer.closed)
+
+	var (
+		done    chan struct{} // Non-nil if background update is active
+		pending *types.Header // The latest head received during the background update
+
+		headCh = make(chan ChainHeadEvent)
+		sub    = chain.SubscribeChainHeadEvent(headCh)
+	)
+	defer sub.Unsubscribe()
+
+	run := func(head *types.Header) {
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			if err := indexer.update(head, indexer.quit); err != nil && !errors.Is(err, errIndexInterrupted) {
+				log.Error("Failed to update index", "index", indexer.index.name(), "number", head.Number, "hash", head.Hash(), "err", err)
+			}
+		}()
+	}
+	run(chain.CurrentBlock())
+	for {
+		select {
+		case ev := <-headCh:
+			if done == nil {
+				run(ev.Block.Header())
+			} else {
+				pending = ev.Block.Header()
+			}
+		case <-done:
+			done = nil
+			if pending != nil {
+				run(pending)
+				pending = nil
+			}
+		case <-indexer.quit:
+			if done != nil {
+				<-done
+			}
+			return
+		}
+	}
+}
+
+// update moves the head of the index to the given canonical head, unindexing
+// the blocks which are no longer canonical first.
+func (indexer *canonicalIndexer) update(head *types.Header, stop chan struct{}) error {
+	var (
+		db    = indexer.db
+		index = indexer.index
+	)
+	number, hash, ok := index.readHead(db)
+	if !ok {
+		// The index was just enabled, start indexing from the next block on
+		batch := db.NewBatch()
+		index.writeTail(batch, head.Number.Uint64()+1)
+		index.writeHead(batch, head.Number.Uint64(), head.Hash())
+		log.Info("Initialized indexer", "index", index.name(), "head", head.Number)
+		return batch.Write()
+	}
+	batch := db.NewBatch()
+
+	// Unindex the blocks of the old chain in case of a reorg or rewind
+	var unwound bool
+	for number > head.Number.Uint64() || rawdb.ReadCanonicalHash(db, number) != hash {
+		header := rawdb.ReadHeader(db, hash, number)
+		if header == nil {
+			return fmt.Errorf("missing indexed header #%d [%x]", number, hash)
+		}
+		index.unindex(db, batch, number, hash)
+		number, hash = number-1, header.ParentHash
+		index.writeHead(batch, number, hash)
+		unwound = true
+	}
+	if tail := index.readTail(db); unwound && tail != nil && *tail > number+1 {
+		// The chain was rewound below the tail, keep the indexed range empty
+		index.writeTail(batch, number+1)
+	}
+	// Index the blocks of the new chain
+	var (
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for number < head.Number.Uint64() {
+		number++
+		if hash = rawdb.ReadCanonicalHash(db, number); hash == (common.Hash{}) {
+			return fmt.Errorf("missing canonical hash #%d", number)
+		}
+		index.index(db, batch, number, hash, false)
+		index.writeHead(batch, number, hash)
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+			select {
+			case <-stop:
+				return errIndexInterrupted
+			default:
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Indexing blocks", "index", index.name(), "number", number, "head", head.Number, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	return batch.Write()
+}
+
+// Close stops the indexer, waiting for the pending index writes to be flushed.
+// Safe to be called multiple times.
+func (indexer *canonicalIndexer) Close() {
+	indexer.quitOnce.Do(func() { close(indexer.quit) })
+	<-indexer.closed
+}
+
+// backfillIndex indexes the canonical blocks from the given number up to the
+// tail of the index, or up to the chain head if the index doesn't exist yet.
+// It's meant to backfill the index for existing history while the node is not
+// running.
+func backfillIndex(db ethdb.Database, index canonicalIndex, from uint64, interrupt chan struct{}) error {
+	var (
+		end   uint64
+		batch = db.NewBatch()
+	)
+	if _, _, ok := index.readHead(db); ok {
+		tail := index.readTail(db)
+		if tail == nil || *tail <= from {
+			log.Info("Blocks already indexed", "index", index.name(), "from", from)
+			return nil
+		}
+		end = *tail
+	} else {
+		hash := rawdb.ReadHeadBlockHash(db)
+		number := rawdb.ReadHeaderNumber(db, hash)
+		if number == nil {
+			return errors.New("missing head block")
+		}
+		if rawdb.ReadHeader(db, hash, *number) == nil {
+			return errors.New("missing head block")
+		}
+		end = *number + 1
+		index.writeHead(batch, *number, hash)
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+	)
+	// Index backwards from the tail, so the index stays contiguous if the
+	// process gets interrupted.
+	for number := end; number > from; {
+		number--
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			return fmt.Errorf("missing canonical hash #%d", number)
+		}
+		index.index(db, batch, number, hash, true)
+		index.writeTail(batch, number)
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+			select {
+			case <-interrupt:
+				return errIndexInterrupted
+			default:
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Indexing blocks", "index", index.name(), "number", number, "from", from, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Indexed blocks", "index", index.name(), "from", from, "to", end-1, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
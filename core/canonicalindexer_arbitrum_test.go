@@ -0,0 +1,142 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testCanonicalIndexer tests that the index follows the canonical chain through
+// reorgs, and that backfillIndex backfills an existing chain. The contract is
+// called by the key in the even blocks of the chain, and in the odd blocks of
+// the fork after block 5. verify checks the index of the given range against
+// the blocks selected by called.
+func testCanonicalIndexer(t *testing.T, index canonicalIndex, key *ecdsa.PrivateKey, contract common.Address, code []byte, verify func(db ethdb.Database, from, to uint64, called func(number uint64) bool)) {
+	t.Helper()
+	var (
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				address:  {Balance: big.NewInt(1000000000000000000)},
+				contract: {Code: code, Balance: common.Big0},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		engine = ethash.NewFaker()
+	)
+	even := func(number uint64) bool { return number > 0 && number%2 == 0 }
+	odd := func(number uint64) bool { return number%2 == 1 }
+
+	// callContract calls the contract in the blocks selected by call
+	callContract := func(call func(number uint64) bool) func(int, *BlockGen) {
+		return func(i int, gen *BlockGen) {
+			if call(gen.Number().Uint64()) {
+				tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(address), contract, common.Big0, 50000, big.NewInt(10*params.InitialBaseFee), nil), types.HomesteadSigner{}, key)
+				gen.AddTx(tx)
+			}
+		}
+	}
+	genDb, chainA, _ := GenerateChainWithGenesis(gspec, engine, 10, callContract(even))
+	chainB, _ := GenerateChain(gspec.Config, chainA[4], engine, genDb, 7, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+		callContract(odd)(i, gen)
+	})
+
+	// waitHead waits until the index reaches the given head
+	waitHead := func(indexer *canonicalIndexer, head uint64) {
+		t.Helper()
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+			if _, number, ok := indexer.Range(); ok && number == head {
+				return
+			}
+		}
+		tail, number, ok := indexer.Range()
+		t.Fatalf("index did not reach head %d: range [%d, %d], ok %v", head, tail, number, ok)
+	}
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, nil, gspec.Config, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	indexer := newCanonicalIndexer(chain, index)
+	defer indexer.Close()
+
+	// Wait for the indexer to initialize at genesis before importing
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if _, _, ok := index.readHead(db); ok {
+			break
+		}
+	}
+	if _, err := chain.InsertChain(chainA); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	waitHead(indexer, 10)
+	verify(db, 1, 10, even)
+
+	// Reorg to a longer fork calling the contract in the odd blocks after block 5
+	if _, err := chain.InsertChain(chainB); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	waitHead(indexer, 12)
+	verify(db, 1, 5, even)
+	verify(db, 6, 12, odd)
+	if _, hash, _ := index.readHead(db); hash != chainB[len(chainB)-1].Hash() {
+		t.Fatalf("index head mismatch: have %x, want %x", hash, chainB[len(chainB)-1].Hash())
+	}
+
+	// Backfill the index of a chain imported without the indexer
+	db2 := rawdb.NewMemoryDatabase()
+	chain2, err := NewBlockChain(db2, nil, gspec.Config, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain2.Stop()
+	if _, err := chain2.InsertChain(chainA); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if err := backfillIndex(db2, index, 4, nil); err != nil {
+		t.Fatalf("failed to backfill index: %v", err)
+	}
+	if tail, head, ok := indexRange(db2, index); !ok || tail != 4 || head != 10 {
+		t.Fatalf("unexpected index range: [%d, %d], ok %v", tail, head, ok)
+	}
+	verify(db2, 1, 3, func(uint64) bool { return false })
+	verify(db2, 4, 10, even)
+
+	if err := backfillIndex(db2, index, 0, nil); err != nil {
+		t.Fatalf("failed to backfill index: %v", err)
+	}
+	if tail, head, ok := indexRange(db2, index); !ok || tail != 0 || head != 10 {
+		t.Fatalf("unexpected index range: [%d, %d], ok %v", tail, head, ok)
+	}
+	verify(db2, 0, 10, even)
+}
@@ -41,3 +41,7 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// StateChangesetEvent is posted when the state changeset of a new canonical
+// block has been stored.
+type StateChangesetEvent struct{ Changeset *types.StateChangeset }
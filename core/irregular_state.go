@@ -0,0 +1,144 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// IrregularStateChange is a change of the state of an account made outside of
+// the execution of transactions, at the start of its block.
+type IrregularStateChange struct {
+	Block   uint64
+	Address common.Address
+	Name    string // description of the change, for logging
+
+	Code    []byte                      // new code, nil to keep the code
+	Storage map[common.Hash]common.Hash // storage slots to set
+	Balance *uint256.Int                // new balance, nil to keep the balance
+
+	// CodeHash is the expected code hash of the account once changed, checked
+	// against the code of the change and against the state of synced nodes.
+	CodeHash common.Hash
+}
+
+// apply makes the change to the state. Only the differing values are written,
+// so that applying the change again is a noop.
+func (c *IrregularStateChange) apply(statedb vm.StateDB) {
+	if c.Code != nil && statedb.GetCodeHash(c.Address) != crypto.Keccak256Hash(c.Code) {
+		statedb.SetCode(c.Address, c.Code)
+	}
+	for key, value := range c.Storage {
+		if statedb.GetState(c.Address, key) != value {
+			statedb.SetState(c.Address, key, value)
+		}
+	}
+	if c.Balance != nil {
+		if balance := statedb.GetBalance(c.Address); balance.Cmp(c.Balance) > 0 {
+			statedb.SubBalance(c.Address, new(uint256.Int).Sub(balance, c.Balance))
+		} else if balance.Cmp(c.Balance) < 0 {
+			statedb.AddBalance(c.Address, new(uint256.Int).Sub(c.Balance, balance))
+		}
+	}
+}
+
+// Verify checks the state against the change, which must have been applied.
+func (c *IrregularStateChange) Verify(statedb vm.StateDB) error {
+	if hash := statedb.GetCodeHash(c.Address); c.CodeHash != (common.Hash{}) && hash != c.CodeHash {
+		return fmt.Errorf("%s: code hash of %v is %v, want %v", c.Name, c.Address, hash, c.CodeHash)
+	}
+	for key, value := range c.Storage {
+		if have := statedb.GetState(c.Address, key); have != value {
+			return fmt.Errorf("%s: storage slot %v of %v is %v, want %v", c.Name, key, c.Address, have, value)
+		}
+	}
+	return nil
+}
+
+// IrregularStateChanges returns the irregular state changes of the chain, by
+// block number.
+func IrregularStateChanges(config *params.ChainConfig) []IrregularStateChange {
+	return getKintoRules(config).stateChanges
+}
+
+// ApplyIrregularStateChanges applies the irregular state changes of the block
+// to the state, before its first transaction. The changes of a block are made
+// once per state, applying them again being a noop.
+//
+// The state processor and the chain maker apply them, the block producers and
+// the tracers replaying a block from the state of its parent must do the same.
+func ApplyIrregularStateChanges(config *params.ChainConfig, number *big.Int, statedb *state.StateDB) {
+	if number == nil || !number.IsUint64() {
+		return
+	}
+	var (
+		block   = number.Uint64()
+		changes = getKintoRules(config).stateChanges
+		marked  bool
+	)
+	for i := range changes {
+		if change := &changes[i]; change.Block == block {
+			if !marked && !statedb.MarkIrregularStateChanges(block) {
+				return
+			}
+			marked = true
+			change.apply(statedb)
+		}
+	}
+}
+
+// kintoStateChanges returns the bytecode replacements of the Kinto hardforks.
+func kintoStateChanges(p *params.KintoChainParams) []IrregularStateChange {
+	return []IrregularStateChange{
+		{
+			Block:    p.Hardfork5Block,
+			Address:  p.Contracts.Create2Factory,
+			Name:     "Kinto hardfork 5 create2 factory",
+			Code:     replacedHF5Bytecode,
+			CodeHash: common.HexToHash("0x22564bce71d63af01d877d05d77064fa938cc83e4a5f091702c46fbcfe57a370"),
+		},
+		{
+			Block:    p.Hardfork6Block,
+			Address:  p.Contracts.EntryPointV7,
+			Name:     "Kinto hardfork 6 EntryPoint v0.7",
+			Code:     entryPointV7Bytecode,
+			CodeHash: common.HexToHash("0xf2d942f62407517016266f94f12e1fb183035d9750ac65c74f935b629abe2f28"),
+		},
+		{
+			Block:    p.Hardfork6Block,
+			Address:  p.Contracts.Create2Factory,
+			Name:     "Kinto hardfork 6 create2 factory",
+			Code:     vanillaCreate2FactoryBytecode,
+			CodeHash: common.HexToHash("0x2fa86add0aed31f33a762c9d88e807c475bd51d0f52bd0955754b2608f7e4989"),
+		},
+		{
+			Block:    p.Hardfork7Block,
+			Address:  p.Contracts.EntryPointV7,
+			Name:     "Kinto hardfork 7 EntryPoint v0.7",
+			Code:     entryPointV7OriginalBytecode,
+			CodeHash: common.HexToHash("0x8db5ff695839d655407cc8490bb7a5d82337a86a6b39c3f0258aa6c3b582fc58"),
+		},
+	}
+}
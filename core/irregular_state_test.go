@@ -0,0 +1,120 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestIrregularStateChanges(t *testing.T) {
+	config, statedb := newKintoTestChain(t)
+	kinto := config.Kinto()
+
+	changes := IrregularStateChanges(config)
+	for _, change := range changes {
+		if hash := crypto.Keccak256Hash(change.Code); hash != change.CodeHash {
+			t.Errorf("%s: code hash %v, want %v", change.Name, hash, change.CodeHash)
+		}
+	}
+
+	// Hardfork 6 replaces the EntryPoint v0.7 and the create2 factory
+	ApplyIrregularStateChanges(config, big.NewInt(int64(kinto.Hardfork6Block)-1), statedb)
+	if code := statedb.GetCode(kinto.Contracts.EntryPointV7); len(code) != 0 {
+		t.Fatal("code replaced before the hardfork block")
+	}
+	ApplyIrregularStateChanges(config, big.NewInt(int64(kinto.Hardfork6Block)), statedb)
+	for _, change := range changes {
+		if change.Block == kinto.Hardfork6Block {
+			if err := change.Verify(statedb); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	// Hardfork 7 restores the original EntryPoint v0.7
+	ApplyIrregularStateChanges(config, big.NewInt(int64(kinto.Hardfork7Block)), statedb)
+	if hash := statedb.GetCodeHash(kinto.Contracts.EntryPointV7); hash != changes[len(changes)-1].CodeHash {
+		t.Errorf("EntryPoint v0.7 code hash %v after hardfork 7", hash)
+	}
+}
+
+func TestIrregularStateChangesAtBlockStart(t *testing.T) {
+	config, statedb := newKintoTestChain(t)
+	kinto := config.Kinto()
+	var (
+		from     = common.HexToAddress("0x1000")
+		to       = kinto.Contracts.WalletFactory
+		blockCtx = vm.BlockContext{
+			CanTransfer: CanTransfer,
+			Transfer:    Transfer,
+			BlockNumber: big.NewInt(int64(kinto.Hardfork6Block)),
+			Difficulty:  new(big.Int),
+			BaseFee:     new(big.Int),
+			GasLimit:    params.GenesisGasLimit,
+		}
+	)
+	// Messages don't make the changes, the block processing does
+	statedb.SetBalance(from, uint256.NewInt(params.Ether))
+	msg := &Message{From: from, To: &to, Value: new(big.Int), GasLimit: params.TxGas, GasPrice: new(big.Int), GasFeeCap: new(big.Int), GasTipCap: new(big.Int)}
+	evm := vm.NewEVM(blockCtx, NewEVMTxContext(msg), statedb, config, vm.Config{})
+	if _, err := ApplyMessage(evm, msg, new(GasPool).AddGas(params.GenesisGasLimit)); err != nil {
+		t.Fatalf("message failed: %v", err)
+	}
+	if code := statedb.GetCode(kinto.Contracts.EntryPointV7); len(code) != 0 {
+		t.Fatal("code replaced by a message")
+	}
+	var (
+		gspec = &Genesis{Config: config, BaseFee: big.NewInt(params.InitialBaseFee)}
+		n     = int(kinto.Hardfork6Block)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), n, nil)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	before, _ := chain.StateAt(blocks[n-2].Root())
+	if code := before.GetCode(kinto.Contracts.EntryPointV7); len(code) != 0 {
+		t.Fatal("code replaced before the hardfork block")
+	}
+	after, _ := chain.StateAt(blocks[n-1].Root())
+	for _, change := range IrregularStateChanges(config) {
+		if change.Block == kinto.Hardfork6Block {
+			if err := change.Verify(after); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	// The changes of a block are made once per state
+	ApplyIrregularStateChanges(config, blockCtx.BlockNumber, statedb)
+	statedb.SetCode(kinto.Contracts.EntryPointV7, nil)
+	ApplyIrregularStateChanges(config, blockCtx.BlockNumber, statedb)
+	if code := statedb.GetCode(kinto.Contracts.EntryPointV7); len(code) != 0 {
+		t.Fatal("changes of the block made twice")
+	}
+}
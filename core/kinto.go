@@ -2,67 +2,172 @@ package core
 
 import (
 	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
 )
 
-// Kinto addresses mainnet
-
-var (
-	aaEntryPointEnvAddress      = common.HexToAddress("0x2843C269D2a64eCfA63548E8B3Fc0FD23B7F70cb")
-	kintoIdEnvAddress           = common.HexToAddress("0xf369f78E3A0492CC4e96a90dae0728A38498e9c7")
-	walletFactoryAddress        = common.HexToAddress("0x8a4720488CA32f1223ccFE5A087e250fE3BC5D75")
-	paymasterAddress            = common.HexToAddress("0x1842a4EFf3eFd24c50B63c3CF89cECEe245Fc2bd")
-	appRegistryAddress          = common.HexToAddress("0x5A2b641b84b0230C8e75F55d5afd27f4Dbd59d5b")
-	upgradeExecutor             = common.HexToAddress("0x88e03D41a6EAA9A0B93B0e2d6F1B34619cC4319b")
-	customGatewayAddress        = common.HexToAddress("0x06FcD8264caF5c28D86eb4630c20004aa1faAaA8")
-	gatewayRouterAddress        = common.HexToAddress("0x340487b92808B84c2bd97C87B590EE81267E04a7")
-	standardGatewayAddress      = common.HexToAddress("0x87799989341A07F495287B1433eea98398FD73aA")
-	wethGateWayAddress          = common.HexToAddress("0xd563ECBDF90EBA783d0a218EFf158C1263ad02BE")
-	bundleBulker                = common.HexToAddress("0x8d2D899402ed84b6c0510bB1ad34ee436ADDD20d")
-	arbRetrayableTx             = common.HexToAddress("0x000000000000000000000000000000000000006E")
-	socket                      = common.HexToAddress("0x3e9727470C66B1e77034590926CDe0242B5A3dCc")
-	socketExecutionManager      = common.HexToAddress("0x6c914cc610e9a05eaFFfD79c10c60Ad1704717E5")
-	socketTransmitManager       = common.HexToAddress("0x6332e56A423480A211E301Cb85be12814e9238Bb")
-	socketFastSwitchboard       = common.HexToAddress("0x516302D1b25e5F6d1ac90eF7256270cd799524CF")
-	socketOptimisticSwitchboard = common.HexToAddress("0x2B98775aBE9cDEb041e3c2E56C76ce2560AF57FB")
-	socketBatcher               = common.HexToAddress("0x12FF8947a2524303C13ca7dA9bE4914381f6557a")
-	socketSimulator             = common.HexToAddress("0x72846179EF1467B2b71F2bb7525fcD4450E46B2A")
-	socketSimulatorUtils        = common.HexToAddress("0x897DA4D039f64090bfdb33cd2Ed2Da81adD6FB02")
-	socketSwitchboardSimulator  = common.HexToAddress("0xa7527C270f30cF3dAFa6e82603b4978e1A849359")
-	socketCapacitorSimulator    = common.HexToAddress("0x6dbB5ee7c63775013FaF810527DBeDe2810d7Aee")
-	create2Factory              = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
-	aaEntryPointEnvAddressV7    = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+// Kinto rules rejecting transactions, as reported by KintoRuleError.
+const (
+	KintoRuleContractCreation     = "contract-creation"     // contract creations are not allowed
+	KintoRuleDestination          = "destination"           // destination not in the allowlist of the hardfork
+	KintoRuleEntryPointWithdraw   = "entrypoint-withdraw"   // withdrawals from the EntryPoint
+	KintoRuleHandleOpsBeneficiary = "handleops-beneficiary" // handleOps with a beneficiary other than the sender
+	KintoRuleEntryPointFunction   = "entrypoint-function"   // EntryPoint function not allowed
+	KintoRulePaymasterFunction    = "paymaster-function"    // paymaster function not allowed
+	KintoRuleAppRegistry          = "app-registry"          // contract call from an EOA rejected by the AppRegistry
 )
 
-// Kinto addresses devnet
-
-/*var (
-	aaEntryPointEnvAddress      = common.HexToAddress("0x3Ac318F6E5479606d95659EDE2A01c92A8dd8c42")
-	kintoIdEnvAddress           = common.HexToAddress("0x951499DDb7af748186CEa5bE861F355998E2e527")
-	walletFactoryAddress        = common.HexToAddress("0x9905f57926c0E679Eb3a2974b7B18F0C97b79d2c")
-	paymasterAddress            = common.HexToAddress("0x9CC6A053764c30eB8eA9c9cac0d714F476a39F8c")
-	appRegistryAddress          = common.HexToAddress("0x6E1B1A6220eFc0671A6362cA3B5BB7BCE60dC12B")
-	upgradeExecutor             = common.HexToAddress("0x6B0d3F40DeD9720938DB274f752F1e11532c2640")
-	customGatewayAddress        = common.HexToAddress("0x094F8C3eA1b5671dd19E15eCD93C80d2A33fCA99")
-	gatewayRouterAddress        = common.HexToAddress("0xf3AC740Fcc64eEd76dFaE663807749189A332d54")
-	standardGatewayAddress      = common.HexToAddress("0x6A8d32c495df943212B7788114e41103047150a5")
-	wethGateWayAddress          = common.HexToAddress("0x79B47F0695608aD8dc90E400a3E123b02eB72D24")
-	bundleBulker                = common.HexToAddress("0x2291d967F4f8E7B062D0eAA977C5adBbd33B99BB")
-	arbRetrayableTx             = common.HexToAddress("0x000000000000000000000000000000000000006E")
-	socket                      = common.HexToAddress("0x62B421B7dbc6207CC010318a4ba567786137de29")
-	socketExecutionManager      = common.HexToAddress("0x4518D09052D6f40f83d489a3E9F81EF369dB0753")
-	socketTransmitManager       = common.HexToAddress("0x956b0c4d2f3f050bDB6A5b6B6a95050af9fA3A62")
-	socketFastSwitchboard       = common.HexToAddress("0x38cACa8a8b5579Cb2d2870A73DbfAa54B6Ee490D")
-	socketOptimisticSwitchboard = common.HexToAddress("0xC94De3804d3c67620E7a70547bCB4a77b53952EC")
-	socketBatcher               = common.HexToAddress("0x1d1ef33231689d6057565f99d8B1864E6bE5eb94")
-	socketSimulator             = common.HexToAddress("0xbfd616DA87ebea4513aB633C9298218dd4a698dc")
-	socketSimulatorUtils        = common.HexToAddress("0x93f73A15272D4D46720234C32BC1eE7290Eb5F18")
-	socketSwitchboardSimulator  = common.HexToAddress("0x108eE40304fB1C3560eFF91f8E15B52ea4E2a257")
-	socketCapacitorSimulator    = common.HexToAddress("0x1390e33B8F1D6D92e27fcEF2c6E5641Be951A2bb")
-	create2Factory              = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
-	aaEntryPointEnvAddressV7    = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
-)*/
+// kintoRuleNames are the rules of the KintoRuleErrors, and "unknown" for the
+// other rejections.
+var kintoRuleNames = []string{
+	KintoRuleContractCreation, KintoRuleDestination, KintoRuleEntryPointWithdraw, KintoRuleHandleOpsBeneficiary,
+	KintoRuleEntryPointFunction, KintoRulePaymasterFunction, KintoRuleAppRegistry, "unknown",
+}
+
+// KintoRuleError is the error of a transaction rejected by the Kinto rules. It
+// wraps ErrKintoNotAllowed.
+type KintoRuleError struct {
+	Rule        string          // rule rejecting the transaction
+	Hardfork    int             // hardfork whose rules apply, 0 for the original ones
+	Destination *common.Address // destination of the transaction, nil for contract creations
+	msg         string
+}
+
+func (e *KintoRuleError) Error() string {
+	return ErrKintoNotAllowed.Error() + ": " + e.msg
+}
+
+func (e *KintoRuleError) Unwrap() error {
+	return ErrKintoNotAllowed
+}
+
+// kintoRejection returns the error of a transaction rejected by the rule. The
+// hardfork is set by checkKintoRules.
+func kintoRejection(rule string, destination *common.Address, format string, args ...interface{}) error {
+	return &KintoRuleError{Rule: rule, Destination: destination, msg: fmt.Sprintf(format, args...)}
+}
+
+// kintoRules are the Kinto rules of a chain, with the allowlists of the
+// destinations of the transactions of its hardforks.
+type kintoRules struct {
+	*params.KintoChainParams
+
+	original  map[common.Address]bool
+	hardfork1 map[common.Address]bool
+	hardfork2 map[common.Address]bool
+	hardfork3 map[common.Address]bool
+	hardfork4 map[common.Address]bool
+	hardfork5 map[common.Address]bool
+	hardfork6 map[common.Address]bool
+
+	stateChanges []IrregularStateChange // bytecode replacements of the hardforks
+
+	contractNames map[common.Address]string // metric labels of the Kinto contracts
+
+	hardforkMeters    []*kintoMeters          // decisions by hardfork
+	destinationMeters map[string]*kintoMeters // decisions by destination label
+}
+
+// kintoMeters account the decisions of the Kinto rules.
+type kintoMeters struct {
+	allowed  metrics.Meter
+	failOpen metrics.Meter
+	denied   metrics.Meter            // set for the destinations
+	rules    map[string]metrics.Meter // denials by rule, set for the hardforks
+}
+
+// kintoRulesCache holds the rules of the Kinto parameters in use.
+var kintoRulesCache sync.Map // params.KintoChainParams -> *kintoRules
+
+// getKintoRules returns the Kinto rules of the chain.
+func getKintoRules(config *params.ChainConfig) *kintoRules {
+	p := config.Kinto()
+	if rules, ok := kintoRulesCache.Load(*p); ok {
+		return rules.(*kintoRules)
+	}
+	params := *p
+	c := &params.Contracts
+	rules := &kintoRules{
+		KintoChainParams: &params,
+		original:         addressSet(originalKintoAddresses(c)),
+		hardfork1:        addressSet(hardfork1KintoAddresses(c)),
+		hardfork2:        addressSet(hardfork2KintoAddresses(c)),
+		hardfork3:        addressSet(hardfork3KintoAddresses(c)),
+		hardfork4:        addressSet(hardfork4KintoAddresses(c)),
+		hardfork5:        addressSet(hardfork5KintoAddresses(c)),
+		hardfork6:        addressSet(hardfork6KintoAddresses(c)),
+		stateChanges:     kintoStateChanges(&params),
+		contractNames:    contractNames(c),
+	}
+	rules.registerMeters()
+	actual, _ := kintoRulesCache.LoadOrStore(*p, rules)
+	return actual.(*kintoRules)
+}
+
+// registerMeters registers the meters of the decisions of the rules, by
+// hardfork and by destination.
+func (rules *kintoRules) registerMeters() {
+	for hardfork := 0; hardfork <= 7; hardfork++ {
+		prefix := fmt.Sprintf("kinto/hf%d/", hardfork)
+		meters := &kintoMeters{
+			allowed:  metrics.GetOrRegisterMeter(prefix+"allowed", nil),
+			failOpen: metrics.GetOrRegisterMeter(prefix+"failopen", nil),
+			rules:    make(map[string]metrics.Meter, len(kintoRuleNames)),
+		}
+		for _, rule := range kintoRuleNames {
+			meters.rules[rule] = metrics.GetOrRegisterMeter(prefix+"denied/"+rule, nil)
+		}
+		rules.hardforkMeters = append(rules.hardforkMeters, meters)
+	}
+	destinations := []string{"creation", "other"}
+	for _, name := range rules.contractNames {
+		destinations = append(destinations, name)
+	}
+	rules.destinationMeters = make(map[string]*kintoMeters)
+	for _, destination := range destinations {
+		if _, ok := rules.destinationMeters[destination]; ok {
+			continue
+		}
+		prefix := "kinto/destination/" + destination + "/"
+		rules.destinationMeters[destination] = &kintoMeters{
+			allowed:  metrics.GetOrRegisterMeter(prefix+"allowed", nil),
+			failOpen: metrics.GetOrRegisterMeter(prefix+"failopen", nil),
+			denied:   metrics.GetOrRegisterMeter(prefix+"denied", nil),
+		}
+	}
+}
+
+func addressSet(addresses []common.Address) map[common.Address]bool {
+	set := make(map[common.Address]bool, len(addresses))
+	for _, address := range addresses {
+		set[address] = true
+	}
+	return set
+}
+
+// contractNames returns the names of the Kinto contracts, by address.
+func contractNames(c *params.KintoContracts) map[common.Address]string {
+	names := make(map[common.Address]string)
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if address, ok := v.Field(i).Interface().(common.Address); ok && address != (common.Address{}) {
+			if _, ok := names[address]; !ok {
+				names[address] = strings.ToLower(v.Type().Field(i).Name)
+			}
+		}
+	}
+	return names
+}
 
 // Kinto-specific constants for function selectors
 const (
@@ -85,51 +190,225 @@ const (
 	beneficiaryOffset    = 32 // offset to skip the first 32 bytes of the data (function selector) to get to the beneficiary address
 )
 
-// enforceKinto decides which set of Kinto rules to apply based on the current block number
+// enforceKinto applies the Kinto rules of the current block to the message.
 func enforceKinto(msg *Message, st *StateTransition) error {
-	var currentBlockNumber = st.evm.Context.BlockNumber
+	if msg.TxRunMode == MessageEthcallMode {
+		return nil // Allow all calls
+	}
 
-	// Hardfork5 bytecode replacement (happens once)
-	if currentBlockNumber.Cmp(common.KintoHardfork5) == 0 {
-		st.state.SetCode(replaceHF5Address, replacedHF5Bytecode)
+	rules := getKintoRules(st.evm.ChainConfig())
+	err := checkKintoRules(rules, st)
+	if msg.TxRunMode == MessageCommitMode && rules.IsKintoRules(st.evm.Context.BlockNumber) {
+		rules.record(st, err)
 	}
+	return err
+}
 
-	//Hardfork6 bytecode replacement (happens once)
-	if currentBlockNumber.Cmp(common.KintoHardfork6) == 0 {
-		st.state.SetCode(aaEntryPointEnvAddressV7, entryPointV7Bytecode)
-		st.state.SetCode(create2Factory, vanillaCreate2FactoryBytecode)
+// kintoAppRegistryGas is the gas available to the AppRegistry calls.
+const kintoAppRegistryGas = 100000
+
+// callAppRegistry calls the AppRegistry before the execution of the message.
+// The call is traced as a top call frame, which the tracer marks as a call of
+// the Kinto rules if it's a vm.KintoCallLogger.
+func callAppRegistry(st *StateTransition, from, appRegistry common.Address, input []byte) ([]byte, error) {
+	tracer, _ := st.evm.Config.Tracer.(vm.KintoCallLogger)
+	if tracer != nil {
+		tracer.CaptureKintoCallStart("kinto:isContractCallAllowedFromEOA")
+	}
+	ret, _, err := st.evm.Call(vm.AccountRef(from), appRegistry, input, kintoAppRegistryGas, uint256.NewInt(0))
+	if tracer != nil {
+		tracer.CaptureKintoCallEnd()
 	}
+	return ret, err
+}
 
-	//Hardfork7 bytecode replacement (happens once)
-	if currentBlockNumber.Cmp(common.KintoHardfork7) == 0 {
-		st.state.SetCode(aaEntryPointEnvAddressV7, entryPointV7OriginalBytecode)
+// record accounts the decision of the Kinto rules on the message executed, by
+// hardfork and rule, and by destination, in the metrics:
+//
+//	kinto/hf<N>/allowed, kinto/hf<N>/failopen, kinto/hf<N>/denied/<rule>
+//	kinto/destination/<contract>/{allowed,failopen,denied}
+//
+// The destinations other than the Kinto contracts are accounted as "other".
+// The messages let through on a failure of the AppRegistry are logged. Only
+// the messages committed are recorded, not the ones replayed by the tracers.
+func (rules *kintoRules) record(st *StateTransition, err error) {
+	var (
+		msg         = st.msg
+		hardfork    = rules.Hardfork(st.evm.Context.BlockNumber)
+		destination = "creation"
+	)
+	if msg.To != nil {
+		destination = rules.contractNames[*msg.To]
+		if destination == "" {
+			destination = "other"
+		}
 	}
+	byHardfork, byDestination := rules.hardforkMeters[hardfork], rules.destinationMeters[destination]
+	switch {
+	case err != nil:
+		rule := "unknown"
+		if rejection, ok := err.(*KintoRuleError); ok {
+			rule = rejection.Rule
+		}
+		byHardfork.rules[rule].Mark(1)
+		byDestination.denied.Mark(1)
+	case st.kintoFailOpen != nil:
+		var hash common.Hash
+		if msg.Tx != nil {
+			hash = msg.Tx.Hash()
+		}
+		log.Warn("Kinto AppRegistry call failed, transaction allowed", "hash", hash, "hardfork", hardfork, "from", msg.From, "to", msg.To, "err", st.kintoFailOpen)
+		byHardfork.failOpen.Mark(1)
+		byDestination.failOpen.Mark(1)
+	default:
+		byHardfork.allowed.Mark(1)
+		byDestination.allowed.Mark(1)
+	}
+}
 
-	if msg.TxRunMode == MessageEthcallMode {
-		return nil // Allow all calls
+// CheckKintoTransaction applies the Kinto rules of the block of the EVM to the
+// message, without executing it nor applying the bytecode replacements of the
+// hardforks. The rules of hardforks 6 and 7 call the AppRegistry contract in the
+// state of the EVM. The rejections are reported as *KintoRuleError.
+func CheckKintoTransaction(evm *vm.EVM, msg *Message) error {
+	st := &StateTransition{
+		gp:    new(GasPool).AddGas(math.MaxUint64),
+		evm:   evm,
+		msg:   msg,
+		state: evm.StateDB,
 	}
+	return checkKintoRules(getKintoRules(evm.ChainConfig()), st)
+}
+
+// KintoExplanation details how the Kinto rules of a block apply to a message.
+type KintoExplanation struct {
+	RulesActive bool // whether the Kinto rules are enforced in the block
+	Hardfork    int  // hardfork whose rules apply, 0 for the original ones
+
+	// DestinationAllowed reports whether the destination is in the allowlist of
+	// the hardfork, nil from hardfork 7 which relies on the AppRegistry only.
+	DestinationAllowed *bool
+	// FunctionSelector is the selector of the called function, empty if the
+	// calldata is shorter than a selector.
+	FunctionSelector string
+	// FunctionAllowed is false if the message is rejected by a rule on the called
+	// function. The function rules are checked after the destination ones.
+	FunctionAllowed bool
+
+	AppRegistry *KintoAppRegistryVerdict // nil before hardfork 6 and for gas estimations
 
-	if currentBlockNumber.Cmp(common.KintoRulesBlockStart) > 0 {
-		if currentBlockNumber.Cmp(common.KintoHardfork1) <= 0 {
-			return enforceOriginalKintoRules(msg) // Original Kinto rules
-		} else if currentBlockNumber.Cmp(common.KintoHardfork2) <= 0 {
-			return enforceHardForkOneRules(msg) // Rules for the first hard fork
-		} else if currentBlockNumber.Cmp(common.KintoHardfork3) <= 0 {
-			return enforceHardForkTwoRules(msg) // Rules for the second hard fork
-		} else if currentBlockNumber.Cmp(common.KintoHardfork4) <= 0 {
-			return enforceHardForkThreeRules(msg) // Rules for the third hard fork
-		} else if currentBlockNumber.Cmp(common.KintoHardfork5) <= 0 {
-			return enforceHardForkFourRules(msg) // Rules for the fourth hard fork
-		} else if currentBlockNumber.Cmp(common.KintoHardfork6) <= 0 {
-			return enforceHardForkFiveRules(msg) // Rules for the fifth hard fork
-		} else if currentBlockNumber.Cmp(common.KintoHardfork7) <= 0 {
-			return enforceHardForkSixRules(st) // Rules for the sixth hard fork
-		} else {
-			return enforceHardForkSevenRules(st) // Rules for the seventh hard fork
+	Err error // rejection of the message, ErrKintoNotAllowed wrapped in a *KintoRuleError
+}
+
+// KintoAppRegistryVerdict is the answer of the AppRegistry to the call of a
+// contract from an EOA.
+type KintoAppRegistryVerdict struct {
+	Allowed    bool
+	ReturnData []byte
+	Err        error // failure of the call, which doesn't reject the message
+}
+
+// ExplainKintoTransaction applies the Kinto rules of the block of the EVM to the
+// message like CheckKintoTransaction, and reports the checks made. The verdict
+// of the AppRegistry is the one the rules decided on.
+func ExplainKintoTransaction(evm *vm.EVM, msg *Message) *KintoExplanation {
+	var (
+		rules = getKintoRules(evm.ChainConfig())
+		num   = evm.Context.BlockNumber
+		exp   = &KintoExplanation{
+			RulesActive:      rules.IsKintoRules(num),
+			Hardfork:         rules.Hardfork(num),
+			FunctionSelector: extractFunctionSelector(msg.Data),
+			FunctionAllowed:  true,
+		}
+	)
+	if !exp.RulesActive {
+		return exp
+	}
+	destination := ZeroAddress
+	if msg.To != nil {
+		destination = *msg.To
+	}
+	if allowlist := rules.allowlist(exp.Hardfork); allowlist != nil {
+		allowed := allowlist[destination]
+		exp.DestinationAllowed = &allowed
+	}
+	st := &StateTransition{
+		gp:    new(GasPool).AddGas(math.MaxUint64),
+		evm:   evm,
+		msg:   msg,
+		state: evm.StateDB,
+	}
+	exp.Err = checkKintoRules(rules, st)
+	exp.AppRegistry = st.kintoAppRegistry
+	if rejection, ok := exp.Err.(*KintoRuleError); ok {
+		switch rejection.Rule {
+		case KintoRuleEntryPointWithdraw, KintoRuleHandleOpsBeneficiary, KintoRuleEntryPointFunction, KintoRulePaymasterFunction:
+			exp.FunctionAllowed = false
 		}
 	}
+	return exp
+}
 
-	return nil
+// allowlist returns the allowed destinations of the hardfork, nil if it has none.
+func (rules *kintoRules) allowlist(hardfork int) map[common.Address]bool {
+	switch hardfork {
+	case 0:
+		return rules.original
+	case 1:
+		return rules.hardfork1
+	case 2:
+		return rules.hardfork2
+	case 3:
+		return rules.hardfork3
+	case 4:
+		return rules.hardfork4
+	case 5:
+		return rules.hardfork5
+	case 6:
+		return rules.hardfork6
+	default:
+		return nil
+	}
+}
+
+// checkKintoRules applies the rules of the hardfork of the block to the message.
+func checkKintoRules(rules *kintoRules, st *StateTransition) error {
+	var (
+		msg                = st.msg
+		currentBlockNumber = st.evm.Context.BlockNumber
+	)
+	if !rules.IsKintoRules(currentBlockNumber) {
+		return nil
+	}
+	hardfork := rules.Hardfork(currentBlockNumber)
+	err := enforceHardforkRules(rules, hardfork, msg, st)
+	if rejection, ok := err.(*KintoRuleError); ok {
+		rejection.Hardfork = hardfork
+	}
+	return err
+}
+
+// enforceHardforkRules decides which set of Kinto rules to apply based on the hardfork
+func enforceHardforkRules(rules *kintoRules, hardfork int, msg *Message, st *StateTransition) error {
+	switch hardfork {
+	case 0:
+		return enforceOriginalKintoRules(rules, msg) // Original Kinto rules
+	case 1:
+		return enforceHardForkOneRules(rules, msg) // Rules for the first hard fork
+	case 2:
+		return enforceHardForkTwoRules(rules, msg) // Rules for the second hard fork
+	case 3:
+		return enforceHardForkThreeRules(rules, msg) // Rules for the third hard fork
+	case 4:
+		return enforceHardForkFourRules(rules, msg) // Rules for the fourth hard fork
+	case 5:
+		return enforceHardForkFiveRules(rules, msg) // Rules for the fifth hard fork
+	case 6:
+		return enforceHardForkSixRules(rules, st) // Rules for the sixth hard fork
+	default:
+		return enforceHardForkSevenRules(rules, st) // Rules for the seventh hard fork
+	}
 }
 
 func extractFunctionSelector(data []byte) string {
@@ -0,0 +1,283 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// kintoConformanceCase is a transaction replayed around every Kinto fork height,
+// with the rule expected to reject it under the rules of each hardfork.
+type kintoConformanceCase struct {
+	name string
+	to   *common.Address
+	data []byte
+	want [8]string // rejecting rule by hardfork, empty if the transaction is allowed
+}
+
+// newKintoConformanceChain returns an Arbitrum chain config with the Kinto rules
+// starting after block 2 and a hardfork every 2 blocks after, so that hardfork
+// N applies from block 2N+3 on.
+func newKintoConformanceChain() *params.ChainConfig {
+	config := params.ArbitrumDevTestChainConfig()
+	config.Clique = nil // the blocks are sealed by the ethash faker
+	config.KintoChainParams = params.KintoTestParams(2, 2)
+	return config
+}
+
+// addKintoTx adds the transaction to the generated block like AddTx, returning
+// the error of its application instead of panicking. The state and the gas pool
+// of the block are left untouched by rejected transactions.
+func addKintoTx(b *BlockGen, tx *types.Transaction) error {
+	if b.gasPool == nil {
+		b.SetCoinbase(common.Address{})
+	}
+	var (
+		snapshot = b.statedb.Snapshot()
+		gas      = *b.gasPool
+	)
+	b.statedb.SetTxContext(tx.Hash(), len(b.txs))
+	receipt, _, err := ApplyTransaction(b.cm.config, nil, &b.header.Coinbase, b.gasPool, b.statedb, b.header, tx, &b.header.GasUsed, vm.Config{})
+	if err != nil {
+		b.statedb.RevertToSnapshot(snapshot)
+		*b.gasPool = gas
+		return err
+	}
+	b.txs = append(b.txs, tx)
+	b.receipts = append(b.receipts, receipt)
+	return nil
+}
+
+// TestKintoConformance replays a matrix of transactions just before and after
+// every Kinto fork height on a generated chain, checking the rule rejecting them
+// under each hardfork, and that the chain including the allowed ones imports.
+func TestKintoConformance(t *testing.T) {
+	var (
+		config    = newKintoConformanceChain()
+		kinto     = config.Kinto()
+		contracts = kinto.Contracts
+
+		key, _ = crypto.GenerateKey()
+		from   = crypto.PubkeyToAddress(key.PublicKey)
+		other  = common.HexToAddress("0xbad0")
+		app    = common.HexToAddress("0xa990") // the only contract allowed by the AppRegistry
+
+		// The AppRegistry answers isContractCallAllowedFromEOA, of hardforks 6
+		// and 7 alike, with whether the `to` argument is the app:
+		// PUSH20 app, CALLDATALOAD(36), EQ, MSTORE(0), RETURN(0, 32)
+		appRegistry = append(append([]byte{byte(vm.PUSH20)}, app.Bytes()...), common.FromHex("0x6024351460005260206000f3")...)
+		stop        = []byte{byte(vm.STOP)}
+
+		gspec = &Genesis{
+			Config: config,
+			Alloc: types.GenesisAlloc{
+				from:                    {Balance: new(big.Int).Mul(big.NewInt(params.Ether), big.NewInt(100))},
+				contracts.AppRegistry:   {Code: appRegistry, Balance: common.Big0},
+				contracts.EntryPoint:    {Code: stop, Balance: common.Big0},
+				contracts.Paymaster:     {Code: stop, Balance: common.Big0},
+				contracts.WalletFactory: {Code: stop, Balance: common.Big0},
+				app:                     {Code: stop, Balance: common.Big0},
+			},
+			GasLimit: 30_000_000,
+			BaseFee:  big.NewInt(params.InitialBaseFee),
+		}
+		engine = ethash.NewFaker()
+	)
+	word := func(address common.Address) []byte { return common.LeftPadBytes(address.Bytes(), 32) }
+	call := func(selector string, args ...[]byte) []byte {
+		data := common.FromHex(selector)
+		for _, arg := range args {
+			data = append(data, arg...)
+		}
+		return data
+	}
+	// handleOps(ops, beneficiary) with no operations
+	handleOps := func(selector string, beneficiary common.Address) []byte {
+		return call(selector, common.LeftPadBytes([]byte{0x40}, 32), word(beneficiary), make([]byte, 32))
+	}
+	const (
+		creation    = KintoRuleContractCreation
+		destination = KintoRuleDestination
+		withdraw    = KintoRuleEntryPointWithdraw
+		beneficiary = KintoRuleHandleOpsBeneficiary
+		epFunction  = KintoRuleEntryPointFunction
+		pmFunction  = KintoRulePaymasterFunction
+		registry    = KintoRuleAppRegistry
+	)
+	cases := []kintoConformanceCase{
+		{
+			name: "create",
+			want: [8]string{creation, creation, creation, creation, creation, creation, creation, registry},
+		},
+		{
+			name: "allowlisted",
+			to:   &contracts.WalletFactory,
+			want: [8]string{"", "", "", "", "", "", "", registry},
+		},
+		{
+			name: "not-allowlisted",
+			to:   &other,
+			want: [8]string{destination, destination, destination, destination, destination, destination, destination, registry},
+		},
+		{
+			name: "app-registry-allowed",
+			to:   &app,
+			want: [8]string{destination, destination, destination, destination, destination, destination, "", ""},
+		},
+		{
+			name: "entrypoint-withdraw-sender",
+			to:   &contracts.EntryPoint,
+			data: call("205c2878", word(from), make([]byte, 32)),
+			want: [8]string{"", "", "", "", "", "", "", registry},
+		},
+		{
+			name: "entrypoint-withdraw-other",
+			to:   &contracts.EntryPoint,
+			data: call("205c2878", word(other), make([]byte, 32)),
+			want: [8]string{"", withdraw, withdraw, withdraw, withdraw, withdraw, withdraw, registry},
+		},
+		{
+			name: "entrypoint-withdraw-stake-other",
+			to:   &contracts.EntryPoint,
+			data: call("c23a5cea", word(other)),
+			want: [8]string{"", withdraw, withdraw, withdraw, withdraw, withdraw, withdraw, registry},
+		},
+		{
+			name: "handleops-sender",
+			to:   &contracts.EntryPoint,
+			data: handleOps("1fad948c", from),
+			want: [8]string{"", "", "", "", "", "", "", registry},
+		},
+		{
+			name: "handleops-other",
+			to:   &contracts.EntryPoint,
+			data: handleOps("1fad948c", other),
+			want: [8]string{"", beneficiary, beneficiary, beneficiary, beneficiary, beneficiary, beneficiary, registry},
+		},
+		{
+			name: "handle-aggregated-ops-other",
+			to:   &contracts.EntryPoint,
+			data: handleOps("4b1d7cf5", other),
+			want: [8]string{"", beneficiary, epFunction, epFunction, epFunction, epFunction, epFunction, registry},
+		},
+		{
+			name: "entrypoint-deposit",
+			to:   &contracts.EntryPoint,
+			data: call("b760faf9", word(from)),
+			want: [8]string{"", "", epFunction, epFunction, epFunction, epFunction, epFunction, registry},
+		},
+		{
+			name: "entrypoint-fallback",
+			to:   &contracts.EntryPoint,
+			want: [8]string{"", "", epFunction, epFunction, epFunction, epFunction, epFunction, registry},
+		},
+		{
+			name: "entrypoint-v7-handleops-sender",
+			to:   &contracts.EntryPointV7,
+			data: handleOps("765e827f", from),
+			want: [8]string{destination, destination, destination, destination, destination, destination, epFunction, registry},
+		},
+		{
+			name: "paymaster-deposit",
+			to:   &contracts.Paymaster,
+			data: call("d0e30db0"),
+			want: [8]string{"", pmFunction, pmFunction, pmFunction, pmFunction, pmFunction, pmFunction, registry},
+		},
+		{
+			name: "paymaster-withdraw",
+			to:   &contracts.Paymaster,
+			data: call("205c2878", word(from), make([]byte, 32)),
+			want: [8]string{"", pmFunction, pmFunction, pmFunction, pmFunction, pmFunction, pmFunction, registry},
+		},
+	}
+	// The blocks just before and after every fork height, with the hardfork
+	// whose rules apply to them, -1 before the Kinto rules
+	heights := []uint64{kinto.RulesBlockStart, kinto.Hardfork1Block, kinto.Hardfork2Block, kinto.Hardfork3Block,
+		kinto.Hardfork4Block, kinto.Hardfork5Block, kinto.Hardfork6Block, kinto.Hardfork7Block}
+	hardforks := make(map[uint64]int)
+	for i, height := range heights {
+		hardforks[height] = i - 1
+		hardforks[height+1] = i
+	}
+	signer := types.LatestSigner(config)
+
+	var included int
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, int(kinto.Hardfork7Block)+1, func(i int, gen *BlockGen) {
+		number := gen.Number().Uint64()
+		hardfork, ok := hardforks[number]
+		if !ok {
+			return
+		}
+		for _, tc := range cases {
+			tx, err := types.SignNewTx(key, signer, &types.LegacyTx{
+				Nonce:    gen.TxNonce(from),
+				To:       tc.to,
+				Gas:      500000,
+				GasPrice: big.NewInt(10 * params.InitialBaseFee),
+				Data:     tc.data,
+			})
+			if err != nil {
+				t.Fatalf("failed to sign transaction: %v", err)
+			}
+			err = addKintoTx(gen, tx)
+
+			want := ""
+			if hardfork >= 0 {
+				want = tc.want[hardfork]
+			}
+			if want == "" {
+				if err != nil {
+					t.Errorf("block %d (hardfork %d), %s: unexpected rejection: %v", number, hardfork, tc.name, err)
+				} else {
+					included++
+				}
+				continue
+			}
+			var rejection *KintoRuleError
+			if !errors.As(err, &rejection) {
+				t.Errorf("block %d (hardfork %d), %s: error %v, want rule %s", number, hardfork, tc.name, err, want)
+				continue
+			}
+			if !errors.Is(err, ErrKintoNotAllowed) {
+				t.Errorf("block %d, %s: rejection doesn't wrap ErrKintoNotAllowed", number, tc.name)
+			}
+			if rejection.Rule != want || rejection.Hardfork != hardfork {
+				t.Errorf("block %d, %s: rejection %s at hardfork %d, want %s at hardfork %d", number, tc.name, rejection.Rule, rejection.Hardfork, want, hardfork)
+			}
+		}
+	})
+	if included == 0 {
+		t.Fatal("no transaction included")
+	}
+
+	// The block processing applies the same rules and bytecode replacements.
+	// Arbitrum chains don't commit their genesis themselves.
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+	chain, err := NewBlockChain(db, nil, config, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	for _, change := range getKintoRules(config).stateChanges {
+		statedb, err := chain.StateAt(chain.GetHeaderByNumber(change.Block).Root)
+		if err != nil {
+			t.Fatalf("failed to open state of block %d: %v", change.Block, err)
+		}
+		if hash := statedb.GetCodeHash(change.Address); hash != change.CodeHash {
+			t.Errorf("%s: code hash %x at block %d, want %x", change.Name, hash, change.Block, change.CodeHash)
+		}
+	}
+}
@@ -1,46 +1,50 @@
 package core
 
 import (
-	"fmt"
-
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
 )
 
-var hardfork3KintoAddresses = map[common.Address]bool{
-	aaEntryPointEnvAddress: true, // aaEntryPointEnvAddress
-	kintoIdEnvAddress:      true, // kintoIdEnvAddress
-	walletFactoryAddress:   true, // walletFactoryAddress
-	paymasterAddress:       true, // paymasterAddress
-	appRegistryAddress:     true, // appRegistryAddress
-	upgradeExecutor:        true, // upgradeExecutor
-	customGatewayAddress:   true, // customGatewayAddress
-	gatewayRouterAddress:   true, // gatewayRouterAddress
-	standardGatewayAddress: true, // standardGatewayAddress
-	wethGateWayAddress:     true, // wethGateWayAddress
+func hardfork3KintoAddresses(c *params.KintoContracts) []common.Address {
+	return []common.Address{
+		c.EntryPoint,
+		c.KintoID,
+		c.WalletFactory,
+		c.Paymaster,
+		c.AppRegistry,
+		c.UpgradeExecutor,
+		c.CustomGateway,
+		c.GatewayRouter,
+		c.StandardGateway,
+		c.WethGateway,
+	}
 }
 
-func enforceHardForkThreeRules(msg *Message) error {
+func enforceHardForkThreeRules(rules *kintoRules, msg *Message) error {
 	destination := msg.To
 	functionSelector := extractFunctionSelector(msg.Data)
 
 	if destination == nil {
-		return fmt.Errorf("%w: %v EOAs can't create contracts directly, %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+		return kintoRejection(KintoRuleContractCreation, destination, "%v EOAs can't create contracts directly, %v", msg.From.Hex(), destination)
 	}
 
-	if _, ok := hardfork3KintoAddresses[*destination]; !ok {
-		return fmt.Errorf("%w: Transaction to address %v is not permitted", ErrKintoNotAllowed, destination.Hex())
+	if _, ok := rules.hardfork3[*destination]; !ok {
+		return kintoRejection(KintoRuleDestination, destination, "Transaction to address %v is not permitted", destination.Hex())
 	}
 
-	if *destination == aaEntryPointEnvAddress && isEntryPointWithdraw(functionSelector) {
+	if *destination == rules.Contracts.EntryPoint && isEntryPointWithdraw(functionSelector) {
+		if len(msg.Data) < functionSelectorSize+fullWordSize {
+			return kintoRejection(KintoRuleEntryPointWithdraw, destination, "%v is trying to withdrawTo/withdrawStake from EntryPoint without a withdraw address", msg.From.Hex())
+		}
 		addressBytes := msg.Data[functionSelectorSize+addressOffset : functionSelectorSize+fullWordSize]
 		paramAddress := common.BytesToAddress(addressBytes)
 
 		if msg.From != paramAddress {
-			return fmt.Errorf("%w: %v is trying to withdrawTo/withdrawStake from EntryPoint to a param different than the sender, %v", ErrKintoNotAllowed, msg.From.Hex(), paramAddress)
+			return kintoRejection(KintoRuleEntryPointWithdraw, destination, "%v is trying to withdrawTo/withdrawStake from EntryPoint to a param different than the sender, %v", msg.From.Hex(), paramAddress)
 		}
 	}
 
-	if *destination == aaEntryPointEnvAddress && functionSelector == functionSelectorEPHandleOps {
+	if *destination == rules.Contracts.EntryPoint && functionSelector == functionSelectorEPHandleOps {
 		data := msg.Data[functionSelectorSize:]
 		if len(data) >= beneficiaryOffset+fullWordSize {
 			beneficiaryEncoded := data[beneficiaryOffset : beneficiaryOffset+fullWordSize]
@@ -48,17 +52,17 @@ func enforceHardForkThreeRules(msg *Message) error {
 			beneficiaryAddress := common.BytesToAddress(beneficiaryBytes)
 
 			if msg.From != beneficiaryAddress {
-				return fmt.Errorf("%w: %v is trying to handleOps from EntryPoint to a beneficiary different than the sender, %v", ErrKintoNotAllowed, msg.From.Hex(), beneficiaryAddress)
+				return kintoRejection(KintoRuleHandleOpsBeneficiary, destination, "%v is trying to handleOps from EntryPoint to a beneficiary different than the sender, %v", msg.From.Hex(), beneficiaryAddress)
 			}
 		}
 	}
 
-	if *destination == aaEntryPointEnvAddress && hardForkTwoForbiddenEPFunctions(functionSelector) {
-		return fmt.Errorf("%w: %v EntryPoint depositTo, HandleAggregatedOps and fallback functions are not allowed , %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+	if *destination == rules.Contracts.EntryPoint && hardForkTwoForbiddenEPFunctions(functionSelector) {
+		return kintoRejection(KintoRuleEntryPointFunction, destination, "%v EntryPoint depositTo, HandleAggregatedOps and fallback functions are not allowed , %v", msg.From.Hex(), destination)
 	}
 
-	if *destination == paymasterAddress && paymasterFunctionNotAllowed(functionSelector) { //ENTRYPOINT PAYMASTER RULES
-		return fmt.Errorf("%w: %v SponsorPaymaster withDrawTo() and deposit() are not allowed , %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+	if *destination == rules.Contracts.Paymaster && paymasterFunctionNotAllowed(functionSelector) { //ENTRYPOINT PAYMASTER RULES
+		return kintoRejection(KintoRulePaymasterFunction, destination, "%v SponsorPaymaster withDrawTo() and deposit() are not allowed , %v", msg.From.Hex(), destination)
 	}
 
 	return nil
@@ -1,38 +1,39 @@
 package core
 
 import (
-	"fmt"
-
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
 )
 
-var hardfork4KintoAddresses = map[common.Address]bool{
-	aaEntryPointEnvAddress:      true, // aaEntryPointEnvAddress
-	kintoIdEnvAddress:           true, // kintoIdEnvAddress
-	walletFactoryAddress:        true, // walletFactoryAddress
-	paymasterAddress:            true, // paymasterAddress
-	appRegistryAddress:          true, // appRegistryAddress
-	upgradeExecutor:             true, // upgradeExecutor
-	customGatewayAddress:        true, // customGatewayAddress
-	gatewayRouterAddress:        true, // gatewayRouterAddress
-	standardGatewayAddress:      true, // standardGatewayAddress
-	wethGateWayAddress:          true, // wethGateWayAddress
-	bundleBulker:                true, // bundleBulker
-	arbRetrayableTx:             true, // arbRetrayableTx
-	socket:                      true, // socket
-	socketExecutionManager:      true, // socketExecutionManager
-	socketTransmitManager:       true, // socketTransmitManager
-	socketFastSwitchboard:       true, // socketFastSwitchboard
-	socketOptimisticSwitchboard: true, // socketOptimisticSwitchboard
-	socketBatcher:               true, // socketBatcher
-	socketSimulator:             true, // socketSimulator
-	socketSimulatorUtils:        true, // socketSimulatorUtils
-	socketSwitchboardSimulator:  true, // socketSwitchboardSimulator
-	socketCapacitorSimulator:    true, // socketCapacitorSimulator
-	create2Factory:              true, // create2Factory
+func hardfork4KintoAddresses(c *params.KintoContracts) []common.Address {
+	return []common.Address{
+		c.EntryPoint,
+		c.KintoID,
+		c.WalletFactory,
+		c.Paymaster,
+		c.AppRegistry,
+		c.UpgradeExecutor,
+		c.CustomGateway,
+		c.GatewayRouter,
+		c.StandardGateway,
+		c.WethGateway,
+		c.BundleBulker,
+		c.ArbRetryableTx,
+		c.Socket,
+		c.SocketExecutionManager,
+		c.SocketTransmitManager,
+		c.SocketFastSwitchboard,
+		c.SocketOptimisticSwitchboard,
+		c.SocketBatcher,
+		c.SocketSimulator,
+		c.SocketSimulatorUtils,
+		c.SocketSwitchboardSimulator,
+		c.SocketCapacitorSimulator,
+		c.Create2Factory,
+	}
 }
 
-func enforceHardForkFourRules(msg *Message) error {
+func enforceHardForkFourRules(rules *kintoRules, msg *Message) error {
 	if msg.TxRunMode == MessageGasEstimationMode {
 		return nil // allow gas estimation
 	}
@@ -41,23 +42,26 @@ func enforceHardForkFourRules(msg *Message) error {
 	functionSelector := extractFunctionSelector(msg.Data)
 
 	if destination == nil {
-		return fmt.Errorf("%w: %v EOAs can't create contracts directly, %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+		return kintoRejection(KintoRuleContractCreation, destination, "%v EOAs can't create contracts directly, %v", msg.From.Hex(), destination)
 	}
 
-	if _, ok := hardfork4KintoAddresses[*destination]; !ok {
-		return fmt.Errorf("%w: Transaction to address %v is not permitted", ErrKintoNotAllowed, destination.Hex())
+	if _, ok := rules.hardfork4[*destination]; !ok {
+		return kintoRejection(KintoRuleDestination, destination, "Transaction to address %v is not permitted", destination.Hex())
 	}
 
-	if *destination == aaEntryPointEnvAddress && isEntryPointWithdraw(functionSelector) {
+	if *destination == rules.Contracts.EntryPoint && isEntryPointWithdraw(functionSelector) {
+		if len(msg.Data) < functionSelectorSize+fullWordSize {
+			return kintoRejection(KintoRuleEntryPointWithdraw, destination, "%v is trying to withdrawTo/withdrawStake from EntryPoint without a withdraw address", msg.From.Hex())
+		}
 		addressBytes := msg.Data[functionSelectorSize+addressOffset : functionSelectorSize+fullWordSize]
 		paramAddress := common.BytesToAddress(addressBytes)
 
 		if msg.From != paramAddress {
-			return fmt.Errorf("%w: %v is trying to withdrawTo/withdrawStake from EntryPoint to a param different than the sender, %v", ErrKintoNotAllowed, msg.From.Hex(), paramAddress)
+			return kintoRejection(KintoRuleEntryPointWithdraw, destination, "%v is trying to withdrawTo/withdrawStake from EntryPoint to a param different than the sender, %v", msg.From.Hex(), paramAddress)
 		}
 	}
 
-	if *destination == aaEntryPointEnvAddress && functionSelector == functionSelectorEPHandleOps {
+	if *destination == rules.Contracts.EntryPoint && functionSelector == functionSelectorEPHandleOps {
 		data := msg.Data[functionSelectorSize:]
 		if len(data) >= beneficiaryOffset+fullWordSize {
 			beneficiaryEncoded := data[beneficiaryOffset : beneficiaryOffset+fullWordSize]
@@ -65,17 +69,17 @@ func enforceHardForkFourRules(msg *Message) error {
 			beneficiaryAddress := common.BytesToAddress(beneficiaryBytes)
 
 			if msg.From != beneficiaryAddress {
-				return fmt.Errorf("%w: %v is trying to handleOps from EntryPoint to a beneficiary different than the sender, %v", ErrKintoNotAllowed, msg.From.Hex(), beneficiaryAddress)
+				return kintoRejection(KintoRuleHandleOpsBeneficiary, destination, "%v is trying to handleOps from EntryPoint to a beneficiary different than the sender, %v", msg.From.Hex(), beneficiaryAddress)
 			}
 		}
 	}
 
-	if *destination == aaEntryPointEnvAddress && hardForkTwoForbiddenEPFunctions(functionSelector) {
-		return fmt.Errorf("%w: %v EntryPoint depositTo, HandleAggregatedOps and fallback functions are not allowed , %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+	if *destination == rules.Contracts.EntryPoint && hardForkTwoForbiddenEPFunctions(functionSelector) {
+		return kintoRejection(KintoRuleEntryPointFunction, destination, "%v EntryPoint depositTo, HandleAggregatedOps and fallback functions are not allowed , %v", msg.From.Hex(), destination)
 	}
 
-	if *destination == paymasterAddress && paymasterFunctionNotAllowed(functionSelector) { //ENTRYPOINT PAYMASTER RULES
-		return fmt.Errorf("%w: %v SponsorPaymaster withDrawTo() and deposit() are not allowed , %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+	if *destination == rules.Contracts.Paymaster && paymasterFunctionNotAllowed(functionSelector) { //ENTRYPOINT PAYMASTER RULES
+		return kintoRejection(KintoRulePaymasterFunction, destination, "%v SponsorPaymaster withDrawTo() and deposit() are not allowed , %v", msg.From.Hex(), destination)
 	}
 
 	return nil
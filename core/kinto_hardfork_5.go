@@ -1,38 +1,40 @@
 package core
 
 import (
-	"fmt"
-
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
 )
 
-var replaceHF5Address = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+// replacedHF5Bytecode replaces the code of the create2 factory at hardfork 5, it
+// embeds the address of the mainnet KintoID contract
 var replacedHF5Bytecode = common.FromHex("0x6080604052600436106100225760003560e01c806399a6cddd146101225761004d565b3661004d57604051638dc2b20960e01b81523360048201523460248201526044015b60405180910390fd5b6040516313289ea360e31b815233600482015260009036906060906001600160a01b037f000000000000000000000000f369f78e3a0492cc4e96a90dae0728a38498e9c71690639944f51890602401602060405180830381865afa1580156100b9573d6000803e3d6000fd5b505050506040513d601f19601f820116820180604052508101906100dd9190610172565b6100fc57604051630ab529c360e21b8152336004820152602401610044565b36601f1901600081602082378035828234f5915081610119578081fd5b8181526014600cf35b34801561012e57600080fd5b506101567f000000000000000000000000f369f78e3a0492cc4e96a90dae0728a38498e9c781565b6040516001600160a01b03909116815260200160405180910390f35b60006020828403121561018457600080fd5b8151801515811461019457600080fd5b939250505056fea2646970667358221220a35916f465fc2e7b19efa5b0d984bc014269d1d8cbad39f684ed4ecc1dd45e5f64736f6c63430008180033")
 
-var hardfork5KintoAddresses = map[common.Address]bool{
-	aaEntryPointEnvAddress:      true, // aaEntryPointEnvAddress
-	kintoIdEnvAddress:           true, // kintoIdEnvAddress
-	walletFactoryAddress:        true, // walletFactoryAddress
-	paymasterAddress:            true, // paymasterAddress
-	appRegistryAddress:          true, // appRegistryAddress
-	upgradeExecutor:             true, // upgradeExecutor
-	customGatewayAddress:        true, // customGatewayAddress
-	gatewayRouterAddress:        true, // gatewayRouterAddress
-	standardGatewayAddress:      true, // standardGatewayAddress
-	wethGateWayAddress:          true, // wethGateWayAddress
-	bundleBulker:                true, // bundleBulker
-	arbRetrayableTx:             true, // arbRetrayableTx
-	socket:                      true, // socket
-	socketExecutionManager:      true, // socketExecutionManager
-	socketTransmitManager:       true, // socketTransmitManager
-	socketFastSwitchboard:       true, // socketFastSwitchboard
-	socketOptimisticSwitchboard: true, // socketOptimisticSwitchboard
-	socketBatcher:               true, // socketBatcher
-	socketSimulator:             true, // socketSimulator
-	socketSimulatorUtils:        true, // socketSimulatorUtils
-	socketSwitchboardSimulator:  true, // socketSwitchboardSimulator
-	socketCapacitorSimulator:    true, // socketCapacitorSimulator
-	create2Factory:              true, // create2Factory
+func hardfork5KintoAddresses(c *params.KintoContracts) []common.Address {
+	return []common.Address{
+		c.EntryPoint,
+		c.KintoID,
+		c.WalletFactory,
+		c.Paymaster,
+		c.AppRegistry,
+		c.UpgradeExecutor,
+		c.CustomGateway,
+		c.GatewayRouter,
+		c.StandardGateway,
+		c.WethGateway,
+		c.BundleBulker,
+		c.ArbRetryableTx,
+		c.Socket,
+		c.SocketExecutionManager,
+		c.SocketTransmitManager,
+		c.SocketFastSwitchboard,
+		c.SocketOptimisticSwitchboard,
+		c.SocketBatcher,
+		c.SocketSimulator,
+		c.SocketSimulatorUtils,
+		c.SocketSwitchboardSimulator,
+		c.SocketCapacitorSimulator,
+		c.Create2Factory,
+	}
 }
 
 var (
@@ -111,7 +113,7 @@ var (
 	}
 )
 
-func enforceHardForkFiveRules(msg *Message) error {
+func enforceHardForkFiveRules(rules *kintoRules, msg *Message) error {
 	if msg.TxRunMode == MessageGasEstimationMode {
 		return nil // allow gas estimation
 	}
@@ -119,31 +121,36 @@ func enforceHardForkFiveRules(msg *Message) error {
 	destination := msg.To
 	origin := msg.From
 
-	if (containsAddress(dinaryStagingEOAs, origin) && containsAddress(dinaryStagingContracts, *destination)) ||
-		(containsAddress(dinaryProductionEOAs, origin) && containsAddress(dinaryProductionContracts, *destination)) {
+	// The contract creations of the dinary EOAs are rejected below, they used to
+	// dereference the nil destination
+	if destination != nil && ((containsAddress(dinaryStagingEOAs, origin) && containsAddress(dinaryStagingContracts, *destination)) ||
+		(containsAddress(dinaryProductionEOAs, origin) && containsAddress(dinaryProductionContracts, *destination))) {
 		return nil // allow dinary contracts to interact with dinary EOAs
 	}
 
 	functionSelector := extractFunctionSelector(msg.Data)
 
 	if destination == nil {
-		return fmt.Errorf("%w: %v EOAs can't create contracts directly, %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+		return kintoRejection(KintoRuleContractCreation, destination, "%v EOAs can't create contracts directly, %v", msg.From.Hex(), destination)
 	}
 
-	if _, ok := hardfork5KintoAddresses[*destination]; !ok {
-		return fmt.Errorf("%w: Transaction to address %v is not permitted", ErrKintoNotAllowed, destination.Hex())
+	if _, ok := rules.hardfork5[*destination]; !ok {
+		return kintoRejection(KintoRuleDestination, destination, "Transaction to address %v is not permitted", destination.Hex())
 	}
 
-	if *destination == aaEntryPointEnvAddress && isEntryPointWithdraw(functionSelector) {
+	if *destination == rules.Contracts.EntryPoint && isEntryPointWithdraw(functionSelector) {
+		if len(msg.Data) < functionSelectorSize+fullWordSize {
+			return kintoRejection(KintoRuleEntryPointWithdraw, destination, "%v is trying to withdrawTo/withdrawStake from EntryPoint without a withdraw address", msg.From.Hex())
+		}
 		addressBytes := msg.Data[functionSelectorSize+addressOffset : functionSelectorSize+fullWordSize]
 		paramAddress := common.BytesToAddress(addressBytes)
 
 		if msg.From != paramAddress {
-			return fmt.Errorf("%w: %v is trying to withdrawTo/withdrawStake from EntryPoint to a param different than the sender, %v", ErrKintoNotAllowed, msg.From.Hex(), paramAddress)
+			return kintoRejection(KintoRuleEntryPointWithdraw, destination, "%v is trying to withdrawTo/withdrawStake from EntryPoint to a param different than the sender, %v", msg.From.Hex(), paramAddress)
 		}
 	}
 
-	if *destination == aaEntryPointEnvAddress && functionSelector == functionSelectorEPHandleOps {
+	if *destination == rules.Contracts.EntryPoint && functionSelector == functionSelectorEPHandleOps {
 		data := msg.Data[functionSelectorSize:]
 		if len(data) >= beneficiaryOffset+fullWordSize {
 			beneficiaryEncoded := data[beneficiaryOffset : beneficiaryOffset+fullWordSize]
@@ -151,17 +158,17 @@ func enforceHardForkFiveRules(msg *Message) error {
 			beneficiaryAddress := common.BytesToAddress(beneficiaryBytes)
 
 			if msg.From != beneficiaryAddress {
-				return fmt.Errorf("%w: %v is trying to handleOps from EntryPoint to a beneficiary different than the sender, %v", ErrKintoNotAllowed, msg.From.Hex(), beneficiaryAddress)
+				return kintoRejection(KintoRuleHandleOpsBeneficiary, destination, "%v is trying to handleOps from EntryPoint to a beneficiary different than the sender, %v", msg.From.Hex(), beneficiaryAddress)
 			}
 		}
 	}
 
-	if *destination == aaEntryPointEnvAddress && hardForkTwoForbiddenEPFunctions(functionSelector) {
-		return fmt.Errorf("%w: %v EntryPoint depositTo, HandleAggregatedOps and fallback functions are not allowed , %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+	if *destination == rules.Contracts.EntryPoint && hardForkTwoForbiddenEPFunctions(functionSelector) {
+		return kintoRejection(KintoRuleEntryPointFunction, destination, "%v EntryPoint depositTo, HandleAggregatedOps and fallback functions are not allowed , %v", msg.From.Hex(), destination)
 	}
 
-	if *destination == paymasterAddress && paymasterFunctionNotAllowed(functionSelector) { //ENTRYPOINT PAYMASTER RULES
-		return fmt.Errorf("%w: %v SponsorPaymaster withDrawTo() and deposit() are not allowed , %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+	if *destination == rules.Contracts.Paymaster && paymasterFunctionNotAllowed(functionSelector) { //ENTRYPOINT PAYMASTER RULES
+		return kintoRejection(KintoRulePaymasterFunction, destination, "%v SponsorPaymaster withDrawTo() and deposit() are not allowed , %v", msg.From.Hex(), destination)
 	}
 
 	return nil
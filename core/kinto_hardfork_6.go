@@ -6,40 +6,43 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/vm"
-	"github.com/holiman/uint256"
+	"github.com/ethereum/go-ethereum/params"
 )
 
-var hardfork6KintoAddresses = map[common.Address]bool{
-	aaEntryPointEnvAddress:      true,  // aaEntryPointEnvAddress
-	kintoIdEnvAddress:           true,  // kintoIdEnvAddress
-	walletFactoryAddress:        true,  // walletFactoryAddress
-	paymasterAddress:            true,  // paymasterAddress
-	appRegistryAddress:          true,  // appRegistryAddress
-	upgradeExecutor:             true,  // upgradeExecutor
-	customGatewayAddress:        true,  // customGatewayAddress
-	gatewayRouterAddress:        true,  // gatewayRouterAddress
-	standardGatewayAddress:      true,  // standardGatewayAddress
-	wethGateWayAddress:          true,  // wethGateWayAddress
-	bundleBulker:                true,  // bundleBulker
-	arbRetrayableTx:             true,  // arbRetrayableTx
-	socket:                      false, // socket
-	socketExecutionManager:      false, // socketExecutionManager
-	socketTransmitManager:       false, // socketTransmitManager
-	socketFastSwitchboard:       false, // socketFastSwitchboard
-	socketOptimisticSwitchboard: false, // socketOptimisticSwitchboard
-	socketBatcher:               false, // socketBatcher
-	socketSimulator:             false, // socketSimulator
-	socketSimulatorUtils:        false, // socketSimulatorUtils
-	socketSwitchboardSimulator:  false, // socketSwitchboardSimulator
-	socketCapacitorSimulator:    false, // socketCapacitorSimulator
-	create2Factory:              false, // create2Factory
-	aaEntryPointEnvAddressV7:    true,  // aaEntryPointEnvAddressv7
+func hardfork6KintoAddresses(c *params.KintoContracts) []common.Address {
+	return []common.Address{
+		c.EntryPoint,
+		c.KintoID,
+		c.WalletFactory,
+		c.Paymaster,
+		c.AppRegistry,
+		c.UpgradeExecutor,
+		c.CustomGateway,
+		c.GatewayRouter,
+		c.StandardGateway,
+		c.WethGateway,
+		c.BundleBulker,
+		c.ArbRetryableTx,
+		// The socket contracts and the create2 factory were flagged as disabled
+		// at this hardfork, but remained valid destinations
+		c.Socket,
+		c.SocketExecutionManager,
+		c.SocketTransmitManager,
+		c.SocketFastSwitchboard,
+		c.SocketOptimisticSwitchboard,
+		c.SocketBatcher,
+		c.SocketSimulator,
+		c.SocketSimulatorUtils,
+		c.SocketSwitchboardSimulator,
+		c.SocketCapacitorSimulator,
+		c.Create2Factory,
+		c.EntryPointV7,
+	}
 }
 
 var ZeroAddress = common.HexToAddress("0x0000000000000000000000000000000000000000")
 
-func enforceHardForkSixRules(st *StateTransition) error {
+func enforceHardForkSixRules(rules *kintoRules, st *StateTransition) error {
 	msg := st.msg
 
 	if msg.TxRunMode == MessageGasEstimationMode {
@@ -53,32 +56,40 @@ func enforceHardForkSixRules(st *StateTransition) error {
 		destination = &ZeroAddress
 	}
 
-	allowed, err := isContractCallAllowedFromEOA(st, origin, *destination)
+	allowed, ret, err := isContractCallAllowedFromEOA(st, rules.Contracts.AppRegistry, origin, *destination)
+	st.kintoAppRegistry = &KintoAppRegistryVerdict{Allowed: allowed, ReturnData: ret, Err: err}
 
 	if allowed && err == nil {
 		return nil
 	}
 
+	if err != nil {
+		st.kintoFailOpen = err // the allowlist decides when the AppRegistry can't
+	}
+
 	functionSelector := extractFunctionSelector(msg.Data)
 
 	if *destination == ZeroAddress {
-		return fmt.Errorf("%w: %v EOAs can't create contracts directly, %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+		return kintoRejection(KintoRuleContractCreation, destination, "%v EOAs can't create contracts directly, %v", msg.From.Hex(), destination)
 	}
 
-	if _, ok := hardfork6KintoAddresses[*destination]; !ok {
-		return fmt.Errorf("%w: Transaction to address %v is not permitted", ErrKintoNotAllowed, destination.Hex())
+	if _, ok := rules.hardfork6[*destination]; !ok {
+		return kintoRejection(KintoRuleDestination, destination, "Transaction to address %v is not permitted", destination.Hex())
 	}
 
-	if isEntryPointAddress(*destination) && isEntryPointWithdraw(functionSelector) {
+	if rules.isEntryPointAddress(*destination) && isEntryPointWithdraw(functionSelector) {
+		if len(msg.Data) < functionSelectorSize+fullWordSize {
+			return kintoRejection(KintoRuleEntryPointWithdraw, destination, "%v is trying to withdrawTo/withdrawStake from EntryPoint without a withdraw address", msg.From.Hex())
+		}
 		addressBytes := msg.Data[functionSelectorSize+addressOffset : functionSelectorSize+fullWordSize]
 		paramAddress := common.BytesToAddress(addressBytes)
 
 		if msg.From != paramAddress {
-			return fmt.Errorf("%w: %v is trying to withdrawTo/withdrawStake from EntryPoint to a param different than the sender, %v", ErrKintoNotAllowed, msg.From.Hex(), paramAddress)
+			return kintoRejection(KintoRuleEntryPointWithdraw, destination, "%v is trying to withdrawTo/withdrawStake from EntryPoint to a param different than the sender, %v", msg.From.Hex(), paramAddress)
 		}
 	}
 
-	if isHandleOps(*destination, functionSelector) {
+	if rules.isHandleOps(*destination, functionSelector) {
 		data := msg.Data[functionSelectorSize:]
 		if len(data) >= beneficiaryOffset+fullWordSize {
 			beneficiaryEncoded := data[beneficiaryOffset : beneficiaryOffset+fullWordSize]
@@ -86,60 +97,62 @@ func enforceHardForkSixRules(st *StateTransition) error {
 			beneficiaryAddress := common.BytesToAddress(beneficiaryBytes)
 
 			if msg.From != beneficiaryAddress {
-				return fmt.Errorf("%w: %v is trying to handleOps from EntryPoint to a beneficiary different than the sender, %v", ErrKintoNotAllowed, msg.From.Hex(), beneficiaryAddress)
+				return kintoRejection(KintoRuleHandleOpsBeneficiary, destination, "%v is trying to handleOps from EntryPoint to a beneficiary different than the sender, %v", msg.From.Hex(), beneficiaryAddress)
 			}
 		}
 	}
 
-	if isEntryPointAddress(*destination) && hardForkSixForbiddenEPFunctions(functionSelector) {
-		return fmt.Errorf("%w: %v EntryPoint depositTo, HandleAggregatedOps and fallback functions are not allowed , %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+	if rules.isEntryPointAddress(*destination) && hardForkSixForbiddenEPFunctions(functionSelector) {
+		return kintoRejection(KintoRuleEntryPointFunction, destination, "%v EntryPoint depositTo, HandleAggregatedOps and fallback functions are not allowed , %v", msg.From.Hex(), destination)
 	}
 
-	if *destination == paymasterAddress && paymasterFunctionNotAllowed(functionSelector) { //ENTRYPOINT PAYMASTER RULES
-		return fmt.Errorf("%w: %v SponsorPaymaster withDrawTo() and deposit() are not allowed , %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+	if *destination == rules.Contracts.Paymaster && paymasterFunctionNotAllowed(functionSelector) { //ENTRYPOINT PAYMASTER RULES
+		return kintoRejection(KintoRulePaymasterFunction, destination, "%v SponsorPaymaster withDrawTo() and deposit() are not allowed , %v", msg.From.Hex(), destination)
 	}
 
 	return nil
 }
 
-func isContractCallAllowedFromEOA(st *StateTransition, from, to common.Address) (bool, error) {
+// isContractCallAllowedFromEOA asks the AppRegistry whether the EOA may call the contract, returning
+// its verdict and the raw return data of the call.
+func isContractCallAllowedFromEOA(st *StateTransition, appRegistry, from, to common.Address) (bool, []byte, error) {
 	// Define the ABI
 	const abiJSON = `[{"inputs":[{"internalType":"address","name":"from","type":"address"},{"internalType":"address","name":"to","type":"address"}],"name":"isContractCallAllowedFromEOA","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
 
 	parsedABI, err := abi.JSON(strings.NewReader(abiJSON))
 	if err != nil {
-		return false, fmt.Errorf("error parsing ABI: %v", err)
+		return false, nil, fmt.Errorf("error parsing ABI: %v", err)
 	}
 
 	input, err := parsedABI.Pack("isContractCallAllowedFromEOA", from, to)
 	if err != nil {
-		return false, fmt.Errorf("error packing function call: %v", err)
+		return false, nil, fmt.Errorf("error packing function call: %v", err)
 	}
 
-	ret, _, err := st.evm.Call(vm.AccountRef(from), appRegistryAddress, input, uint64(100000), uint256.NewInt(0))
+	ret, err := callAppRegistry(st, from, appRegistry, input)
 	if err != nil {
-		return false, fmt.Errorf("error executing contract call: %v", err)
+		return false, ret, fmt.Errorf("error executing contract call: %v", err)
 	}
 
 	if len(ret) == 0 {
-		return false, fmt.Errorf("empty result from contract call")
+		return false, ret, fmt.Errorf("empty result from contract call")
 	}
 
 	var result bool
 	err = parsedABI.UnpackIntoInterface(&result, "isContractCallAllowedFromEOA", ret)
 	if err != nil {
-		return false, fmt.Errorf("error unpacking result: %v", err)
+		return false, ret, fmt.Errorf("error unpacking result: %v", err)
 	}
 
-	return result, nil
+	return result, ret, nil
 }
 
-func isEntryPointAddress(address common.Address) bool {
-	return address == aaEntryPointEnvAddress || address == aaEntryPointEnvAddressV7
+func (rules *kintoRules) isEntryPointAddress(address common.Address) bool {
+	return address == rules.Contracts.EntryPoint || address == rules.Contracts.EntryPointV7
 }
 
-func isHandleOps(address common.Address, functionSelector string) bool {
-	return isEntryPointAddress(address) && (functionSelector == functionSelectorEPHandleOps || functionSelector == functionSelectorEPHandleOpsV7)
+func (rules *kintoRules) isHandleOps(address common.Address, functionSelector string) bool {
+	return rules.isEntryPointAddress(address) && (functionSelector == functionSelectorEPHandleOps || functionSelector == functionSelectorEPHandleOpsV7)
 }
 
 func hardForkSixForbiddenEPFunctions(functionSelector string) bool {
@@ -7,11 +7,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/vm"
-	"github.com/holiman/uint256"
 )
 
-func enforceHardForkSevenRules(st *StateTransition) error {
+func enforceHardForkSevenRules(rules *kintoRules, st *StateTransition) error {
 	msg := st.msg
 
 	if msg.TxRunMode == MessageGasEstimationMode {
@@ -25,50 +23,54 @@ func enforceHardForkSevenRules(st *StateTransition) error {
 		destination = &ZeroAddress
 	}
 
-	allowed, err := isContractCallAllowedFromEOAHF7(st, origin, *destination, st.msg.Data, st.msg.Value)
+	allowed, ret, err := isContractCallAllowedFromEOAHF7(st, rules.Contracts.AppRegistry, origin, *destination, st.msg.Data, st.msg.Value)
+	st.kintoAppRegistry = &KintoAppRegistryVerdict{Allowed: allowed, ReturnData: ret, Err: err}
 
 	if allowed && err == nil {
 		return nil
 	}
 
 	if !allowed && err == nil {
-		return fmt.Errorf("%w: %v is not allowed to call %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+		return kintoRejection(KintoRuleAppRegistry, destination, "%v is not allowed to call %v", msg.From.Hex(), destination)
 	}
 
 	//if it is !allowed and err !=nil something went wrong with the contract call
 	//however we still allow the transaction to proceed or it will brick the chain
+	st.kintoFailOpen = err
 	return nil
 }
 
-func isContractCallAllowedFromEOAHF7(st *StateTransition, from, to common.Address, data []byte, value *big.Int) (bool, error) {
+// isContractCallAllowedFromEOAHF7 asks the AppRegistry whether the EOA may call the contract, returning
+// its verdict and the raw return data of the call.
+func isContractCallAllowedFromEOAHF7(st *StateTransition, appRegistry, from, to common.Address, data []byte, value *big.Int) (bool, []byte, error) {
 	// Define the updated ABI
 	const abiJSON = `[{"inputs":[{"internalType":"address","name":"sender","type":"address"},{"internalType":"address","name":"to","type":"address"},{"internalType":"bytes","name":"callData","type":"bytes"},{"internalType":"uint256","name":"value","type":"uint256"}],"name":"isContractCallAllowedFromEOA","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
 
 	parsedABI, err := abi.JSON(strings.NewReader(abiJSON))
 	if err != nil {
-		return false, fmt.Errorf("error parsing ABI: %v", err)
+		return false, nil, fmt.Errorf("error parsing ABI: %v", err)
 	}
 
 	// Pack the function call with the new parameters
 	input, err := parsedABI.Pack("isContractCallAllowedFromEOA", from, to, data, value)
 	if err != nil {
-		return false, fmt.Errorf("error packing function call: %v", err)
+		return false, nil, fmt.Errorf("error packing function call: %v", err)
 	}
 
-	ret, _, err := st.evm.Call(vm.AccountRef(from), appRegistryAddress, input, uint64(100000), uint256.NewInt(0))
+	ret, err := callAppRegistry(st, from, appRegistry, input)
 	if err != nil {
-		return false, fmt.Errorf("error executing contract call: %v", err)
+		return false, ret, fmt.Errorf("error executing contract call: %v", err)
 	}
 
 	if len(ret) == 0 {
-		return false, fmt.Errorf("empty result from contract call")
+		return false, ret, fmt.Errorf("empty result from contract call")
 	}
 
 	var result bool
 	err = parsedABI.UnpackIntoInterface(&result, "isContractCallAllowedFromEOA", ret)
 	if err != nil {
-		return false, fmt.Errorf("error unpacking result: %v", err)
+		return false, ret, fmt.Errorf("error unpacking result: %v", err)
 	}
 
-	return result, nil
+	return result, ret, nil
 }
@@ -1,29 +1,30 @@
 package core
 
 import (
-	"fmt"
-
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // Valid Kinto addresses before the hardfork
-var originalKintoAddresses = map[common.Address]bool{
-	aaEntryPointEnvAddress: true, // aaEntryPointEnvAddress
-	kintoIdEnvAddress:      true, // kintoIdEnvAddress
-	walletFactoryAddress:   true, // walletFactoryAddress
-	paymasterAddress:       true, // paymasterAddress
+func originalKintoAddresses(c *params.KintoContracts) []common.Address {
+	return []common.Address{
+		c.EntryPoint,
+		c.KintoID,
+		c.WalletFactory,
+		c.Paymaster,
+	}
 }
 
 // enforceOriginalKintoRules applies the original Kinto rules
-func enforceOriginalKintoRules(msg *Message) error {
+func enforceOriginalKintoRules(rules *kintoRules, msg *Message) error {
 	destination := msg.To
 
 	if destination == nil {
-		return fmt.Errorf("%w: %v is trying to create a contract directly, %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+		return kintoRejection(KintoRuleContractCreation, destination, "%v is trying to create a contract directly, %v", msg.From.Hex(), destination)
 	}
 
-	if _, ok := originalKintoAddresses[*destination]; !ok {
-		return fmt.Errorf("%w: %v is trying to tx against an invalid address, %v", ErrKintoNotAllowed, msg.From.Hex(), destination)
+	if _, ok := rules.original[*destination]; !ok {
+		return kintoRejection(KintoRuleDestination, destination, "%v is trying to tx against an invalid address, %v", msg.From.Hex(), destination)
 	}
 
 	return nil
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newKintoTestChain returns a chain config with the Kinto rules starting at
+// block 10, and a hardfork every 10 blocks after, and an empty state.
+func newKintoTestChain(t *testing.T) (*params.ChainConfig, *state.StateDB) {
+	config := *params.TestChainConfig
+	config.KintoChainParams = params.KintoTestParams(10, 10)
+
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &config, statedb
+}
+
+func TestCheckKintoTransaction(t *testing.T) {
+	config, statedb := newKintoTestChain(t)
+	kinto := config.Kinto()
+	var (
+		from    = common.HexToAddress("0x1000")
+		other   = common.HexToAddress("0x2000")
+		factory = kinto.Contracts.WalletFactory
+	)
+	for _, tt := range []struct {
+		block    int64
+		to       *common.Address
+		rule     string
+		hardfork int
+	}{
+		{block: 5, to: &other},                              // before the Kinto rules
+		{block: 15, to: &factory},                           // allowlisted destination
+		{block: 15, to: &other, rule: KintoRuleDestination}, // original rules
+		{block: 25, to: nil, rule: KintoRuleContractCreation, hardfork: 1},
+		{block: 45, to: &other, rule: KintoRuleDestination, hardfork: 3},
+	} {
+		evm := vm.NewEVM(vm.BlockContext{BlockNumber: big.NewInt(tt.block)}, vm.TxContext{Origin: from}, statedb, config, vm.Config{})
+		msg := &Message{From: from, To: tt.to, Value: new(big.Int), GasLimit: 21000}
+
+		err := CheckKintoTransaction(evm, msg)
+		if tt.rule == "" {
+			if err != nil {
+				t.Errorf("block %d, to %v: unexpected rejection: %v", tt.block, tt.to, err)
+			}
+			continue
+		}
+		var rejection *KintoRuleError
+		if !errors.As(err, &rejection) {
+			t.Errorf("block %d, to %v: error %v, want rule %s", tt.block, tt.to, err, tt.rule)
+			continue
+		}
+		if !errors.Is(err, ErrKintoNotAllowed) {
+			t.Errorf("block %d: rejection doesn't wrap ErrKintoNotAllowed", tt.block)
+		}
+		if rejection.Rule != tt.rule || rejection.Hardfork != tt.hardfork || rejection.Destination != tt.to {
+			t.Errorf("block %d: rejection %s at hardfork %d to %v, want %s at hardfork %d to %v",
+				tt.block, rejection.Rule, rejection.Hardfork, rejection.Destination, tt.rule, tt.hardfork, tt.to)
+		}
+	}
+}
+
+func TestEntryPointWithdrawShortCalldata(t *testing.T) {
+	config, statedb := newKintoTestChain(t)
+	var (
+		from       = common.HexToAddress("0x1000")
+		entryPoint = config.Kinto().Contracts.EntryPoint
+	)
+	// A withdrawTo selector without its address is rejected, not read past the calldata
+	for hardfork := 1; hardfork <= 6; hardfork++ {
+		block := int64(15 + 10*hardfork)
+		evm := vm.NewEVM(vm.BlockContext{BlockNumber: big.NewInt(block)}, vm.TxContext{Origin: from}, statedb, config, vm.Config{})
+		msg := &Message{From: from, To: &entryPoint, Value: new(big.Int), Data: common.FromHex(functionSelectorEPWithdrawTo), GasLimit: 21000}
+
+		var rejection *KintoRuleError
+		if err := CheckKintoTransaction(evm, msg); !errors.As(err, &rejection) || rejection.Rule != KintoRuleEntryPointWithdraw || rejection.Hardfork != hardfork {
+			t.Errorf("block %d: error %v, want rule %s at hardfork %d", block, err, KintoRuleEntryPointWithdraw, hardfork)
+		}
+	}
+}
+
+func TestHardForkFiveDinaryCreation(t *testing.T) {
+	config, statedb := newKintoTestChain(t)
+	var (
+		from     = dinaryStagingEOAs[0]
+		contract = dinaryStagingContracts[0]
+	)
+	check := func(to *common.Address) error {
+		evm := vm.NewEVM(vm.BlockContext{BlockNumber: big.NewInt(65)}, vm.TxContext{Origin: from}, statedb, config, vm.Config{})
+		return CheckKintoTransaction(evm, &Message{From: from, To: to, Value: new(big.Int), GasLimit: 21000})
+	}
+	if err := check(&contract); err != nil {
+		t.Fatalf("dinary call rejected: %v", err)
+	}
+	var rejection *KintoRuleError
+	if err := check(nil); !errors.As(err, &rejection) || rejection.Rule != KintoRuleContractCreation || rejection.Hardfork != 5 {
+		t.Fatalf("dinary contract creation error %v, want rule %s at hardfork 5", err, KintoRuleContractCreation)
+	}
+}
+
+func TestExplainKintoTransaction(t *testing.T) {
+	config, statedb := newKintoTestChain(t)
+	var (
+		from       = common.HexToAddress("0x1000")
+		entryPoint = config.Kinto().Contracts.EntryPoint
+		tracer     = new(kintoCallRecorder)
+	)
+	explain := func(block int64, to *common.Address, data []byte) *KintoExplanation {
+		evm := vm.NewEVM(vm.BlockContext{BlockNumber: big.NewInt(block)}, vm.TxContext{Origin: from}, statedb, config, vm.Config{Tracer: tracer})
+		return ExplainKintoTransaction(evm, &Message{From: from, To: to, Value: new(big.Int), Data: data, GasLimit: 21000})
+	}
+
+	// EntryPoint depositTo is not allowed from hardfork 2
+	exp := explain(35, &entryPoint, append(common.FromHex("b760faf9"), make([]byte, 32)...))
+	if !exp.RulesActive || exp.Hardfork != 2 {
+		t.Fatalf("rules active %v at hardfork %d, want hardfork 2", exp.RulesActive, exp.Hardfork)
+	}
+	if exp.DestinationAllowed == nil || !*exp.DestinationAllowed {
+		t.Error("EntryPoint not reported as allowed destination")
+	}
+	if exp.FunctionSelector != "b760faf9" || exp.FunctionAllowed {
+		t.Errorf("function %q reported allowed %v", exp.FunctionSelector, exp.FunctionAllowed)
+	}
+	if exp.AppRegistry != nil {
+		t.Error("AppRegistry verdict before hardfork 6")
+	}
+	var rejection *KintoRuleError
+	if !errors.As(exp.Err, &rejection) || rejection.Rule != KintoRuleEntryPointFunction {
+		t.Errorf("error %v, want rule %s", exp.Err, KintoRuleEntryPointFunction)
+	}
+
+	// From hardfork 7 the AppRegistry decides, and the failures of its call let
+	// the transaction through
+	exp = explain(85, &entryPoint, nil)
+	if exp.Hardfork != 7 || exp.DestinationAllowed != nil {
+		t.Errorf("hardfork %d with destination allowlist", exp.Hardfork)
+	}
+	if exp.AppRegistry == nil || exp.AppRegistry.Err == nil || exp.AppRegistry.Allowed {
+		t.Errorf("AppRegistry verdict %+v, want failed call", exp.AppRegistry)
+	}
+	if len(tracer.labels) != 1 {
+		t.Errorf("AppRegistry called %d times, want once", len(tracer.labels))
+	}
+	if exp.Err != nil {
+		t.Errorf("transaction rejected: %v", exp.Err)
+	}
+}
+
+// kintoCallRecorder records the calls of the Kinto rules, and the top calls
+// traced while they're made.
+type kintoCallRecorder struct {
+	vm.EVMLogger
+	label  string
+	labels []string
+	calls  []common.Address
+}
+
+func (r *kintoCallRecorder) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	if r.label != "" {
+		r.calls = append(r.calls, to)
+	}
+}
+
+func (r *kintoCallRecorder) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+
+func (r *kintoCallRecorder) CaptureKintoCallStart(label string) {
+	r.label = label
+	r.labels = append(r.labels, label)
+}
+
+func (r *kintoCallRecorder) CaptureKintoCallEnd() {
+	r.label = ""
+}
+
+func TestKintoFailOpen(t *testing.T) {
+	config, statedb := newKintoTestChain(t)
+	var (
+		from   = common.HexToAddress("0x1000")
+		to     = common.HexToAddress("0x2000")
+		tracer = new(kintoCallRecorder)
+		evm    = vm.NewEVM(vm.BlockContext{BlockNumber: big.NewInt(85)}, vm.TxContext{Origin: from}, statedb, config, vm.Config{Tracer: tracer})
+		msg    = &Message{From: from, To: &to, Value: new(big.Int), GasLimit: 21000, TxRunMode: MessageCommitMode}
+		st     = &StateTransition{gp: new(GasPool).AddGas(math.MaxUint64), evm: evm, msg: msg, state: statedb}
+	)
+	// Without AppRegistry code the call of hardfork 7 fails and lets the message through
+	if err := enforceKinto(msg, st); err != nil {
+		t.Fatalf("message rejected: %v", err)
+	}
+	if st.kintoFailOpen == nil {
+		t.Error("AppRegistry failure not recorded")
+	}
+	if len(tracer.labels) != 1 || tracer.labels[0] != "kinto:isContractCallAllowedFromEOA" {
+		t.Errorf("traced Kinto calls %v", tracer.labels)
+	}
+	if appRegistry := config.Kinto().Contracts.AppRegistry; len(tracer.calls) != 1 || tracer.calls[0] != appRegistry {
+		t.Errorf("traced calls %v, want the AppRegistry call", tracer.calls)
+	}
+}
+
+func TestKintoMetricsCommitOnly(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	// Distinct rules, for their meters to be registered with the metrics enabled
+	// in place of the ones of the other tests
+	config, statedb := newKintoTestChain(t)
+	config.Kinto().RulesBlockStart = 11
+	metrics.Unregister("kinto/hf0/denied/" + KintoRuleDestination)
+	metrics.Unregister("kinto/destination/other/denied")
+
+	var (
+		from = common.HexToAddress("0x1000")
+		to   = common.HexToAddress("0x2000")
+		evm  = vm.NewEVM(vm.BlockContext{BlockNumber: big.NewInt(15)}, vm.TxContext{Origin: from}, statedb, config, vm.Config{})
+	)
+	for _, mode := range []MessageRunMode{MessageReplayMode, MessageGasEstimationMode, MessageCommitMode} {
+		msg := &Message{From: from, To: &to, Value: new(big.Int), GasLimit: 21000, TxRunMode: mode}
+		st := &StateTransition{gp: new(GasPool).AddGas(math.MaxUint64), evm: evm, msg: msg, state: statedb}
+		if err := enforceKinto(msg, st); !errors.Is(err, ErrKintoNotAllowed) {
+			t.Fatalf("mode %d: error %v, want rejection", mode, err)
+		}
+	}
+	if count := metrics.GetOrRegisterMeter("kinto/hf0/denied/"+KintoRuleDestination, nil).Snapshot().Count(); count != 1 {
+		t.Errorf("%d denials recorded, want the committed one", count)
+	}
+	if count := metrics.GetOrRegisterMeter("kinto/destination/other/denied", nil).Snapshot().Count(); count != 1 {
+		t.Errorf("%d denials recorded by destination, want the committed one", count)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// LogIndexer maintains the exact log index of the canonical chain, mapping
+// contract addresses and topics to the blocks and positions of their logs. It
+// indexes new blocks as the chain head moves, and unindexes the blocks dropped
+// by reorgs. Blocks older than the ones present when the index was first
+// enabled are indexed by IndexLogs.
+type LogIndexer struct {
+	*canonicalIndexer
+}
+
+// NewLogIndexer starts indexing the logs of the given chain.
+func NewLogIndexer(chain *BlockChain) *LogIndexer {
+	return &LogIndexer{newCanonicalIndexer(chain, logIndex{})}
+}
+
+// LogIndexRange returns the range of blocks whose logs are indexed in the
+// database, reporting whether any block is indexed.
+func LogIndexRange(db ethdb.KeyValueReader) (uint64, uint64, bool) {
+	return indexRange(db, logIndex{})
+}
+
+// IndexLogs indexes the logs of the canonical blocks from the given number up
+// to the tail of the log index, or up to the chain head if the index doesn't
+// exist yet. It's meant to backfill the index for existing history while the
+// node is not running.
+func IndexLogs(db ethdb.Database, from uint64, interrupt chan struct{}) error {
+	return backfillIndex(db, logIndex{}, from, interrupt)
+}
+
+// logIndex is the canonicalIndex of the logs.
+type logIndex struct{}
+
+func (logIndex) name() string { return "logs" }
+
+func (logIndex) readHead(db ethdb.KeyValueReader) (uint64, common.Hash, bool) {
+	return rawdb.ReadLogIndexHead(db)
+}
+
+func (logIndex) writeHead(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	rawdb.WriteLogIndexHead(db, number, hash)
+}
+
+func (logIndex) readTail(db ethdb.KeyValueReader) *uint64 {
+	return rawdb.ReadLogIndexTail(db)
+}
+
+func (logIndex) writeTail(db ethdb.KeyValueWriter, number uint64) {
+	rawdb.WriteLogIndexTail(db, number)
+}
+
+func (logIndex) index(db ethdb.Reader, batch ethdb.KeyValueWriter, number uint64, hash common.Hash, backfill bool) {
+	rawdb.WriteLogIndex(batch, number, rawdb.ReadLogs(db, hash, number))
+}
+
+func (logIndex) unindex(db ethdb.Reader, batch ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	rawdb.DeleteLogIndex(batch, number, rawdb.ReadLogs(db, hash, number))
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// TestLogIndexer tests that the log index follows the canonical chain through
+// reorgs, and that IndexLogs backfills an existing chain.
+func TestLogIndexer(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		contract = common.HexToAddress("0xc0ffee")
+		topic    = common.BytesToHash([]byte{0xff})
+	)
+	// PUSH1 0xff PUSH1 0 PUSH1 0 LOG1
+	code := common.FromHex("0x60ff60006000a1")
+
+	testCanonicalIndexer(t, logIndex{}, key, contract, code, func(db ethdb.Database, from, to uint64, called func(number uint64) bool) {
+		t.Helper()
+		for _, entries := range []map[uint64][]uint32{
+			rawdb.ReadLogIndex(db, rawdb.LogIndexAddress, contract.Bytes(), from, to),
+			rawdb.ReadLogIndex(db, rawdb.LogIndexTopic0, topic.Bytes(), from, to),
+		} {
+			for number := from; number <= to; number++ {
+				positions, ok := entries[number]
+				if ok != called(number) {
+					t.Fatalf("block %d: index presence mismatch: have %v, want %v", number, ok, called(number))
+				}
+				if ok && (len(positions) != 1 || positions[0] != 0) {
+					t.Fatalf("block %d: unexpected positions %v", number, positions)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReadStateChangeset retrieves the state changeset of the given block.
+func ReadStateChangeset(db ethdb.KeyValueReader, number uint64, hash common.Hash) *types.StateChangeset {
+	data, _ := db.Get(stateChangesetKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	changeset := new(types.StateChangeset)
+	if err := rlp.DecodeBytes(data, changeset); err != nil {
+		log.Error("Invalid state changeset RLP", "number", number, "hash", hash, "err", err)
+		return nil
+	}
+	return changeset
+}
+
+// HasStateChangeset verifies the existence of the state changeset of the
+// given block.
+func HasStateChangeset(db ethdb.KeyValueReader, number uint64, hash common.Hash) bool {
+	has, _ := db.Has(stateChangesetKey(number, hash))
+	return has
+}
+
+// WriteStateChangeset stores the state changeset of the given block.
+func WriteStateChangeset(db ethdb.KeyValueWriter, number uint64, hash common.Hash, changeset *types.StateChangeset) {
+	data, err := rlp.EncodeToBytes(changeset)
+	if err != nil {
+		log.Crit("Failed to encode state changeset", "err", err)
+	}
+	if err := db.Put(stateChangesetKey(number, hash), data); err != nil {
+		log.Crit("Failed to store state changeset", "err", err)
+	}
+}
+
+// DeleteStateChangesets removes the state changesets of all blocks (canonical
+// or not) at the given height.
+func DeleteStateChangesets(db ethdb.KeyValueStore, number uint64) {
+	it := db.NewIterator(stateChangesetNumberPrefix(number), nil)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		if err := batch.Delete(it.Key()); err != nil {
+			log.Crit("Failed to delete state changeset", "err", err)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to delete state changesets", "err", err)
+	}
+}
+
+// ReadStateChangesetTail retrieves the number of the oldest block whose state
+// changeset is retained.
+func ReadStateChangesetTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(stateChangesetTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteStateChangesetTail stores the number of the oldest block whose state
+// changeset is retained.
+func WriteStateChangesetTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(stateChangesetTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the state changeset tail", "err", err)
+	}
+}
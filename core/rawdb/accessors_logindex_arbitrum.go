@@ -0,0 +1,147 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Kinds of the log index entries. Topics are indexed by position, so that the
+// index answers the same positional queries as the log filters.
+const (
+	LogIndexAddress byte = iota // entries of the emitting contract address
+	LogIndexTopic0              // entries of the first topic, followed by the other positions
+)
+
+// logIndexEntries groups the positions of the logs of a block by index key.
+// The position of a log is its index within the block.
+func logIndexEntries(logs [][]*types.Log) map[string][]uint32 {
+	var (
+		entries  = make(map[string][]uint32)
+		position uint32
+	)
+	add := func(kind byte, value []byte) {
+		key := string(append([]byte{kind}, value...))
+		if positions := entries[key]; len(positions) == 0 || positions[len(positions)-1] != position {
+			entries[key] = append(positions, position)
+		}
+	}
+	for _, txLogs := range logs {
+		for _, l := range txLogs {
+			add(LogIndexAddress, l.Address.Bytes())
+			for i, topic := range l.Topics {
+				add(LogIndexTopic0+byte(i), topic.Bytes())
+			}
+			position++
+		}
+	}
+	return entries
+}
+
+// WriteLogIndex stores the log index entries of the logs of a canonical block,
+// as returned by ReadLogs.
+func WriteLogIndex(db ethdb.KeyValueWriter, number uint64, logs [][]*types.Log) {
+	for key, positions := range logIndexEntries(logs) {
+		data, err := rlp.EncodeToBytes(positions)
+		if err != nil {
+			log.Crit("Failed to encode log index entry", "err", err)
+		}
+		if err := db.Put(logIndexKey(key[0], []byte(key[1:]), number), data); err != nil {
+			log.Crit("Failed to store log index entry", "err", err)
+		}
+	}
+}
+
+// DeleteLogIndex removes the log index entries of the logs of a block.
+func DeleteLogIndex(db ethdb.KeyValueWriter, number uint64, logs [][]*types.Log) {
+	for key := range logIndexEntries(logs) {
+		if err := db.Delete(logIndexKey(key[0], []byte(key[1:]), number)); err != nil {
+			log.Crit("Failed to delete log index entry", "err", err)
+		}
+	}
+}
+
+// ReadLogIndex retrieves the positions of the logs matching the address or
+// topic of the given kind, in the blocks of the range [from, to]. The result
+// maps the block numbers to the log positions within them.
+func ReadLogIndex(db ethdb.Iteratee, kind byte, value []byte, from, to uint64) map[uint64][]uint32 {
+	prefix := logIndexValuePrefix(kind, value)
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	matches := make(map[uint64][]uint32)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(key[len(prefix):])
+		if number > to {
+			break
+		}
+		var positions []uint32
+		if err := rlp.DecodeBytes(it.Value(), &positions); err != nil {
+			log.Error("Invalid log index entry", "number", number, "err", err)
+			continue
+		}
+		matches[number] = positions
+	}
+	return matches
+}
+
+// ReadLogIndexHead retrieves the number and hash of the newest block whose
+// logs are indexed.
+func ReadLogIndexHead(db ethdb.KeyValueReader) (uint64, common.Hash, bool) {
+	data, _ := db.Get(logIndexHeadKey)
+	if len(data) != 8+common.HashLength {
+		return 0, common.Hash{}, false
+	}
+	return binary.BigEndian.Uint64(data), common.BytesToHash(data[8:]), true
+}
+
+// WriteLogIndexHead stores the number and hash of the newest block whose logs
+// are indexed.
+func WriteLogIndexHead(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	if err := db.Put(logIndexHeadKey, append(encodeBlockNumber(number), hash.Bytes()...)); err != nil {
+		log.Crit("Failed to store the log index head", "err", err)
+	}
+}
+
+// ReadLogIndexTail retrieves the number of the oldest block whose logs are
+// indexed.
+func ReadLogIndexTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(logIndexTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteLogIndexTail stores the number of the oldest block whose logs are
+// indexed.
+func WriteLogIndexTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(logIndexTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the log index tail", "err", err)
+	}
+}
@@ -109,3 +109,17 @@ func WriteWasmSchemaVersion(db ethdb.KeyValueWriter) {
 func ReadWasmSchemaVersion(db ethdb.KeyValueReader) ([]byte, error) {
 	return db.Get(wasmSchemaVersionKey)
 }
+
+// ReadOnlinePruning retrieves the serialized progress of the online state
+// pruning.
+func ReadOnlinePruning(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(onlinePruningKey)
+	return data
+}
+
+// WriteOnlinePruning stores the serialized progress of the online state pruning.
+func WriteOnlinePruning(db ethdb.KeyValueWriter, progress []byte) {
+	if err := db.Put(onlinePruningKey, progress); err != nil {
+		log.Crit("Failed to store online pruning progress", "err", err)
+	}
+}
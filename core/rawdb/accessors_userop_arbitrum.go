@@ -0,0 +1,117 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// UserOpLookupEntry is the decoded UserOperationEvent of an ERC-4337 user
+// operation, with the position of the event in the canonical chain.
+type UserOpLookupEntry struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	TxIndex     uint64
+	LogIndex    uint64 // index of the UserOperationEvent within the block
+
+	EntryPoint    common.Address
+	Sender        common.Address
+	Paymaster     common.Address
+	Nonce         *big.Int
+	Success       bool
+	ActualGasCost *big.Int
+	ActualGasUsed *big.Int
+}
+
+// ReadUserOpLookupEntry retrieves the lookup entry of the user operation with
+// the given hash, nil if it isn't indexed.
+func ReadUserOpLookupEntry(db ethdb.KeyValueReader, hash common.Hash) *UserOpLookupEntry {
+	data, _ := db.Get(userOpLookupKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(UserOpLookupEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Error("Invalid user operation lookup entry RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return entry
+}
+
+// WriteUserOpLookupEntry stores the lookup entry of the user operation with the
+// given hash.
+func WriteUserOpLookupEntry(db ethdb.KeyValueWriter, hash common.Hash, entry *UserOpLookupEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode user operation lookup entry", "err", err)
+	}
+	if err := db.Put(userOpLookupKey(hash), data); err != nil {
+		log.Crit("Failed to store user operation lookup entry", "err", err)
+	}
+}
+
+// DeleteUserOpLookupEntry removes the lookup entry of the user operation with
+// the given hash.
+func DeleteUserOpLookupEntry(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Delete(userOpLookupKey(hash)); err != nil {
+		log.Crit("Failed to delete user operation lookup entry", "err", err)
+	}
+}
+
+// ReadUserOpIndexHead retrieves the number and hash of the newest block whose
+// user operations are indexed.
+func ReadUserOpIndexHead(db ethdb.KeyValueReader) (uint64, common.Hash, bool) {
+	data, _ := db.Get(userOpIndexHeadKey)
+	if len(data) != 8+common.HashLength {
+		return 0, common.Hash{}, false
+	}
+	return binary.BigEndian.Uint64(data), common.BytesToHash(data[8:]), true
+}
+
+// WriteUserOpIndexHead stores the number and hash of the newest block whose
+// user operations are indexed.
+func WriteUserOpIndexHead(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	if err := db.Put(userOpIndexHeadKey, append(encodeBlockNumber(number), hash.Bytes()...)); err != nil {
+		log.Crit("Failed to store the user operation index head", "err", err)
+	}
+}
+
+// ReadUserOpIndexTail retrieves the number of the oldest block whose user
+// operations are indexed.
+func ReadUserOpIndexTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(userOpIndexTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteUserOpIndexTail stores the number of the oldest block whose user
+// operations are indexed.
+func WriteUserOpIndexTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(userOpIndexTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the user operation index tail", "err", err)
+	}
+}
@@ -495,9 +495,53 @@ func (s *stat) Count() string {
 	return s.count.String()
 }
 
-// InspectDatabase traverses the entire database and checks the size
+// InspectStat is the size and number of items of a category of data.
+type InspectStat struct {
+	Database string `json:"database"`
+	Category string `json:"category"`
+	Size     uint64 `json:"size"`
+	Items    uint64 `json:"items"`
+}
+
+func newInspectStat(database, category string, s *stat) InspectStat {
+	return InspectStat{Database: database, Category: category, Size: uint64(s.size), Items: uint64(s.count)}
+}
+
+// InspectReport is the result of a database inspection.
+type InspectReport struct {
+	Time        time.Time     `json:"time"`
+	Head        uint64        `json:"head"` // number of the head header at the time of the inspection
+	Stats       []InspectStat `json:"stats"`
+	Total       uint64        `json:"total"`
+	Unaccounted InspectStat   `json:"unaccounted"`
+}
+
+// InspectDatabase traverses the entire database and prints the size
 // of all different categories of data.
 func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
+	report, err := InspectDatabaseReport(db, keyPrefix, keyStart)
+	if err != nil {
+		return err
+	}
+	stats := make([][]string, 0, len(report.Stats))
+	for _, s := range report.Stats {
+		stats = append(stats, []string{s.Database, s.Category, common.StorageSize(s.Size).String(), counter(s.Items).String()})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Database", "Category", "Size", "Items"})
+	table.SetFooter([]string{"", "Total", common.StorageSize(report.Total).String(), " "})
+	table.AppendBulk(stats)
+	table.Render()
+
+	if report.Unaccounted.Size > 0 {
+		log.Error("Database contains unaccounted data", "size", common.StorageSize(report.Unaccounted.Size), "count", report.Unaccounted.Items)
+	}
+	return nil
+}
+
+// InspectDatabaseReport traverses the entire database and checks the size
+// of all different categories of data.
+func InspectDatabaseReport(db ethdb.Database, keyPrefix, keyStart []byte) (*InspectReport, error) {
 	it := db.NewIterator(keyPrefix, keyStart)
 	defer it.Release()
 
@@ -530,6 +574,9 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		chtTrieNodes   stat
 		bloomTrieNodes stat
 
+		// Arbitrum and Stylus data
+		arbitrum = newArbitrumInspector(db, keyPrefix, keyStart)
+
 		// Meta- and unaccounted data
 		metadata    stat
 		unaccounted stat
@@ -547,10 +594,13 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		switch {
 		case bytes.HasPrefix(key, headerPrefix) && len(key) == (len(headerPrefix)+8+common.HashLength):
 			headers.Add(size)
+			arbitrum.inspectHeader(it.Value())
 		case bytes.HasPrefix(key, blockBodyPrefix) && len(key) == (len(blockBodyPrefix)+8+common.HashLength):
 			bodies.Add(size)
 		case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
-			receipts.Add(size)
+			if !arbitrum.inspectReceipts(it.Value(), size) {
+				receipts.Add(size)
+			}
 		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerTDSuffix):
 			tds.Add(size)
 		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerHashSuffix):
@@ -595,6 +645,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			bytes.HasPrefix(key, BloomTrieIndexPrefix) ||
 			bytes.HasPrefix(key, BloomTriePrefix): // Bloomtrie sub
 			bloomTrieNodes.Add(size)
+		case arbitrum.inspect(key, size):
 		default:
 			var accounted bool
 			for _, meta := range [][]byte{
@@ -603,6 +654,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
 				uncleanShutdownKey, badBlockKey, transitionStatusKey, skeletonSyncStatusKey,
 				persistentStateIDKey, trieJournalKey, snapshotSyncStatusKey, snapSyncStatusFlagKey,
+				wasmSchemaVersionKey, stateChangesetTailKey, onlinePruningKey,
+				logIndexHeadKey, logIndexTailKey, userOpIndexHeadKey, userOpIndexTailKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -620,56 +673,61 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			logged = time.Now()
 		}
 	}
-	// Display the database statistic of key-value store.
-	stats := [][]string{
-		{"Key-Value store", "Headers", headers.Size(), headers.Count()},
-		{"Key-Value store", "Bodies", bodies.Size(), bodies.Count()},
-		{"Key-Value store", "Receipt lists", receipts.Size(), receipts.Count()},
-		{"Key-Value store", "Difficulties", tds.Size(), tds.Count()},
-		{"Key-Value store", "Block number->hash", numHashPairings.Size(), numHashPairings.Count()},
-		{"Key-Value store", "Block hash->number", hashNumPairings.Size(), hashNumPairings.Count()},
-		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
-		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
-		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
-		{"Key-Value store", "Hash trie nodes", legacyTries.Size(), legacyTries.Count()},
-		{"Key-Value store", "Path trie state lookups", stateLookups.Size(), stateLookups.Count()},
-		{"Key-Value store", "Path trie account nodes", accountTries.Size(), accountTries.Count()},
-		{"Key-Value store", "Path trie storage nodes", storageTries.Size(), storageTries.Count()},
-		{"Key-Value store", "Trie preimages", preimages.Size(), preimages.Count()},
-		{"Key-Value store", "Account snapshot", accountSnaps.Size(), accountSnaps.Count()},
-		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
-		{"Key-Value store", "Beacon sync headers", beaconHeaders.Size(), beaconHeaders.Count()},
-		{"Key-Value store", "Clique snapshots", cliqueSnaps.Size(), cliqueSnaps.Count()},
-		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
-		{"Light client", "CHT trie nodes", chtTrieNodes.Size(), chtTrieNodes.Count()},
-		{"Light client", "Bloom trie nodes", bloomTrieNodes.Size(), bloomTrieNodes.Count()},
+	// Hash trie state roots are reported separately, don't count them twice.
+	arbitrum.moveStateRoots(&legacyTries)
+
+	// Collect the database statistic of key-value store.
+	stats := []InspectStat{
+		newInspectStat("Key-Value store", "Headers", &headers),
+		newInspectStat("Key-Value store", "Bodies", &bodies),
+		newInspectStat("Key-Value store", "Receipt lists", &receipts),
+		newInspectStat("Key-Value store", "Difficulties", &tds),
+		newInspectStat("Key-Value store", "Block number->hash", &numHashPairings),
+		newInspectStat("Key-Value store", "Block hash->number", &hashNumPairings),
+		newInspectStat("Key-Value store", "Transaction index", &txLookups),
+		newInspectStat("Key-Value store", "Bloombit index", &bloomBits),
+		newInspectStat("Key-Value store", "Contract codes", &codes),
+		newInspectStat("Key-Value store", "Hash trie nodes", &legacyTries),
+		newInspectStat("Key-Value store", "Path trie state lookups", &stateLookups),
+		newInspectStat("Key-Value store", "Path trie account nodes", &accountTries),
+		newInspectStat("Key-Value store", "Path trie storage nodes", &storageTries),
+		newInspectStat("Key-Value store", "Trie preimages", &preimages),
+		newInspectStat("Key-Value store", "Account snapshot", &accountSnaps),
+		newInspectStat("Key-Value store", "Storage snapshot", &storageSnaps),
+		newInspectStat("Key-Value store", "Beacon sync headers", &beaconHeaders),
+		newInspectStat("Key-Value store", "Clique snapshots", &cliqueSnaps),
+		newInspectStat("Key-Value store", "Singleton metadata", &metadata),
+		newInspectStat("Light client", "CHT trie nodes", &chtTrieNodes),
+		newInspectStat("Light client", "Bloom trie nodes", &bloomTrieNodes),
 	}
+	stats = append(stats, arbitrum.stats()...)
+
 	// Inspect all registered append-only file store then.
 	ancients, err := inspectFreezers(db)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, ancient := range ancients {
 		for _, table := range ancient.sizes {
-			stats = append(stats, []string{
-				fmt.Sprintf("Ancient store (%s)", strings.Title(ancient.name)),
-				strings.Title(table.name),
-				table.size.String(),
-				fmt.Sprintf("%d", ancient.count()),
+			stats = append(stats, InspectStat{
+				Database: fmt.Sprintf("Ancient store (%s)", strings.Title(ancient.name)),
+				Category: strings.Title(table.name),
+				Size:     uint64(table.size),
+				Items:    ancient.count(),
 			})
 		}
 		total += ancient.size()
 	}
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Database", "Category", "Size", "Items"})
-	table.SetFooter([]string{"", "Total", total.String(), " "})
-	table.AppendBulk(stats)
-	table.Render()
-
-	if unaccounted.size > 0 {
-		log.Error("Database contains unaccounted data", "size", unaccounted.size, "count", unaccounted.count)
+	report := &InspectReport{
+		Time:        time.Now(),
+		Stats:       stats,
+		Total:       uint64(total),
+		Unaccounted: newInspectStat("Key-Value store", "Unaccounted", &unaccounted),
 	}
-	return nil
+	if number := ReadHeaderNumber(db, ReadHeadHeaderHash(db)); number != nil {
+		report.Head = *number
+	}
+	return report, nil
 }
 
 // printChainMetadata prints out chain metadata to stderr.
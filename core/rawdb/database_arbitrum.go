@@ -0,0 +1,204 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// arbitrumInspector collects the database statistics of the key spaces added
+// by Arbitrum and Stylus.
+type arbitrumInspector struct {
+	db         ethdb.KeyValueReader
+	keyPrefix  []byte
+	keyStart   []byte
+	hashScheme bool
+
+	activatedAsm    [4]stat // activated asm per target, see wasmTargets
+	deprecatedWasm  stat    // wasm entries of schema version 0
+	changesets      stat    // per-block state changesets
+	logIndex        stat    // exact log index entries
+	userOpLookups   stat    // user operation lookup entries
+	classicReceipts stat    // receipt lists in the Arbitrum classic encoding
+
+	stateRoots    stat                                // state roots persisted for headers in the key-value store
+	iteratedRoots stat                                // state roots also accounted for as hash trie nodes
+	missingRoots  counter                             // headers in the key-value store whose state was not persisted
+	seenRoots     lru.BasicLRU[common.Hash, struct{}] // state roots of the latest headers, already accounted for
+}
+
+// maxSeenRoots is the number of state roots remembered to skip the headers
+// sharing the root of a previous one. Headers are iterated by number, so such
+// headers are close to each other, and remembering the latest roots is enough
+// to not account for them twice while keeping the memory use bounded.
+const maxSeenRoots = 4096
+
+// wasmTargets lists the activated asm prefixes in the order they are reported.
+var wasmTargets = []struct {
+	prefix WasmPrefix
+	target string
+}{
+	{activatedAsmWavmPrefix, string(TargetWavm)},
+	{activatedAsmArmPrefix, string(TargetArm64)},
+	{activatedAsmX86Prefix, string(TargetAmd64)},
+	{activatedAsmHostPrefix, string(TargetHost)},
+}
+
+func newArbitrumInspector(db ethdb.Database, keyPrefix, keyStart []byte) *arbitrumInspector {
+	return &arbitrumInspector{
+		db:         db,
+		keyPrefix:  keyPrefix,
+		keyStart:   keyStart,
+		hashScheme: ReadStateScheme(db) == HashScheme,
+		seenRoots:  lru.NewBasicLRU[common.Hash, struct{}](maxSeenRoots),
+	}
+}
+
+// inspect accounts for an entry in one of the Arbitrum key spaces, reporting
+// whether the key belongs to any of them.
+func (a *arbitrumInspector) inspect(key []byte, size common.StorageSize) bool {
+	if len(key) == WasmKeyLen {
+		for i, target := range wasmTargets {
+			if bytes.HasPrefix(key, target.prefix[:]) {
+				a.activatedAsm[i].Add(size)
+				return true
+			}
+		}
+	}
+	if prefixes, length := DeprecatedPrefixesV0(); len(key) == length {
+		for _, prefix := range prefixes {
+			if bytes.HasPrefix(key, prefix) {
+				a.deprecatedWasm.Add(size)
+				return true
+			}
+		}
+	}
+	if bytes.HasPrefix(key, stateChangesetPrefix) && len(key) == len(stateChangesetPrefix)+8+common.HashLength {
+		a.changesets.Add(size)
+		return true
+	}
+	if bytes.HasPrefix(key, logIndexPrefix) {
+		if n := len(key) - len(logIndexPrefix) - 1 - 8; n == common.AddressLength || n == common.HashLength {
+			a.logIndex.Add(size)
+			return true
+		}
+	}
+	if bytes.HasPrefix(key, userOpLookupPrefix) && len(key) == len(userOpLookupPrefix)+common.HashLength {
+		a.userOpLookups.Add(size)
+		return true
+	}
+	return false
+}
+
+// inspectReceipts accounts for a receipt list if it is in the Arbitrum classic
+// encoding, reporting whether it was.
+func (a *arbitrumInspector) inspectReceipts(blob []byte, size common.StorageSize) bool {
+	if !isArbitrumLegacyReceipts(blob) {
+		return false
+	}
+	a.classicReceipts.Add(size)
+	return true
+}
+
+// inspectHeader checks whether the state of a header in the key-value store was
+// persisted. Sparse archive nodes skip committing the state of some blocks, so
+// only the state roots of part of the headers are expected to be present. The
+// check only applies to the hash scheme, where the root node is keyed by hash.
+func (a *arbitrumInspector) inspectHeader(blob []byte) {
+	if !a.hashScheme {
+		return
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(blob, header); err != nil {
+		return
+	}
+	if header.Root == types.EmptyRootHash || a.seenRoots.Contains(header.Root) {
+		return
+	}
+	node, _ := a.db.Get(header.Root.Bytes())
+	if len(node) == 0 {
+		a.missingRoots++
+		return
+	}
+	size := common.StorageSize(common.HashLength + len(node))
+	a.seenRoots.Add(header.Root, struct{}{})
+	a.stateRoots.Add(size)
+	if a.iterated(header.Root) {
+		a.iteratedRoots.Add(size)
+	}
+}
+
+// iterated reports whether a state root node was visited by the inspection,
+// in which case it was also accounted for as a hash trie node.
+func (a *arbitrumInspector) iterated(root common.Hash) bool {
+	start := append(append([]byte{}, a.keyPrefix...), a.keyStart...)
+	return bytes.HasPrefix(root.Bytes(), a.keyPrefix) && bytes.Compare(root.Bytes(), start) >= 0
+}
+
+// moveStateRoots removes the state roots found by inspectHeader from the hash
+// trie node statistics, so they're not reported twice. A root shared by headers
+// too far apart is accounted for more than once, hence the clamping.
+func (a *arbitrumInspector) moveStateRoots(legacyTries *stat) {
+	if a.iteratedRoots.count > legacyTries.count || a.iteratedRoots.size > legacyTries.size {
+		legacyTries.size, legacyTries.count = 0, 0
+		return
+	}
+	legacyTries.size -= a.iteratedRoots.size
+	legacyTries.count -= a.iteratedRoots.count
+}
+
+// stats returns the report rows of the Arbitrum key spaces.
+func (a *arbitrumInspector) stats() []InspectStat {
+	var stats []InspectStat
+	for i, target := range wasmTargets {
+		stats = append(stats, newInspectStat("Key-Value store", fmt.Sprintf("Stylus activated asm (%s)", target.target), &a.activatedAsm[i]))
+	}
+	return append(stats,
+		newInspectStat("Key-Value store", "Stylus deprecated v0 entries", &a.deprecatedWasm),
+		newInspectStat("Key-Value store", "State changesets", &a.changesets),
+		newInspectStat("Key-Value store", "Log index entries", &a.logIndex),
+		newInspectStat("Key-Value store", "User operation lookups", &a.userOpLookups),
+		newInspectStat("Key-Value store", "Arbitrum classic receipt lists", &a.classicReceipts),
+		newInspectStat("Key-Value store", "Hash trie state roots", &a.stateRoots),
+		InspectStat{Database: "Key-Value store", Category: "Headers without state", Items: uint64(a.missingRoots)},
+	)
+}
+
+// isArbitrumLegacyReceipts reports whether a stored receipt list is in the
+// Arbitrum classic encoding, by checking the status field of its first receipt.
+func isArbitrumLegacyReceipts(blob []byte) bool {
+	list, _, err := rlp.SplitList(blob)
+	if err != nil {
+		return false
+	}
+	receipt, _, err := rlp.SplitList(list)
+	if err != nil {
+		return false
+	}
+	status, _, err := rlp.SplitString(receipt)
+	if err != nil {
+		return false
+	}
+	return types.IsArbitrumLegacyReceiptStatus(status)
+}
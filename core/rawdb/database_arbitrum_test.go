@@ -0,0 +1,144 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that the database inspection breaks out the Arbitrum key spaces instead
+// of reporting them as unaccounted.
+func TestInspectDatabaseArbitrum(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	defer db.Close()
+
+	// Stylus entries for a few targets, including the deprecated ones
+	WriteActivatedAsm(db, TargetWavm, common.Hash{1}, []byte{1})
+	WriteActivatedAsm(db, TargetAmd64, common.Hash{1}, []byte{1})
+	WriteActivatedAsm(db, TargetAmd64, common.Hash{2}, []byte{2})
+	WriteWasmSchemaVersion(db)
+	prefixes, _ := DeprecatedPrefixesV0()
+	db.Put(append(common.CopyBytes(prefixes[0]), common.Hash{3}.Bytes()...), []byte{3})
+
+	// State changesets
+	WriteStateChangeset(db, 1, common.Hash{4}, &types.StateChangeset{BlockNumber: 1, BlockHash: common.Hash{4}})
+	WriteStateChangesetTail(db, 1)
+
+	// Log index entries of an address and a topic
+	WriteLogIndex(db, 1, [][]*types.Log{{{Address: common.Address{8}, Topics: []common.Hash{{9}}}}})
+	WriteLogIndexHead(db, 1, common.Hash{4})
+	WriteLogIndexTail(db, 1)
+
+	// A user operation lookup entry
+	WriteUserOpLookupEntry(db, common.Hash{10}, &UserOpLookupEntry{BlockNumber: 1, BlockHash: common.Hash{4}})
+	WriteUserOpIndexHead(db, 1, common.Hash{4})
+	WriteUserOpIndexTail(db, 1)
+
+	// A sparse archive chain, with the state of the last block skipped
+	var (
+		node    = []byte{0xc0}
+		root    = crypto.Keccak256Hash(node)
+		missing = common.Hash{5}
+	)
+	WriteLegacyTrieNode(db, root, node)
+	for i, stateRoot := range []common.Hash{root, root, missing} {
+		header := &types.Header{Number: big.NewInt(int64(i)), Root: stateRoot, Extra: []byte{byte(i)}}
+		WriteHeader(db, header)
+		WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+	}
+	// A classic and a Nitro receipt list
+	WriteReceipts(db, common.Hash{6}, 1, types.Receipts{{Type: types.ArbitrumLegacyTxType, Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{}}})
+	WriteReceipts(db, common.Hash{7}, 2, types.Receipts{{Type: types.DynamicFeeTxType, Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{}}})
+
+	report, err := InspectDatabaseReport(db, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	items := make(map[string]uint64)
+	for _, stat := range report.Stats {
+		if stat.Database == "Key-Value store" {
+			items[stat.Category] = stat.Items
+		}
+	}
+	for category, want := range map[string]uint64{
+		"Stylus activated asm (wavm)":    1,
+		"Stylus activated asm (amd64)":   2,
+		"Stylus activated asm (arm64)":   0,
+		"Stylus deprecated v0 entries":   1,
+		"State changesets":               1,
+		"Log index entries":              2,
+		"User operation lookups":         1,
+		"Arbitrum classic receipt lists": 1,
+		"Receipt lists":                  1,
+		"Headers":                        3,
+		"Hash trie state roots":          1,
+		"Hash trie nodes":                0,
+		"Headers without state":          1,
+	} {
+		if have := items[category]; have != want {
+			t.Errorf("%s: item count mismatch: have %d, want %d", category, have, want)
+		}
+	}
+	if report.Unaccounted.Items != 0 {
+		t.Errorf("unaccounted items: have %d, want 0", report.Unaccounted.Items)
+	}
+}
+
+// Tests that the state roots remembered by the inspection are bounded, while
+// the roots shared by consecutive headers are still accounted for once.
+func TestInspectHeaderSeenRootsBounded(t *testing.T) {
+	db := NewMemoryDatabase()
+	a := newArbitrumInspector(db, nil, nil)
+	a.hashScheme = true
+
+	for i := 0; i < maxSeenRoots+10; i++ {
+		node := []byte{0xc1, byte(i), byte(i >> 8)}
+		root := crypto.Keccak256Hash(node)
+		WriteLegacyTrieNode(db, root, node)
+
+		// Every root is shared by two consecutive headers
+		for j := 0; j < 2; j++ {
+			blob, err := rlp.EncodeToBytes(&types.Header{Number: big.NewInt(int64(i)), Root: root, Extra: []byte{byte(j)}})
+			if err != nil {
+				t.Fatalf("failed to encode header: %v", err)
+			}
+			a.inspectHeader(blob)
+		}
+	}
+	if have := a.seenRoots.Len(); have > maxSeenRoots {
+		t.Errorf("seen roots not bounded: have %d, want at most %d", have, maxSeenRoots)
+	}
+	if have, want := uint64(a.stateRoots.count), uint64(maxSeenRoots+10); have != want {
+		t.Errorf("state root count mismatch: have %d, want %d", have, want)
+	}
+	// All the roots were iterated by a full inspection, and are moved out of the
+	// hash trie nodes
+	legacyTries := a.iteratedRoots
+	a.moveStateRoots(&legacyTries)
+	if legacyTries.count != 0 || legacyTries.size != 0 {
+		t.Errorf("hash trie nodes not moved: have %d items of %v", legacyTries.count, legacyTries.size)
+	}
+}
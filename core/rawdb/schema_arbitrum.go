@@ -19,6 +19,8 @@
 package rawdb
 
 import (
+	"encoding/binary"
+
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -40,6 +42,19 @@ var (
 	activatedAsmArmPrefix  = WasmPrefix{0x00, 'w', 'r'} // (prefix, moduleHash) -> stylus asm for ARM system
 	activatedAsmX86Prefix  = WasmPrefix{0x00, 'w', 'x'} // (prefix, moduleHash) -> stylus asm for x86 system
 	activatedAsmHostPrefix = WasmPrefix{0x00, 'w', 'h'} // (prefix, moduleHash) -> stylus asm for system other then ARM and x86
+
+	stateChangesetPrefix  = []byte("arbStateChangeset-")    // stateChangesetPrefix + num (uint64 big endian) + hash -> state changeset
+	stateChangesetTailKey = []byte("ArbStateChangesetTail") // tracks the oldest block whose state changeset is retained
+
+	onlinePruningKey = []byte("ArbOnlinePruning") // tracks the progress of the online state pruning
+
+	logIndexPrefix  = []byte("arbLogIndex-")    // logIndexPrefix + kind + address/topic + num (uint64 big endian) -> log positions
+	logIndexHeadKey = []byte("ArbLogIndexHead") // tracks the number and hash of the newest block whose logs are indexed
+	logIndexTailKey = []byte("ArbLogIndexTail") // tracks the oldest block whose logs are indexed
+
+	userOpLookupPrefix = []byte("arbUserOp-")         // userOpLookupPrefix + userOpHash -> user operation lookup entry
+	userOpIndexHeadKey = []byte("ArbUserOpIndexHead") // tracks the number and hash of the newest block whose user operations are indexed
+	userOpIndexTailKey = []byte("ArbUserOpIndexTail") // tracks the oldest block whose user operations are indexed
 )
 
 func DeprecatedPrefixesV0() (keyPrefixes [][]byte, keyLength int) {
@@ -57,3 +72,34 @@ func activatedKey(prefix WasmPrefix, moduleHash common.Hash) WasmKey {
 	copy(key[WasmPrefixLen:], moduleHash[:])
 	return key
 }
+
+// stateChangesetKey = stateChangesetPrefix + num (uint64 big endian) + hash
+func stateChangesetKey(number uint64, hash common.Hash) []byte {
+	key := make([]byte, len(stateChangesetPrefix)+8+common.HashLength)
+	n := copy(key, stateChangesetPrefix)
+	binary.BigEndian.PutUint64(key[n:], number)
+	copy(key[n+8:], hash.Bytes())
+	return key
+}
+
+// stateChangesetNumberPrefix = stateChangesetPrefix + num (uint64 big endian)
+func stateChangesetNumberPrefix(number uint64) []byte {
+	return append(append([]byte{}, stateChangesetPrefix...), encodeBlockNumber(number)...)
+}
+
+// logIndexValuePrefix = logIndexPrefix + kind + address/topic
+func logIndexValuePrefix(kind byte, value []byte) []byte {
+	key := make([]byte, 0, len(logIndexPrefix)+1+len(value)+8)
+	key = append(append(key, logIndexPrefix...), kind)
+	return append(key, value...)
+}
+
+// logIndexKey = logIndexPrefix + kind + address/topic + num (uint64 big endian)
+func logIndexKey(kind byte, value []byte, number uint64) []byte {
+	return append(logIndexValuePrefix(kind, value), encodeBlockNumber(number)...)
+}
+
+// userOpLookupKey = userOpLookupPrefix + userOpHash
+func userOpLookupKey(hash common.Hash) []byte {
+	return append(append([]byte{}, userOpLookupPrefix...), hash.Bytes()...)
+}
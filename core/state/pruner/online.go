@@ -0,0 +1,511 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"golang.org/x/time/rate"
+)
+
+// onlineRetryDelay is the time to wait before retrying a failed pruning round.
+const onlineRetryDelay = time.Minute
+
+// OnlineConfig includes all the configurations for online pruning.
+type OnlineConfig struct {
+	Interval  time.Duration // Minimum time between the end of a pruning round and the start of the next one
+	BloomSize uint64        // Megabytes of memory allocated to the bloom-filter of live state
+	MarkRate  int           // Maximum number of state entries marked per second, 0 for unlimited
+	SweepRate int           // Maximum number of database entries swept per second, 0 for unlimited
+
+	// KeepCheckpoints retains the state of every canonical block whose state
+	// is persisted, which is what a sparse archive node stores.
+	KeepCheckpoints bool
+}
+
+// OnlineChain defines the chain data needed by the online pruner.
+type OnlineChain interface {
+	// Config retrieves the chain's fork configuration.
+	Config() *params.ChainConfig
+
+	// CurrentBlock retrieves the current head header of the canonical chain.
+	CurrentBlock() *types.Header
+
+	// GetHeaderByNumber retrieves a canonical header from the database by number.
+	GetHeaderByNumber(number uint64) *types.Header
+}
+
+// onlineProgress is the progress of the online pruning persisted in the
+// database, allowing a round to continue after a restart.
+type onlineProgress struct {
+	Running  bool   // Whether a round is in progress
+	Cursor   []byte // Key to continue sweeping from
+	Finished uint64 // Unix time of the last completed round
+}
+
+// onlineCheckpoint is a canonical block whose state is persisted, retained
+// when the pruner keeps the checkpoints of a sparse archive node.
+type onlineCheckpoint struct {
+	number uint64
+	hash   common.Hash
+	root   common.Hash
+}
+
+// OnlinePruner is a background mark-and-sweep pruner of the hash-based state.
+// Unlike Pruner, it runs while the node keeps processing blocks and serving
+// requests. Every round:
+//
+//   - installs a guard on the trie database, marking every node persisted
+//     from then on as live
+//   - marks the state of the chain head, of the most recently persisted block,
+//     of the genesis block, of every block still referenced by the trie database
+//     and optionally of all sparse archive checkpoints
+//   - sweeps the trie nodes of the disk that were not marked
+//
+// The state of all other blocks is deleted. Contract codes stored with the
+// code prefix are never swept, as they're written outside of the trie database.
+//
+// The sweep cursor is persisted, so an interrupted round is resumed after a
+// restart. The marking has to be redone in that case, since the set of live
+// state entries is kept in memory only.
+type OnlinePruner struct {
+	config OnlineConfig
+	db     ethdb.Database
+	triedb *triedb.Database
+	chain  OnlineChain
+
+	markLimiter  *rate.Limiter
+	sweepLimiter *rate.Limiter
+
+	checkpoints []onlineCheckpoint // Persisted canonical states found by previous rounds
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+// NewOnlinePruner creates the online pruner and starts its background loop.
+func NewOnlinePruner(db ethdb.Database, triedb *triedb.Database, chain OnlineChain, config OnlineConfig) (*OnlinePruner, error) {
+	if triedb.Scheme() != rawdb.HashScheme {
+		return nil, errors.New("online pruning is only supported in hash scheme")
+	}
+	// Sanitize the bloom filter size if it's too small.
+	if config.BloomSize < 256 {
+		log.Warn("Sanitizing bloomfilter size", "provided(MB)", config.BloomSize, "updated(MB)", 256)
+		config.BloomSize = 256
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &OnlinePruner{
+		config:       config,
+		db:           db,
+		triedb:       triedb,
+		chain:        chain,
+		markLimiter:  newLimiter(config.MarkRate),
+		sweepLimiter: newLimiter(config.SweepRate),
+		ctx:          ctx,
+		cancel:       cancel,
+		closed:       make(chan struct{}),
+	}
+	go p.loop()
+
+	log.Info("Initialized online state pruning", "interval", config.Interval, "checkpoints", config.KeepCheckpoints)
+	return p, nil
+}
+
+// newLimiter creates a rate limiter of the given number of events per second,
+// with zero meaning unlimited.
+func newLimiter(perSecond int) *rate.Limiter {
+	if perSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), perSecond)
+}
+
+// Close terminates the pruner, waiting for the running round to be interrupted.
+// The progress of an interrupted round is kept and resumed on the next start.
+func (p *OnlinePruner) Close() {
+	p.cancel()
+	<-p.closed
+}
+
+// loop schedules the pruning rounds.
+func (p *OnlinePruner) loop() {
+	defer close(p.closed)
+
+	for {
+		var (
+			progress = p.readProgress()
+			wait     time.Duration
+		)
+		if !progress.Running && progress.Finished != 0 {
+			wait = time.Until(time.Unix(int64(progress.Finished), 0).Add(p.config.Interval))
+		}
+		select {
+		case <-time.After(wait):
+		case <-p.ctx.Done():
+			return
+		}
+		if err := p.prune(progress); err != nil {
+			if p.ctx.Err() != nil {
+				log.Info("Online state pruning interrupted", "err", err)
+				return
+			}
+			log.Warn("Online state pruning failed", "err", err)
+			select {
+			case <-time.After(onlineRetryDelay):
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// readProgress retrieves the persisted pruning progress.
+func (p *OnlinePruner) readProgress() onlineProgress {
+	var progress onlineProgress
+	if blob := rawdb.ReadOnlinePruning(p.db); len(blob) > 0 {
+		if err := rlp.DecodeBytes(blob, &progress); err != nil {
+			log.Warn("Failed to decode online pruning progress", "err", err)
+			return onlineProgress{}
+		}
+	}
+	return progress
+}
+
+// writeProgress persists the pruning progress.
+func (p *OnlinePruner) writeProgress(db ethdb.KeyValueWriter, progress onlineProgress) {
+	blob, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		log.Crit("Failed to encode online pruning progress", "err", err)
+	}
+	rawdb.WriteOnlinePruning(db, blob)
+}
+
+// liveGuard is the set of live state entries of a pruning round. It's notified
+// of the nodes persisted by the trie database while the round is running, and
+// serializes them with the deletions of the sweeper.
+type liveGuard struct {
+	bloom *stateBloom
+	lock  sync.Mutex
+}
+
+// Persisted implements hashdb.PruneGuard, marking a persisted node as live.
+func (g *liveGuard) Persisted(hash common.Hash) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.bloom.Put(hash.Bytes(), nil)
+}
+
+// prune runs a pruning round, or resumes the one in progress.
+func (p *OnlinePruner) prune(progress onlineProgress) error {
+	bloom, err := newStateBloomWithSize(p.config.BloomSize)
+	if err != nil {
+		return err
+	}
+	guard := &liveGuard{bloom: bloom}
+
+	// Install the guard before looking up the live state, so that any node
+	// persisted from now on is retained.
+	if err := p.triedb.SetPruneGuard(guard); err != nil {
+		return err
+	}
+	defer p.triedb.SetPruneGuard(nil)
+
+	start := time.Now()
+	roots, err := p.liveRoots()
+	if err != nil {
+		return err
+	}
+	log.Info("Marking live state for online pruning", "roots", len(roots), "resume", progress.Running)
+	storages := make(map[common.Hash]struct{})
+	for _, root := range roots {
+		if err := p.mark(guard, root, storages); err != nil {
+			return fmt.Errorf("failed to mark state %x: %w", root, err)
+		}
+	}
+	log.Info("Marked live state for online pruning", "roots", len(roots), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	var cursor []byte
+	if progress.Running {
+		cursor = progress.Cursor
+	}
+	return p.sweep(guard, cursor, start)
+}
+
+// liveRoots returns the state roots retained by the pruning.
+func (p *OnlinePruner) liveRoots() ([]common.Hash, error) {
+	head := p.chain.CurrentBlock()
+	if head == nil {
+		return nil, errors.New("missing head header")
+	}
+	var genesis uint64
+	if config := p.chain.Config(); config.IsArbitrum() {
+		genesis = config.ArbitrumChainParams.GenesisBlockNum
+	}
+	var (
+		roots = []common.Hash{head.Root}
+		seen  = map[common.Hash]bool{head.Root: true}
+		add   = func(root common.Hash) {
+			if !seen[root] {
+				seen[root] = true
+				roots = append(roots, root)
+			}
+		}
+	)
+	if header := p.chain.GetHeaderByNumber(genesis); header != nil {
+		add(header.Root)
+	} else {
+		return nil, fmt.Errorf("missing genesis header %d", genesis)
+	}
+	// Retain the states of the recent blocks tracked in memory. Cap may have
+	// flushed part of their nodes already, which are still needed when their
+	// state is eventually committed.
+	referenced, err := p.triedb.ReferencedRoots()
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range referenced {
+		add(root)
+	}
+	// With checkpoints, retain all the persisted states.
+	if p.config.KeepCheckpoints {
+		if err := p.updateCheckpoints(head, genesis); err != nil {
+			return nil, err
+		}
+		for _, checkpoint := range p.checkpoints {
+			add(checkpoint.root)
+		}
+		return roots, nil
+	}
+	// Otherwise retain the most recently persisted state, the chain restarts
+	// from it after a crash.
+	for number := head.Number.Uint64(); number > genesis; number-- {
+		if err := p.ctx.Err(); err != nil {
+			return nil, err
+		}
+		header := p.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, fmt.Errorf("missing header %d", number)
+		}
+		if rawdb.HasLegacyTrieNode(p.db, header.Root) {
+			add(header.Root)
+			break
+		}
+	}
+	return roots, nil
+}
+
+// updateCheckpoints extends the checkpoints found by the previous rounds with
+// the persisted states of the canonical blocks after the last one, so that
+// the headers are only walked back to genesis in the first round. Checkpoints
+// which are no longer canonical are dropped first.
+func (p *OnlinePruner) updateCheckpoints(head *types.Header, genesis uint64) error {
+	for len(p.checkpoints) > 0 {
+		last := p.checkpoints[len(p.checkpoints)-1]
+		if last.number <= head.Number.Uint64() {
+			if header := p.chain.GetHeaderByNumber(last.number); header != nil && header.Hash() == last.hash {
+				break
+			}
+		}
+		p.checkpoints = p.checkpoints[:len(p.checkpoints)-1]
+	}
+	from := genesis + 1
+	if len(p.checkpoints) > 0 {
+		from = p.checkpoints[len(p.checkpoints)-1].number + 1
+	}
+	for number := from; number <= head.Number.Uint64(); number++ {
+		if err := p.ctx.Err(); err != nil {
+			return err
+		}
+		header := p.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return fmt.Errorf("missing header %d", number)
+		}
+		if rawdb.HasLegacyTrieNode(p.db, header.Root) {
+			p.checkpoints = append(p.checkpoints, onlineCheckpoint{number: number, hash: header.Hash(), root: header.Root})
+		}
+	}
+	return nil
+}
+
+// mark adds all the trie nodes and codes of the given state to the live set.
+// The nodes are resolved through the trie database, so the state of the head
+// may still be partially in memory. Storage tries are content addressed, so
+// the ones already marked by a previous root are skipped.
+func (p *OnlinePruner) mark(guard *liveGuard, root common.Hash, storages map[common.Hash]struct{}) error {
+	tr, err := trie.New(trie.StateTrieID(root), p.triedb)
+	if err != nil {
+		return err
+	}
+	accountIt, err := tr.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	for accountIt.Next(true) {
+		if err := p.markLimiter.Wait(p.ctx); err != nil {
+			return err
+		}
+		// If the iterator hash is the empty hash, this is an embedded node
+		if hash := accountIt.Hash(); hash != (common.Hash{}) {
+			guard.Persisted(hash)
+		}
+		if !accountIt.Leaf() {
+			continue
+		}
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(accountIt.LeafBlob(), &account); err != nil {
+			return fmt.Errorf("failed to decode account data: %w", err)
+		}
+		// Contract codes of the legacy scheme are stored by hash
+		if !bytes.Equal(account.CodeHash, types.EmptyCodeHash[:]) {
+			guard.Persisted(common.BytesToHash(account.CodeHash))
+		}
+		if account.Root == types.EmptyRootHash {
+			continue
+		}
+		if _, ok := storages[account.Root]; ok {
+			continue
+		}
+		owner := common.BytesToHash(accountIt.LeafKey())
+		storageTr, err := trie.New(trie.StorageTrieID(root, owner, account.Root), p.triedb)
+		if err != nil {
+			return err
+		}
+		storageIt, err := storageTr.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+		for storageIt.Next(true) {
+			if err := p.markLimiter.Wait(p.ctx); err != nil {
+				return err
+			}
+			if hash := storageIt.Hash(); hash != (common.Hash{}) {
+				guard.Persisted(hash)
+			}
+		}
+		if err := storageIt.Error(); err != nil {
+			return err
+		}
+		storages[account.Root] = struct{}{}
+	}
+	return accountIt.Error()
+}
+
+// sweep deletes the trie nodes of the disk which are not in the live set,
+// starting from the given key. The deletions are written in batches, checking
+// the live set again while holding the guard lock, so that nodes persisted by
+// the trie database in the meantime are never deleted. The cursor is persisted
+// along every batch.
+func (p *OnlinePruner) sweep(guard *liveGuard, cursor []byte, start time.Time) error {
+	var (
+		count, skipped int
+		size           common.StorageSize
+		logged         = time.Now()
+		candidates     [][]byte
+		sizes          []common.StorageSize
+		pending        int
+		iter           = p.db.NewIterator(nil, cursor)
+	)
+	defer func() { iter.Release() }()
+
+	p.writeProgress(p.db, onlineProgress{Running: true, Cursor: cursor})
+
+	// flush deletes the collected candidates still not live and persists the
+	// sweep cursor in the same batch.
+	flush := func(next []byte) error {
+		guard.lock.Lock()
+		defer guard.lock.Unlock()
+
+		batch := p.db.NewBatch()
+		for i, key := range candidates {
+			if guard.bloom.Contain(key) {
+				skipped++
+				continue
+			}
+			batch.Delete(key)
+			count++
+			size += sizes[i]
+		}
+		p.writeProgress(batch, onlineProgress{Running: true, Cursor: next})
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		candidates, sizes, pending = candidates[:0], sizes[:0], 0
+		return nil
+	}
+	for iter.Next() {
+		if err := p.sweepLimiter.Wait(p.ctx); err != nil {
+			return err
+		}
+		key := iter.Key()
+		if len(key) != common.HashLength {
+			continue
+		}
+		if guard.bloom.Contain(key) {
+			skipped++
+			continue
+		}
+		// Only delete what is provably a trie node or a legacy contract code,
+		// the value of both hashes to the key.
+		if !rawdb.IsLegacyTrieNode(key, iter.Value()) {
+			continue
+		}
+		candidates = append(candidates, common.CopyBytes(key))
+		sizes = append(sizes, common.StorageSize(len(key)+len(iter.Value())))
+		pending += len(key)
+
+		if pending >= ethdb.IdealBatchSize {
+			next := common.CopyBytes(key)
+			if err := flush(next); err != nil {
+				return err
+			}
+			// Recreate the iterator after every batch commit in order
+			// to allow the underlying compactor to delete the entries.
+			iter.Release()
+			iter = p.db.NewIterator(nil, next)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning state data online", "nodes", count, "skipped", skipped, "size", size,
+				"elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if err := flush(nil); err != nil {
+		return err
+	}
+	p.writeProgress(p.db, onlineProgress{Finished: uint64(time.Now().Unix())})
+	log.Info("Online state pruning finished", "nodes", count, "skipped", skipped, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,298 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+// testOnlineChain is a canonical chain of headers with the given state roots.
+type testOnlineChain struct {
+	headers []*types.Header
+}
+
+func (c *testOnlineChain) Config() *params.ChainConfig { return params.TestChainConfig }
+func (c *testOnlineChain) CurrentBlock() *types.Header { return c.headers[len(c.headers)-1] }
+func (c *testOnlineChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[number]
+}
+
+// commitState writes the state of the given accounts on top of the parent state,
+// persisting it if requested.
+func commitState(t *testing.T, db ethdb.Database, tdb *triedb.Database, parent common.Hash, number uint64, accounts map[common.Address]uint64, persist bool) common.Hash {
+	statedb, err := state.New(parent, state.NewDatabaseWithNodeDB(db, tdb), nil)
+	if err != nil {
+		t.Fatalf("failed to open state %x: %v", parent, err)
+	}
+	for addr, balance := range accounts {
+		statedb.SetBalance(addr, uint256.NewInt(balance))
+		statedb.SetState(addr, common.Hash{1}, common.BigToHash(new(big.Int).SetUint64(balance)))
+	}
+	root, err := statedb.Commit(number, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if persist {
+		if err := tdb.Commit(root, false); err != nil {
+			t.Fatalf("failed to persist state: %v", err)
+		}
+	}
+	return root
+}
+
+// checkState verifies that the whole state of the given root is present, by
+// walking all its trie nodes.
+func checkState(t *testing.T, p *OnlinePruner, root common.Hash) {
+	t.Helper()
+	bloom, _ := newStateBloomWithSize(256)
+	if err := p.mark(&liveGuard{bloom: bloom}, root, make(map[common.Hash]struct{})); err != nil {
+		t.Fatalf("incomplete state %x: %v", root, err)
+	}
+}
+
+func TestOnlinePruning(t *testing.T) {
+	var (
+		db   = rawdb.NewMemoryDatabase()
+		tdb  = triedb.NewDatabase(db, triedb.HashDefaults)
+		addr = func(i byte) common.Address { return common.Address{i} }
+	)
+	// Build a chain of persisted states, the last one only kept in memory
+	var (
+		genesis = commitState(t, db, tdb, types.EmptyRootHash, 0, map[common.Address]uint64{addr(1): 1}, true)
+		stale   = commitState(t, db, tdb, genesis, 1, map[common.Address]uint64{addr(2): 2}, true)
+		recent  = commitState(t, db, tdb, stale, 2, map[common.Address]uint64{addr(3): 3}, true)
+		head    = commitState(t, db, tdb, recent, 3, map[common.Address]uint64{addr(4): 4}, false)
+		chain   = &testOnlineChain{}
+	)
+	for i, root := range []common.Hash{genesis, stale, recent, head} {
+		chain.headers = append(chain.headers, &types.Header{Number: big.NewInt(int64(i)), Root: root})
+	}
+	p := &OnlinePruner{
+		config:       OnlineConfig{BloomSize: 256},
+		db:           db,
+		triedb:       tdb,
+		chain:        chain,
+		markLimiter:  newLimiter(0),
+		sweepLimiter: newLimiter(0),
+		ctx:          context.Background(),
+	}
+	if err := p.prune(p.readProgress()); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if rawdb.HasLegacyTrieNode(db, stale) {
+		t.Fatalf("stale state root not pruned")
+	}
+	for _, root := range []common.Hash{genesis, recent, head} {
+		checkState(t, p, root)
+	}
+	if progress := p.readProgress(); progress.Running || progress.Finished == 0 {
+		t.Fatalf("unexpected progress after round: %+v", progress)
+	}
+}
+
+func TestOnlinePruningCheckpoints(t *testing.T) {
+	var (
+		db   = rawdb.NewMemoryDatabase()
+		tdb  = triedb.NewDatabase(db, triedb.HashDefaults)
+		addr = func(i byte) common.Address { return common.Address{i} }
+
+		chain = &testOnlineChain{}
+		roots []common.Hash
+		root  = types.EmptyRootHash
+	)
+	for i := 0; i < 6; i++ {
+		// Every other state is skipped, as a sparse archive does
+		root = commitState(t, db, tdb, root, uint64(i), map[common.Address]uint64{addr(byte(i)): uint64(i + 1)}, i%2 == 0)
+		roots = append(roots, root)
+		chain.headers = append(chain.headers, &types.Header{Number: big.NewInt(int64(i)), Root: root})
+	}
+	p := &OnlinePruner{
+		config:       OnlineConfig{BloomSize: 256, KeepCheckpoints: true},
+		db:           db,
+		triedb:       tdb,
+		chain:        chain,
+		markLimiter:  newLimiter(0),
+		sweepLimiter: newLimiter(0),
+		ctx:          context.Background(),
+	}
+	if err := p.prune(p.readProgress()); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	for i := 0; i < len(roots); i += 2 {
+		checkState(t, p, roots[i])
+	}
+}
+
+// Tests that nodes persisted while a round is running are never swept.
+func TestOnlinePruningGuard(t *testing.T) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		tdb   = triedb.NewDatabase(db, triedb.HashDefaults)
+		root  = commitState(t, db, tdb, types.EmptyRootHash, 0, map[common.Address]uint64{{1}: 1}, true)
+		chain = &testOnlineChain{headers: []*types.Header{{Number: big.NewInt(0), Root: root}}}
+	)
+	// Two nodes which are not part of any live state, one of them persisted
+	// again while the round is running
+	var (
+		stale    = []byte{0xc2, 0x80, 0x80}
+		repeated = []byte{0xc3, 0x80, 0x80, 0x80}
+	)
+	rawdb.WriteLegacyTrieNode(db, crypto.Keccak256Hash(stale), stale)
+	rawdb.WriteLegacyTrieNode(db, crypto.Keccak256Hash(repeated), repeated)
+
+	p := &OnlinePruner{
+		config:       OnlineConfig{BloomSize: 256},
+		db:           db,
+		triedb:       tdb,
+		chain:        chain,
+		markLimiter:  newLimiter(0),
+		sweepLimiter: newLimiter(0),
+		ctx:          context.Background(),
+	}
+	bloom, _ := newStateBloomWithSize(256)
+	guard := &liveGuard{bloom: bloom}
+	if err := p.mark(guard, root, make(map[common.Hash]struct{})); err != nil {
+		t.Fatalf("failed to mark state: %v", err)
+	}
+	tdb.SetPruneGuard(guard)
+	defer tdb.SetPruneGuard(nil)
+
+	// Persist a new state through the trie database, and the repeated node as
+	// if it was flushed by it too
+	next := commitState(t, db, tdb, root, 1, map[common.Address]uint64{{2}: 2}, true)
+	guard.Persisted(crypto.Keccak256Hash(repeated))
+
+	if err := p.sweep(guard, nil, time.Now()); err != nil {
+		t.Fatalf("failed to sweep: %v", err)
+	}
+	if rawdb.HasLegacyTrieNode(db, crypto.Keccak256Hash(stale)) {
+		t.Fatalf("stale node not pruned")
+	}
+	if !rawdb.HasLegacyTrieNode(db, crypto.Keccak256Hash(repeated)) {
+		t.Fatalf("node persisted during the round was pruned")
+	}
+	checkState(t, p, root)
+	checkState(t, p, next)
+}
+
+// Tests that the states referenced in memory are retained, even if part of
+// their nodes were already flushed to disk.
+func TestOnlinePruningReferenced(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		tdb     = triedb.NewDatabase(db, triedb.HashDefaults)
+		genesis = commitState(t, db, tdb, types.EmptyRootHash, 0, map[common.Address]uint64{{1}: 1}, true)
+		flushed = commitState(t, db, tdb, genesis, 1, map[common.Address]uint64{{2}: 2}, false)
+		head    = commitState(t, db, tdb, flushed, 2, map[common.Address]uint64{{3}: 3}, false)
+		chain   = &testOnlineChain{}
+	)
+	for i, root := range []common.Hash{genesis, flushed, head} {
+		chain.headers = append(chain.headers, &types.Header{Number: big.NewInt(int64(i)), Root: root})
+	}
+	// Flush the nodes of both in-memory states to disk, as Cap does when the
+	// dirty cache exceeds its allowance
+	tdb.Reference(flushed, common.Hash{})
+	tdb.Reference(head, common.Hash{})
+	if err := tdb.Cap(0); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	p := &OnlinePruner{
+		config:       OnlineConfig{BloomSize: 256},
+		db:           db,
+		triedb:       tdb,
+		chain:        chain,
+		markLimiter:  newLimiter(0),
+		sweepLimiter: newLimiter(0),
+		ctx:          context.Background(),
+	}
+	if err := p.prune(p.readProgress()); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	checkState(t, p, flushed)
+	if err := tdb.Commit(flushed, false); err != nil {
+		t.Fatalf("failed to persist state: %v", err)
+	}
+	tdb.Dereference(flushed)
+	checkState(t, p, flushed)
+}
+
+// Tests that checkpoints are only searched after the last one found.
+func TestOnlinePruningCheckpointsResume(t *testing.T) {
+	var (
+		db   = rawdb.NewMemoryDatabase()
+		tdb  = triedb.NewDatabase(db, triedb.HashDefaults)
+		addr = func(i byte) common.Address { return common.Address{i} }
+
+		chain = &testOnlineChain{}
+		root  = types.EmptyRootHash
+	)
+	extend := func(n int) {
+		for i := 0; i < n; i++ {
+			number := len(chain.headers)
+			root = commitState(t, db, tdb, root, uint64(number), map[common.Address]uint64{addr(byte(number)): uint64(number + 1)}, number%2 == 0)
+			chain.headers = append(chain.headers, &types.Header{Number: big.NewInt(int64(number)), Root: root})
+		}
+	}
+	extend(5)
+
+	p := &OnlinePruner{
+		config:       OnlineConfig{BloomSize: 256, KeepCheckpoints: true},
+		db:           db,
+		triedb:       tdb,
+		chain:        chain,
+		markLimiter:  newLimiter(0),
+		sweepLimiter: newLimiter(0),
+		ctx:          context.Background(),
+	}
+	if err := p.prune(p.readProgress()); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if len(p.checkpoints) != 2 || p.checkpoints[1].number != 4 {
+		t.Fatalf("unexpected checkpoints: %+v", p.checkpoints)
+	}
+	// Hide the headers before the last checkpoint, the next round must not
+	// look them up again
+	extend(4)
+	for i := 1; i < 4; i++ {
+		chain.headers[i] = nil
+	}
+	if err := p.prune(p.readProgress()); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if len(p.checkpoints) != 4 || p.checkpoints[3].number != 8 {
+		t.Fatalf("unexpected checkpoints: %+v", p.checkpoints)
+	}
+	for _, checkpoint := range p.checkpoints {
+		checkState(t, p, checkpoint.root)
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// ExportVersion is the version of the state export format.
+const ExportVersion = 1
+
+// Record kinds of the state export format.
+const (
+	ExportAccount = byte(iota) // account hash and slim account RLP
+	ExportStorage              // slot hash and value of the last exported account
+	ExportCode                 // code hash and contract code
+)
+
+// ExportRecord is a single entry of an exported state stream. A stream is the
+// concatenation of RLP encoded records: every account is followed by its code,
+// unless already exported, and its storage slots, all in hash order.
+type ExportRecord struct {
+	Kind byte
+	Hash common.Hash
+	Data []byte
+}
+
+// ExportCursor is the position of the next entry to export.
+type ExportCursor struct {
+	Account common.Hash  `json:"account"`
+	Storage *common.Hash `json:"storage,omitempty"` // next slot of Account, nil to start with the account itself
+}
+
+// ExportStats counts the entries of an exported state stream.
+type ExportStats struct {
+	Accounts uint64 `json:"accounts"`
+	Slots    uint64 `json:"slots"`
+	Codes    uint64 `json:"codes"`
+}
+
+// StateExporter streams the state of a snapshot layer in the export format,
+// split into chunks of a bounded size.
+type StateExporter struct {
+	tree  *Tree
+	root  common.Hash
+	db    ethdb.KeyValueReader
+	codes map[common.Hash]struct{} // codes already exported
+}
+
+// NewStateExporter creates an exporter for the state with the given root. The
+// contract codes are read from db, each of them exported only once.
+func NewStateExporter(tree *Tree, root common.Hash, db ethdb.KeyValueReader) *StateExporter {
+	return &StateExporter{
+		tree:  tree,
+		root:  root,
+		db:    db,
+		codes: make(map[common.Hash]struct{}),
+	}
+}
+
+// ExportChunk writes the state starting at the given cursor into w, until the
+// chunk grows beyond limit bytes. It returns the cursor to resume the export
+// from, or nil if the whole state was exported.
+//
+// A chunk which starts in the middle of the storage of an account repeats the
+// account record, so every chunk can be decoded on its own.
+func (e *StateExporter) ExportChunk(w io.Writer, start ExportCursor, limit uint64) (*ExportCursor, ExportStats, error) {
+	var (
+		stats   ExportStats
+		written uint64
+	)
+	write := func(kind byte, hash common.Hash, data []byte) error {
+		blob, err := rlp.EncodeToBytes(&ExportRecord{Kind: kind, Hash: hash, Data: data})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(blob); err != nil {
+			return err
+		}
+		written += uint64(len(blob))
+		return nil
+	}
+	acctIt, err := e.tree.AccountIterator(e.root, start.Account)
+	if err != nil {
+		return nil, stats, err
+	}
+	defer acctIt.Release()
+
+	for acctIt.Next() {
+		hash, blob := acctIt.Hash(), acctIt.Account()
+		if written >= limit {
+			return &ExportCursor{Account: hash}, stats, nil
+		}
+		account, err := types.FullAccount(blob)
+		if err != nil {
+			return nil, stats, err
+		}
+		if err := write(ExportAccount, hash, blob); err != nil {
+			return nil, stats, err
+		}
+		resumed := start.Storage != nil && hash == start.Account
+		if !resumed {
+			stats.Accounts++
+		}
+
+		if codeHash := common.BytesToHash(account.CodeHash); codeHash != types.EmptyCodeHash {
+			if _, ok := e.codes[codeHash]; !ok {
+				code := rawdb.ReadCode(e.db, codeHash)
+				if len(code) == 0 {
+					return nil, stats, fmt.Errorf("missing code %x of account %x", codeHash, hash)
+				}
+				if err := write(ExportCode, codeHash, code); err != nil {
+					return nil, stats, err
+				}
+				e.codes[codeHash] = struct{}{}
+				stats.Codes++
+			}
+		}
+		if account.Root != types.EmptyRootHash {
+			var seek common.Hash
+			if resumed {
+				seek = *start.Storage
+			}
+			storageIt, err := e.tree.StorageIterator(e.root, hash, seek)
+			if err != nil {
+				return nil, stats, err
+			}
+			// At least one slot is exported after a repeated account record,
+			// so that every chunk makes progress.
+			for slots := 0; storageIt.Next(); slots++ {
+				if written >= limit && slots > 0 {
+					slot := storageIt.Hash()
+					storageIt.Release()
+					return &ExportCursor{Account: hash, Storage: &slot}, stats, nil
+				}
+				if err := write(ExportStorage, storageIt.Hash(), storageIt.Slot()); err != nil {
+					storageIt.Release()
+					return nil, stats, err
+				}
+				stats.Slots++
+			}
+			err = storageIt.Error()
+			storageIt.Release()
+			if err != nil {
+				return nil, stats, err
+			}
+		}
+	}
+	return nil, stats, acctIt.Error()
+}
+
+// StateImporter rebuilds the tries of an exported state stream and writes
+// them, along with the contract codes, into a database.
+type StateImporter struct {
+	db     ethdb.KeyValueStore
+	batch  ethdb.Batch
+	scheme string
+
+	accountTrie *trie.StackTrie
+	storageTrie *trie.StackTrie
+
+	account     *types.StateAccount // account whose storage is being imported
+	accountHash common.Hash
+	accountBlob []byte
+	lastSlot    *common.Hash
+
+	codes   map[common.Hash]struct{}    // codes imported so far
+	missing map[common.Hash]common.Hash // referenced codes not yet imported, mapped to the first account using them
+	stats   ExportStats
+}
+
+// NewStateImporter creates an importer writing the state into db, using the
+// given state scheme.
+func NewStateImporter(db ethdb.KeyValueStore, scheme string) *StateImporter {
+	imp := &StateImporter{
+		db:      db,
+		batch:   db.NewBatch(),
+		scheme:  scheme,
+		codes:   make(map[common.Hash]struct{}),
+		missing: make(map[common.Hash]common.Hash),
+	}
+	imp.accountTrie = trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+		rawdb.WriteTrieNode(imp.batch, common.Hash{}, path, hash, blob, scheme)
+	})
+	return imp
+}
+
+// Import reads all records of an exported state stream from r. The streams of
+// consecutive chunks must be imported in order.
+func (imp *StateImporter) Import(r io.Reader) error {
+	stream := rlp.NewStream(r, 0)
+	for {
+		var record ExportRecord
+		if err := stream.Decode(&record); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := imp.importRecord(&record); err != nil {
+			return err
+		}
+		if imp.batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := imp.batch.Write(); err != nil {
+				return err
+			}
+			imp.batch.Reset()
+		}
+	}
+}
+
+func (imp *StateImporter) importRecord(record *ExportRecord) error {
+	switch record.Kind {
+	case ExportAccount:
+		if imp.account != nil {
+			if record.Hash == imp.accountHash {
+				// The account is repeated at the start of a chunk splitting its storage
+				if !bytes.Equal(record.Data, imp.accountBlob) {
+					return fmt.Errorf("account %x changed between chunks", record.Hash)
+				}
+				return nil
+			}
+			if bytes.Compare(record.Hash[:], imp.accountHash[:]) <= 0 {
+				return fmt.Errorf("account %x out of order", record.Hash)
+			}
+			if err := imp.finishAccount(); err != nil {
+				return err
+			}
+		}
+		account, err := types.FullAccount(record.Data)
+		if err != nil {
+			return fmt.Errorf("invalid account %x: %w", record.Hash, err)
+		}
+		if codeHash := common.BytesToHash(account.CodeHash); codeHash != types.EmptyCodeHash {
+			if _, ok := imp.codes[codeHash]; !ok && !rawdb.HasCode(imp.db, codeHash) {
+				if _, ok := imp.missing[codeHash]; !ok {
+					imp.missing[codeHash] = record.Hash
+				}
+			}
+		}
+		imp.account, imp.accountHash, imp.accountBlob, imp.lastSlot = account, record.Hash, common.CopyBytes(record.Data), nil
+		if account.Root != types.EmptyRootHash {
+			owner := record.Hash
+			imp.storageTrie = trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+				rawdb.WriteTrieNode(imp.batch, owner, path, hash, blob, imp.scheme)
+			})
+		}
+		imp.stats.Accounts++
+
+	case ExportStorage:
+		if imp.account == nil || imp.storageTrie == nil {
+			return fmt.Errorf("storage slot %x without contract", record.Hash)
+		}
+		if imp.lastSlot != nil && bytes.Compare(record.Hash[:], imp.lastSlot[:]) <= 0 {
+			return fmt.Errorf("storage slot %x of account %x out of order", record.Hash, imp.accountHash)
+		}
+		if err := imp.storageTrie.Update(record.Hash[:], record.Data); err != nil {
+			return err
+		}
+		slot := record.Hash
+		imp.lastSlot = &slot
+		imp.stats.Slots++
+
+	case ExportCode:
+		if hash := crypto.Keccak256Hash(record.Data); hash != record.Hash {
+			return fmt.Errorf("code hash mismatch: have %x, want %x", hash, record.Hash)
+		}
+		rawdb.WriteCode(imp.batch, record.Hash, record.Data)
+		imp.codes[record.Hash] = struct{}{}
+		delete(imp.missing, record.Hash)
+		imp.stats.Codes++
+
+	default:
+		return fmt.Errorf("unknown record kind %d", record.Kind)
+	}
+	return nil
+}
+
+// finishAccount verifies the storage root of the current account and inserts
+// it into the account trie.
+func (imp *StateImporter) finishAccount() error {
+	if imp.storageTrie != nil {
+		if root := imp.storageTrie.Hash(); root != imp.account.Root {
+			return fmt.Errorf("storage root mismatch of account %x: have %x, want %x", imp.accountHash, root, imp.account.Root)
+		}
+		imp.storageTrie = nil
+	}
+	blob, err := types.FullAccountRLP(imp.accountBlob)
+	if err != nil {
+		return err
+	}
+	if err := imp.accountTrie.Update(imp.accountHash[:], blob); err != nil {
+		return err
+	}
+	imp.account = nil
+	return nil
+}
+
+// Finish completes the import, checking that the rebuilt state matches the
+// given root and that every referenced contract code is present. The nodes
+// written before a failed verification are left dangling in the database.
+func (imp *StateImporter) Finish(root common.Hash) (ExportStats, error) {
+	if imp.account != nil {
+		if err := imp.finishAccount(); err != nil {
+			return imp.stats, err
+		}
+	}
+	if have := imp.accountTrie.Hash(); have != root {
+		return imp.stats, fmt.Errorf("state root mismatch: have %x, want %x", have, root)
+	}
+	for codeHash, account := range imp.missing {
+		return imp.stats, fmt.Errorf("missing code %x of account %x", codeHash, account)
+	}
+	if err := imp.batch.Write(); err != nil {
+		return imp.stats, err
+	}
+	imp.batch.Reset()
+	return imp.stats, nil
+}
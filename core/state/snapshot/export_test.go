@@ -0,0 +1,154 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+// Tests that a state exported in chunks of any size is rebuilt by the importer.
+func TestStateExportImport(t *testing.T) {
+	testStateExportImport(t, rawdb.HashScheme)
+	testStateExportImport(t, rawdb.PathScheme)
+}
+
+func testStateExportImport(t *testing.T, scheme string) {
+	var (
+		helper = newHelper(scheme)
+		code   = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+		keys   = []string{"key-1", "key-2", "key-3", "key-4"}
+		vals   = []string{"val-1", "val-2", "val-3", "val-4"}
+	)
+	rawdb.WriteCode(helper.diskdb, crypto.Keccak256Hash(code), code)
+
+	for i, name := range []string{"acc-1", "acc-2", "acc-3", "acc-4"} {
+		account := &types.StateAccount{Balance: uint256.NewInt(uint64(i)), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()}
+		if i%2 == 0 {
+			// Contracts sharing the same code, with some storage
+			account.Root = helper.makeStorageTrie(hashData([]byte(name)), keys, vals, true)
+			account.CodeHash = crypto.Keccak256(code)
+			helper.addSnapStorage(name, keys, vals)
+		}
+		helper.addAccount(name, account)
+	}
+	root := helper.Commit()
+	snaps := &Tree{
+		layers: map[common.Hash]snapshot{
+			root: &diskLayer{
+				diskdb: helper.diskdb,
+				cache:  fastcache.New(500 * 1024),
+				root:   root,
+			},
+		},
+	}
+	for _, limit := range []uint64{1, 100, 1024 * 1024} {
+		var (
+			exporter = NewStateExporter(snaps, root, helper.diskdb)
+			db       = rawdb.NewMemoryDatabase()
+			importer = NewStateImporter(db, scheme)
+			cursor   = &ExportCursor{}
+			chunks   int
+			exported ExportStats
+		)
+		for cursor != nil {
+			var (
+				buf   bytes.Buffer
+				err   error
+				stats ExportStats
+			)
+			cursor, stats, err = exporter.ExportChunk(&buf, *cursor, limit)
+			if err != nil {
+				t.Fatalf("limit %d: failed to export chunk %d: %v", limit, chunks, err)
+			}
+			exported.Accounts += stats.Accounts
+			exported.Slots += stats.Slots
+			exported.Codes += stats.Codes
+			if err := importer.Import(&buf); err != nil {
+				t.Fatalf("limit %d: failed to import chunk %d: %v", limit, chunks, err)
+			}
+			chunks++
+		}
+		stats, err := importer.Finish(root)
+		if err != nil {
+			t.Fatalf("limit %d: failed to finish import: %v", limit, err)
+		}
+		if want := (ExportStats{Accounts: 4, Slots: 8, Codes: 1}); stats != want || exported != want {
+			t.Fatalf("limit %d: unexpected stats: exported %+v, imported %+v, want %+v", limit, exported, stats, want)
+		}
+		if limit == 1 && chunks != 10 {
+			t.Fatalf("limit %d: chunk count mismatch: have %d, want %d", limit, chunks, 10)
+		}
+		// Iterate the rebuilt state to ensure it's complete
+		config := &triedb.Config{HashDB: &hashdb.Config{}}
+		if scheme == rawdb.PathScheme {
+			config = &triedb.Config{PathDB: &pathdb.Config{}}
+		}
+		tdb := triedb.NewDatabase(db, config)
+		accTrie, err := trie.New(trie.StateTrieID(root), tdb)
+		if err != nil {
+			t.Fatalf("limit %d: failed to open rebuilt state: %v", limit, err)
+		}
+		nodeIt, err := accTrie.NodeIterator(nil)
+		if err != nil {
+			t.Fatalf("limit %d: failed to iterate rebuilt state: %v", limit, err)
+		}
+		for nodeIt.Next(true) {
+		}
+		if err := nodeIt.Error(); err != nil {
+			t.Fatalf("limit %d: incomplete rebuilt state: %v", limit, err)
+		}
+		if !rawdb.HasCode(db, crypto.Keccak256Hash(code)) {
+			t.Fatalf("limit %d: code not imported", limit)
+		}
+	}
+}
+
+// Tests that the importer rejects a state not matching the expected root.
+func TestStateImportRootMismatch(t *testing.T) {
+	helper := newHelper(rawdb.HashScheme)
+	helper.addAccount("acc-1", &types.StateAccount{Balance: uint256.NewInt(1), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()})
+	root := helper.Commit()
+
+	snaps := &Tree{
+		layers: map[common.Hash]snapshot{
+			root: &diskLayer{diskdb: helper.diskdb, cache: fastcache.New(500 * 1024), root: root},
+		},
+	}
+	var buf bytes.Buffer
+	if _, _, err := NewStateExporter(snaps, root, helper.diskdb).ExportChunk(&buf, ExportCursor{}, 1024); err != nil {
+		t.Fatalf("failed to export state: %v", err)
+	}
+	importer := NewStateImporter(rawdb.NewMemoryDatabase(), rawdb.HashScheme)
+	if err := importer.Import(&buf); err != nil {
+		t.Fatalf("failed to import state: %v", err)
+	}
+	if _, err := importer.Finish(common.Hash{1}); err == nil {
+		t.Fatalf("expected root mismatch error")
+	}
+}
@@ -726,6 +726,8 @@ func (s *StateDB) Copy() *StateDB {
 			recentWasms:            s.arbExtraData.recentWasms.Copy(),
 			openWasmPages:          s.arbExtraData.openWasmPages,
 			everWasmPages:          s.arbExtraData.everWasmPages,
+			recordChangeset:        s.arbExtraData.recordChangeset,
+			irregularStateBlock:    s.arbExtraData.irregularStateBlock,
 		},
 
 		db:                   s.db,
@@ -1376,6 +1378,10 @@ func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, er
 			s.onCommit(set)
 		}
 	}
+	// Arbitrum: capture the block changeset before the origin sets are dropped
+	if s.arbExtraData.recordChangeset {
+		s.arbExtraData.changeset = s.collectChangeset(block)
+	}
 	// Clear all internal flags at the end of commit operation.
 	s.accounts = make(map[common.Hash][]byte)
 	s.storages = make(map[common.Hash]map[common.Hash][]byte)
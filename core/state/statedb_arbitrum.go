@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"errors"
 	"runtime"
@@ -28,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -162,6 +164,19 @@ type ArbitrumExtraData struct {
 	everWasmPages          uint16                        // largest number of pages ever allocated during this tx's execution
 	activatedWasms         map[common.Hash]ActivatedWasm // newly activated WASMs
 	recentWasms            RecentWasms
+	recordChangeset        bool                  // whether Commit should capture the state changeset
+	changeset              *types.StateChangeset // changeset captured by the last Commit
+	irregularStateBlock    uint64                // block whose irregular state changes were made, 0 if none
+}
+
+// MarkIrregularStateChanges records that the irregular state changes of the
+// block are made to the state, reporting false if they already were.
+func (s *StateDB) MarkIrregularStateChanges(number uint64) bool {
+	if s.arbExtraData.irregularStateBlock == number {
+		return false
+	}
+	s.arbExtraData.irregularStateBlock = number
+	return true
 }
 
 func (s *StateDB) SetArbFinalizer(f func(*ArbitrumExtraData)) {
@@ -191,6 +206,130 @@ func (s *StateDB) GetSelfDestructs() []common.Address {
 	return selfDestructs
 }
 
+// EnableChangesetRecording makes the next Commit capture the set of accounts
+// and storage slots mutated since the state was opened. The result can be
+// retrieved with Changeset after the commit.
+func (s *StateDB) EnableChangesetRecording() {
+	s.arbExtraData.recordChangeset = true
+}
+
+// Changeset returns the state changeset captured by the last Commit, or nil if
+// recording was not enabled.
+func (s *StateDB) Changeset() *types.StateChangeset {
+	return s.arbExtraData.changeset
+}
+
+// collectChangeset assembles the block changeset out of the origin sets tracked
+// for the state history. It must be called by Commit before they are cleared.
+//
+// Note, in hash scheme the storage of destructed accounts isn't enumerated, so
+// only the slots explicitly written to are reported for them.
+func (s *StateDB) collectChangeset(block uint64) *types.StateChangeset {
+	addresses := make(map[common.Address]struct{}, len(s.accountsOrigin)+len(s.stateObjectsDestruct))
+	for addr := range s.accountsOrigin {
+		addresses[addr] = struct{}{}
+	}
+	for addr := range s.stateObjectsDestruct {
+		addresses[addr] = struct{}{}
+	}
+	for addr := range s.storagesOrigin {
+		addresses[addr] = struct{}{}
+	}
+	changeset := &types.StateChangeset{
+		BlockNumber: block,
+		Accounts:    make([]*types.AccountChange, 0, len(addresses)),
+	}
+	for addr := range addresses {
+		change := &types.AccountChange{
+			Address:      addr,
+			PrevBalance:  new(big.Int),
+			Balance:      new(big.Int),
+			PrevCodeHash: types.EmptyCodeHash,
+			CodeHash:     types.EmptyCodeHash,
+		}
+		// Resolve the account as it was before the block
+		var prev *types.StateAccount
+		if blob, ok := s.accountsOrigin[addr]; ok {
+			if blob != nil {
+				account, err := types.FullAccount(blob)
+				if err != nil {
+					log.Error("Failed to decode original account", "address", addr, "err", err)
+					continue
+				}
+				prev = account
+			}
+		} else if account, ok := s.stateObjectsDestruct[addr]; ok {
+			prev = account
+		} else if obj := s.stateObjects[addr]; obj != nil {
+			prev = obj.origin
+		}
+		if prev != nil {
+			change.PrevBalance = prev.Balance.ToBig()
+			change.PrevNonce = prev.Nonce
+			change.PrevCodeHash = common.BytesToHash(prev.CodeHash)
+		}
+		// Resolve the account as it is after the block
+		obj := s.stateObjects[addr]
+		if obj == nil || obj.deleted {
+			if prev == nil {
+				continue // created and destructed within the block
+			}
+			change.Deleted = true
+		} else {
+			change.Balance = obj.data.Balance.ToBig()
+			change.Nonce = obj.data.Nonce
+			change.CodeHash = common.BytesToHash(obj.data.CodeHash)
+		}
+		// Collect the mutated slots, the origin set is keyed by slot hash so the
+		// raw keys are recovered from the object's storage cache
+		if origin := s.storagesOrigin[addr]; len(origin) > 0 && obj != nil {
+			addrHash := crypto.Keccak256Hash(addr.Bytes())
+			for key := range obj.originStorage {
+				khash := crypto.Keccak256Hash(key.Bytes())
+				prevBlob, ok := origin[khash]
+				if !ok {
+					continue
+				}
+				slot := &types.StorageChange{
+					Slot: key,
+					Prev: decodeStorageValue(prevBlob),
+				}
+				if !change.Deleted {
+					slot.Value = decodeStorageValue(s.storages[addrHash][khash])
+				}
+				if slot.Prev != slot.Value {
+					change.Storage = append(change.Storage, slot)
+				}
+			}
+			sort.Slice(change.Storage, func(i, j int) bool {
+				return bytes.Compare(change.Storage[i].Slot[:], change.Storage[j].Slot[:]) < 0
+			})
+		}
+		if !change.Deleted && len(change.Storage) == 0 && change.PrevNonce == change.Nonce &&
+			change.PrevBalance.Cmp(change.Balance) == 0 && change.PrevCodeHash == change.CodeHash && prev != nil {
+			continue // touched but not modified
+		}
+		changeset.Accounts = append(changeset.Accounts, change)
+	}
+	sort.Slice(changeset.Accounts, func(i, j int) bool {
+		return bytes.Compare(changeset.Accounts[i].Address[:], changeset.Accounts[j].Address[:]) < 0
+	})
+	return changeset
+}
+
+// decodeStorageValue decodes a prefix-zero trimmed rlp storage value, as kept
+// in the origin sets. A nil blob stands for a missing slot.
+func decodeStorageValue(blob []byte) common.Hash {
+	if len(blob) == 0 {
+		return common.Hash{}
+	}
+	_, content, _, err := rlp.Split(blob)
+	if err != nil {
+		return common.Hash{}
+	}
+	return common.BytesToHash(content)
+}
+
 // making the function public to be used by external tests
 func ForEachStorage(s *StateDB, addr common.Address, cb func(key, value common.Hash) bool) error {
 	return forEachStorage(s, addr, cb)
@@ -242,6 +242,9 @@ type StateTransition struct {
 	initialGas   uint64
 	state        vm.StateDB
 	evm          *vm.EVM
+
+	kintoFailOpen    error                    // failure of the AppRegistry call the Kinto rules let the message through on
+	kintoAppRegistry *KintoAppRegistryVerdict // answer of the AppRegistry to the Kinto rules, nil if not called
 }
 
 // NewStateTransition initialises and returns a new state transition object.
@@ -16,6 +16,14 @@
 
 package types
 
+import "bytes"
+
 func (r *Receipt) GasUsedForL2() uint64 {
 	return r.GasUsed - r.GasUsedForL1
 }
+
+// IsArbitrumLegacyReceiptStatus reports whether the status field of a stored
+// receipt marks it as being in the Arbitrum classic encoding.
+func IsArbitrumLegacyReceiptStatus(status []byte) bool {
+	return bytes.Equal(status, receiptRootArbitrumLegacy)
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+//go:generate go run github.com/fjl/gencodec -type StateChangeset -field-override stateChangesetMarshaling -out gen_state_changeset_json.go
+//go:generate go run github.com/fjl/gencodec -type AccountChange -field-override accountChangeMarshaling -out gen_account_change_json.go
+
+// StateChangeset is the compact set of state mutations made by a single block.
+// Only fields that are relevant for indexers are tracked: balance, nonce and
+// code hash of every touched account, plus the old and new value of every
+// storage slot modified in the block.
+type StateChangeset struct {
+	BlockNumber uint64           `json:"blockNumber"`
+	BlockHash   common.Hash      `json:"blockHash"`
+	Accounts    []*AccountChange `json:"accounts"`
+}
+
+// field type overrides for gencodec
+type stateChangesetMarshaling struct {
+	BlockNumber hexutil.Uint64
+}
+
+// AccountChange describes the mutation of a single account within a block.
+// Accounts that did not exist before the block have zero values in their
+// Prev* fields, accounts deleted by the block have Deleted set.
+type AccountChange struct {
+	Address      common.Address   `json:"address"`
+	Deleted      bool             `json:"deleted"`
+	PrevBalance  *big.Int         `json:"prevBalance"`
+	Balance      *big.Int         `json:"balance"`
+	PrevNonce    uint64           `json:"prevNonce"`
+	Nonce        uint64           `json:"nonce"`
+	PrevCodeHash common.Hash      `json:"prevCodeHash"`
+	CodeHash     common.Hash      `json:"codeHash"`
+	Storage      []*StorageChange `json:"storage"`
+}
+
+// field type overrides for gencodec
+type accountChangeMarshaling struct {
+	PrevBalance *hexutil.Big
+	Balance     *hexutil.Big
+	PrevNonce   hexutil.Uint64
+	Nonce       hexutil.Uint64
+}
+
+// StorageChange is the old and new value of a single modified storage slot.
+type StorageChange struct {
+	Slot  common.Hash `json:"slot"`
+	Prev  common.Hash `json:"prev"`
+	Value common.Hash `json:"value"`
+}
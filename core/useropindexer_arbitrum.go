@@ -0,0 +1,173 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// UserOperationEventTopic is the topic of the UserOperationEvent emitted by the
+// ERC-4337 EntryPoint v0.6 and v0.7 for every executed user operation.
+var UserOperationEventTopic = crypto.Keccak256Hash([]byte("UserOperationEvent(bytes32,address,address,uint256,bool,uint256,uint256)"))
+
+// UserOpEntryPoints returns the addresses of the EntryPoints whose user
+// operations are indexed.
+func UserOpEntryPoints(config *params.ChainConfig) []common.Address {
+	contracts := config.Kinto().Contracts
+	return []common.Address{contracts.EntryPoint, contracts.EntryPointV7}
+}
+
+// UserOpIndexer maintains the index of the ERC-4337 user operations included in
+// the canonical chain, mapping the user operation hashes to their decoded
+// UserOperationEvent. Like the LogIndexer, it indexes new blocks as the chain
+// head moves and unindexes the blocks dropped by reorgs, older blocks being
+// indexed by IndexUserOps.
+type UserOpIndexer struct {
+	*canonicalIndexer
+}
+
+// NewUserOpIndexer starts indexing the user operations of the given chain.
+func NewUserOpIndexer(chain *BlockChain) *UserOpIndexer {
+	return &UserOpIndexer{newCanonicalIndexer(chain, userOpIndex{UserOpEntryPoints(chain.Config())})}
+}
+
+// Lookup returns the indexed UserOperationEvent of the user operation, nil if
+// it isn't indexed.
+func (indexer *UserOpIndexer) Lookup(hash common.Hash) *rawdb.UserOpLookupEntry {
+	return rawdb.ReadUserOpLookupEntry(indexer.db, hash)
+}
+
+// UserOpIndexRange returns the range of blocks whose user operations are
+// indexed in the database, reporting whether any block is indexed.
+func UserOpIndexRange(db ethdb.KeyValueReader) (uint64, uint64, bool) {
+	return indexRange(db, userOpIndex{})
+}
+
+// IndexUserOps indexes the user operations of the canonical blocks from the
+// given number up to the tail of the index, or up to the chain head if the
+// index doesn't exist yet. It's meant to backfill the index for existing
+// history while the node is not running.
+func IndexUserOps(db ethdb.Database, config *params.ChainConfig, from uint64, interrupt chan struct{}) error {
+	return backfillIndex(db, userOpIndex{UserOpEntryPoints(config)}, from, interrupt)
+}
+
+// userOpIndex is the canonicalIndex of the user operations executed by the
+// EntryPoints.
+type userOpIndex struct {
+	entryPoints []common.Address
+}
+
+func (userOpIndex) name() string { return "user operations" }
+
+func (userOpIndex) readHead(db ethdb.KeyValueReader) (uint64, common.Hash, bool) {
+	return rawdb.ReadUserOpIndexHead(db)
+}
+
+func (userOpIndex) writeHead(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	rawdb.WriteUserOpIndexHead(db, number, hash)
+}
+
+func (userOpIndex) readTail(db ethdb.KeyValueReader) *uint64 {
+	return rawdb.ReadUserOpIndexTail(db)
+}
+
+func (userOpIndex) writeTail(db ethdb.KeyValueWriter, number uint64) {
+	rawdb.WriteUserOpIndexTail(db, number)
+}
+
+func (index userOpIndex) index(db ethdb.Reader, batch ethdb.KeyValueWriter, number uint64, hash common.Hash, backfill bool) {
+	for opHash, entry := range userOpEvents(db, index.entryPoints, hash, number) {
+		// Don't overwrite the newer inclusions of the same operation
+		if backfill && rawdb.ReadUserOpLookupEntry(db, opHash) != nil {
+			continue
+		}
+		rawdb.WriteUserOpLookupEntry(batch, opHash, entry)
+	}
+}
+
+// unindex deletes the entries of the block's operations. The operations
+// included again by the new chain are indexed again afterwards.
+func (index userOpIndex) unindex(db ethdb.Reader, batch ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	for opHash := range userOpEvents(db, index.entryPoints, hash, number) {
+		if entry := rawdb.ReadUserOpLookupEntry(db, opHash); entry != nil && entry.BlockHash == hash {
+			rawdb.DeleteUserOpLookupEntry(batch, opHash)
+		}
+	}
+}
+
+// userOpEvents decodes the UserOperationEvents emitted by the EntryPoints in the
+// block, by user operation hash.
+func userOpEvents(db ethdb.Reader, entryPoints []common.Address, hash common.Hash, number uint64) map[common.Hash]*rawdb.UserOpLookupEntry {
+	var (
+		events   map[common.Hash]*rawdb.UserOpLookupEntry
+		body     *types.Body
+		logIndex uint64
+	)
+	for txIndex, logs := range rawdb.ReadLogs(db, hash, number) {
+		for _, l := range logs {
+			logIndex++
+			if len(l.Topics) != 4 || l.Topics[0] != UserOperationEventTopic || len(l.Data) != 4*32 || !isEntryPoint(entryPoints, l.Address) {
+				continue
+			}
+			if body == nil {
+				if body = rawdb.ReadBody(db, hash, number); body == nil {
+					log.Error("Missing body of indexed block", "number", number, "hash", hash)
+					return nil
+				}
+			}
+			if txIndex >= len(body.Transactions) {
+				log.Error("Transaction and receipt count mismatch", "number", number, "hash", hash)
+				return nil
+			}
+			if events == nil {
+				events = make(map[common.Hash]*rawdb.UserOpLookupEntry)
+			}
+			events[l.Topics[1]] = &rawdb.UserOpLookupEntry{
+				BlockNumber:   number,
+				BlockHash:     hash,
+				TxHash:        body.Transactions[txIndex].Hash(),
+				TxIndex:       uint64(txIndex),
+				LogIndex:      logIndex - 1,
+				EntryPoint:    l.Address,
+				Sender:        common.BytesToAddress(l.Topics[2].Bytes()),
+				Paymaster:     common.BytesToAddress(l.Topics[3].Bytes()),
+				Nonce:         new(big.Int).SetBytes(l.Data[:32]),
+				Success:       new(big.Int).SetBytes(l.Data[32:64]).Sign() != 0,
+				ActualGasCost: new(big.Int).SetBytes(l.Data[64:96]),
+				ActualGasUsed: new(big.Int).SetBytes(l.Data[96:128]),
+			}
+		}
+	}
+	return events
+}
+
+func isEntryPoint(entryPoints []common.Address, address common.Address) bool {
+	for _, entryPoint := range entryPoints {
+		if address == entryPoint {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// TestUserOpIndexer tests that the user operation index follows the canonical
+// chain through reorgs, and that IndexUserOps backfills an existing chain.
+func TestUserOpIndexer(t *testing.T) {
+	var (
+		key, _      = crypto.GenerateKey()
+		address     = crypto.PubkeyToAddress(key.PublicKey)
+		entryPoints = UserOpEntryPoints(params.TestChainConfig)
+	)
+	// MSTORE(32, 1) LOG4(0, 128, UserOperationEvent, NUMBER, CALLER, 0):
+	// a successful operation whose hash is the block number
+	code := append(append(common.FromHex("0x6001602052600033437f"), UserOperationEventTopic.Bytes()...), common.FromHex("0x60806000a4")...)
+
+	testCanonicalIndexer(t, userOpIndex{entryPoints}, key, entryPoints[0], code, func(db ethdb.Database, from, to uint64, called func(number uint64) bool) {
+		t.Helper()
+		for number := from; number <= to; number++ {
+			entry := rawdb.ReadUserOpLookupEntry(db, common.BigToHash(new(big.Int).SetUint64(number)))
+			if (entry != nil) != called(number) {
+				t.Fatalf("block %d: index presence mismatch: have %v, want %v", number, entry != nil, called(number))
+			}
+			if entry == nil {
+				continue
+			}
+			if entry.BlockNumber != number || entry.BlockHash != rawdb.ReadCanonicalHash(db, number) {
+				t.Fatalf("block %d: entry of block #%d [%x]", number, entry.BlockNumber, entry.BlockHash)
+			}
+			if entry.EntryPoint != entryPoints[0] || entry.Sender != address || !entry.Success || entry.TxIndex != 0 || entry.LogIndex != 0 {
+				t.Fatalf("block %d: unexpected entry %+v", number, entry)
+			}
+			if body := rawdb.ReadBody(db, entry.BlockHash, number); entry.TxHash != body.Transactions[0].Hash() {
+				t.Fatalf("block %d: transaction hash mismatch", number)
+			}
+		}
+	})
+}
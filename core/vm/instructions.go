@@ -817,15 +817,13 @@ func opSelfdestruct(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext
 		return nil, ErrWriteProtection
 	}
 
-	// Declare the beneficiary variable outside the if-else block
-	var beneficiary common.Address
+	beneficiaryAddr := scope.Stack.pop()
+	requested := common.BytesToAddress(beneficiaryAddr.Bytes())
 
 	// Determine the beneficiary based on the block number
-	if interpreter.evm.Context.BlockNumber.Cmp(common.KintoHardfork2) > 0 {
-		beneficiary = common.HexToAddress(common.SelfDestructWallet)
-	} else {
-		beneficiaryAddr := scope.Stack.pop()
-		beneficiary = common.BytesToAddress(beneficiaryAddr.Bytes())
+	beneficiary := requested
+	if kinto := interpreter.evm.chainConfig.Kinto(); kinto.Hardfork(interpreter.evm.Context.BlockNumber) >= 2 {
+		beneficiary = kinto.SelfDestructWallet
 	}
 
 	balance := interpreter.evm.StateDB.GetBalance(scope.Contract.Address())
@@ -841,6 +839,7 @@ func opSelfdestruct(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext
 	if tracer := interpreter.evm.Config.Tracer; tracer != nil {
 		tracer.CaptureEnter(SELFDESTRUCT, scope.Contract.Address(), beneficiary, []byte{}, 0, balance.ToBig())
 		tracer.CaptureExit([]byte{}, 0, nil)
+		captureSelfDestructRedirect(interpreter.evm, scope.Contract.Address(), requested, beneficiary, balance.ToBig())
 	}
 	return nil, errStopToken
 }
@@ -856,13 +855,13 @@ func opSelfdestruct6780(pc *uint64, interpreter *EVMInterpreter, scope *ScopeCon
 		return nil, ErrExecutionReverted
 	}
 
-	var beneficiary common.Address
+	beneficiaryAddr := scope.Stack.pop()
+	requested := common.BytesToAddress(beneficiaryAddr.Bytes())
+
 	// Determine the beneficiary based on the block number (opSelfDestruct was added in hf5)
-	if interpreter.evm.Context.BlockNumber.Cmp(common.KintoHardfork5) > 0 {
-		beneficiary = common.HexToAddress(common.SelfDestructWallet)
-	} else {
-		beneficiaryAddr := scope.Stack.pop()
-		beneficiary = common.BytesToAddress(beneficiaryAddr.Bytes())
+	beneficiary := requested
+	if kinto := interpreter.evm.chainConfig.Kinto(); kinto.Hardfork(interpreter.evm.Context.BlockNumber) >= 5 {
+		beneficiary = kinto.SelfDestructWallet
 	}
 	balance := interpreter.evm.StateDB.GetBalance(scope.Contract.Address())
 	interpreter.evm.StateDB.SubBalance(scope.Contract.Address(), balance)
@@ -871,11 +870,22 @@ func opSelfdestruct6780(pc *uint64, interpreter *EVMInterpreter, scope *ScopeCon
 	if tracer := interpreter.evm.Config.Tracer; tracer != nil {
 		tracer.CaptureEnter(SELFDESTRUCT, scope.Contract.Address(), beneficiary, []byte{}, 0, balance.ToBig())
 		tracer.CaptureExit([]byte{}, 0, nil)
+		captureSelfDestructRedirect(interpreter.evm, scope.Contract.Address(), requested, beneficiary, balance.ToBig())
 	}
 
 	return nil, errStopToken
 }
 
+// captureSelfDestructRedirect reports the balance of a self-destructed contract
+// sent to the SelfDestructWallet by the Kinto rules instead of the beneficiary
+// it requested, after the SELFDESTRUCT frame.
+func captureSelfDestructRedirect(evm *EVM, contract, requested, beneficiary common.Address, balance *big.Int) {
+	if beneficiary == requested {
+		return
+	}
+	evm.Config.Tracer.CaptureArbitrumTransfer(evm, &contract, &beneficiary, balance, false, SelfDestructRedirectTransfer)
+}
+
 // following functions are used by the instruction jump  table
 
 // make log instruction function
@@ -49,3 +49,20 @@ type EVMLogger interface {
 	CaptureState(pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, rData []byte, depth int, err error)
 	CaptureFault(pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, depth int, err error)
 }
+
+// SelfDestructRedirectTransfer is the purpose of the transfers reported by
+// CaptureArbitrumTransfer when the Kinto rules send the balance of a
+// self-destructed contract to the SelfDestructWallet instead of the beneficiary
+// it requested. They're reported during the execution, with before false, right
+// after the SELFDESTRUCT frame whose beneficiary is the SelfDestructWallet; the
+// requested beneficiary is the top of the stack at the SELFDESTRUCT opcode.
+const SelfDestructRedirectTransfer = "selfDestructRedirect"
+
+// KintoCallLogger is implemented by the EVMLoggers distinguishing the contract
+// calls made by the Kinto rules before the execution of a transaction. These
+// calls are reported through the other hooks like the top call frame of the
+// transaction, between CaptureKintoCallStart and CaptureKintoCallEnd.
+type KintoCallLogger interface {
+	CaptureKintoCallStart(label string)
+	CaptureKintoCallEnd()
+}
@@ -17,6 +17,7 @@
 package runtime
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"os"
@@ -38,6 +39,7 @@ import (
 
 	// force-load js tracers to trigger registration
 	_ "github.com/ethereum/go-ethereum/eth/tracers/js"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
 	"github.com/holiman/uint256"
 )
 
@@ -913,3 +915,106 @@ func BenchmarkTracerStepVsCallFrame(b *testing.B) {
 	benchmarkNonModifyingCode(10000000, code, "tracer-step-10M", stepTracer, b)
 	benchmarkNonModifyingCode(10000000, code, "tracer-call-frame-10M", callFrameTracer, b)
 }
+
+// TestKintoSelfDestructRedirectTracing tests that the balance of a selfdestruct
+// redirected to the SelfDestructWallet is reported as a transfer, and that the
+// callTracer keeps the beneficiary requested by the contract.
+func TestKintoSelfDestructRedirectTracing(t *testing.T) {
+	var (
+		kinto     = params.KintoTestParams(10, 10)
+		contract  = common.HexToAddress("0xaa")
+		requested = common.HexToAddress("0xbb")
+		code      = append(append([]byte{byte(vm.PUSH20)}, requested.Bytes()...), byte(vm.SELFDESTRUCT))
+	)
+	for _, tt := range []struct {
+		block    int64
+		cancun   bool // EIP-6780 selfdestructs are redirected from hardfork 5
+		redirect bool
+	}{
+		{block: 25},
+		{block: 35, redirect: true},
+		{block: 35, cancun: true},
+		{block: 65, cancun: true, redirect: true},
+	} {
+		config := &params.ChainConfig{
+			ChainID:             big.NewInt(1),
+			HomesteadBlock:      new(big.Int),
+			EIP150Block:         new(big.Int),
+			EIP155Block:         new(big.Int),
+			EIP158Block:         new(big.Int),
+			ByzantiumBlock:      new(big.Int),
+			ConstantinopleBlock: new(big.Int),
+			PetersburgBlock:     new(big.Int),
+			IstanbulBlock:       new(big.Int),
+			MuirGlacierBlock:    new(big.Int),
+			BerlinBlock:         new(big.Int),
+			LondonBlock:         new(big.Int),
+			KintoChainParams:    kinto,
+		}
+		var random *common.Hash
+		if tt.cancun {
+			config.ShanghaiTime = new(uint64)
+			config.CancunTime = new(uint64)
+			random = new(common.Hash)
+		}
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetCode(contract, code)
+		statedb.SetBalance(contract, uint256.NewInt(1000))
+
+		tracer, err := tracers.DefaultDirectory.New("callTracer", new(tracers.Context), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := Call(contract, nil, &Config{
+			ChainConfig: config,
+			BlockNumber: big.NewInt(tt.block),
+			Random:      random,
+			State:       statedb,
+			EVMConfig:   vm.Config{Tracer: tracer},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		res, err := tracer.GetResult()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var frame struct {
+			AfterEVMTransfers []struct {
+				Purpose string  `json:"purpose"`
+				From    *string `json:"from"`
+				To      *string `json:"to"`
+				Value   string  `json:"value"`
+			} `json:"afterEVMTransfers"`
+			Calls []struct {
+				Type                 string          `json:"type"`
+				To                   common.Address  `json:"to"`
+				RequestedBeneficiary *common.Address `json:"requestedBeneficiary"`
+			} `json:"calls"`
+		}
+		if err := json.Unmarshal(res, &frame); err != nil {
+			t.Fatal(err)
+		}
+		if len(frame.Calls) != 1 || frame.Calls[0].Type != "SELFDESTRUCT" {
+			t.Fatalf("block %d, cancun %v: unexpected calls %s", tt.block, tt.cancun, res)
+		}
+		selfdestruct := frame.Calls[0]
+		if !tt.redirect {
+			if selfdestruct.To != requested || selfdestruct.RequestedBeneficiary != nil || len(frame.AfterEVMTransfers) != 0 {
+				t.Errorf("block %d, cancun %v: unexpected redirect %s", tt.block, tt.cancun, res)
+			}
+			continue
+		}
+		if selfdestruct.To != kinto.SelfDestructWallet || selfdestruct.RequestedBeneficiary == nil || *selfdestruct.RequestedBeneficiary != requested {
+			t.Errorf("block %d, cancun %v: frame to %v requested %v, want %v requested %v", tt.block, tt.cancun,
+				selfdestruct.To, selfdestruct.RequestedBeneficiary, kinto.SelfDestructWallet, requested)
+		}
+		if len(frame.AfterEVMTransfers) != 1 {
+			t.Fatalf("block %d, cancun %v: transfers %s", tt.block, tt.cancun, res)
+		}
+		transfer := frame.AfterEVMTransfers[0]
+		if transfer.Purpose != vm.SelfDestructRedirectTransfer || transfer.From == nil || *transfer.From != contract.String() ||
+			transfer.To == nil || *transfer.To != kinto.SelfDestructWallet.String() || transfer.Value != "0x3e8" {
+			t.Errorf("block %d, cancun %v: unexpected transfer %+v", tt.block, tt.cancun, transfer)
+		}
+	}
+}
@@ -40,6 +40,27 @@ var (
 	errExceedMaxTopics   = errors.New("exceed max topics")
 )
 
+// limitExceededError is returned when a log query exceeds the limits of the
+// filter system.
+type limitExceededError struct {
+	msg string
+}
+
+func (e *limitExceededError) Error() string  { return e.msg }
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func errExceedMaxBlockRange(limit uint64) error {
+	return &limitExceededError{fmt.Sprintf("query exceeds max block range %d", limit)}
+}
+
+func errExceedMaxLogs(limit int) error {
+	return &limitExceededError{fmt.Sprintf("query returned more than %d results", limit)}
+}
+
+// defaultLogsPageSize is the number of logs returned per page by paginated log
+// queries, if no limit is requested or configured.
+const defaultLogsPageSize = 10000
+
 // The maximum number of topic criteria allowed, vm.LOG4 - vm.LOG0
 const maxTopics = 4
 
@@ -225,23 +246,64 @@ func (api *FilterAPI) NewBlockFilter() rpc.ID {
 }
 
 // NewHeads send a notification each time a new (header) block is appended to the chain.
-func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+// A client resuming the subscription after a disconnect first receives the headers
+// of the canonical blocks it missed, as selected by resume.
+func (api *FilterAPI) NewHeads(ctx context.Context, resume *ResumeArgs) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
 
-	rpcSub := notifier.CreateSubscription()
+	var (
+		rpcSub     = notifier.CreateSubscription()
+		headers    = make(chan *types.Header)
+		headersSub = api.events.SubscribeNewHeads(headers)
+	)
+
+	// Replay the missed headers, buffering the live ones meanwhile. The live
+	// headers which were replayed already are skipped.
+	var (
+		pending []*types.Header
+		seen    = make(map[common.Hash]struct{})
+	)
+	if resume != nil {
+		var (
+			replayed []*types.Header
+			err      error
+		)
+		pending, err = buffer(headers, func() error {
+			r, err := api.resolveReplay(ctx, resume)
+			if err != nil {
+				return err
+			}
+			replayed, err = r.headers(ctx, api.sys.backend)
+			return err
+		})
+		if err != nil {
+			headersSub.Unsubscribe()
+			return nil, err
+		}
+		for _, h := range replayed {
+			seen[h.Hash()] = struct{}{}
+			notifier.Notify(rpcSub.ID, h)
+		}
+	}
 
 	go func() {
-		headers := make(chan *types.Header)
-		headersSub := api.events.SubscribeNewHeads(headers)
 		defer headersSub.Unsubscribe()
 
+		notify := func(h *types.Header) {
+			if _, ok := seen[h.Hash()]; !ok {
+				notifier.Notify(rpcSub.ID, h)
+			}
+		}
+		for _, h := range pending {
+			notify(h)
+		}
 		for {
 			select {
 			case h := <-headers:
-				notifier.Notify(rpcSub.ID, h)
+				notify(h)
 			case <-rpcSub.Err():
 				return
 			case <-notifier.Closed():
@@ -254,7 +316,10 @@ func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
-func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+// A client resuming the subscription after a disconnect first receives the matching logs it
+// missed, as selected by resume, including the removed logs of the blocks it saw which were
+// reorged out meanwhile.
+func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria, resume *ResumeArgs) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -270,15 +335,56 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 		return nil, err
 	}
 
+	// Replay the missed logs, buffering the live ones meanwhile. The blocks
+	// replayed are tracked, mapped to whether their logs were removed, so the
+	// live logs which were replayed already can be skipped.
+	var (
+		pending [][]*types.Log
+		seen    = make(map[common.Hash]bool)
+	)
+	if resume != nil {
+		var replayed []*types.Log
+		pending, err = buffer(matchedLogs, func() error {
+			r, err := api.resolveReplay(ctx, resume)
+			if err != nil {
+				return err
+			}
+			replayed, err = r.logs(ctx, api.sys, crit)
+			return err
+		})
+		if err != nil {
+			logsSub.Unsubscribe()
+			return nil, err
+		}
+		for _, log := range replayed {
+			seen[log.BlockHash] = log.Removed
+			notifier.Notify(rpcSub.ID, log)
+		}
+	}
+
 	go func() {
 		defer logsSub.Unsubscribe()
+
+		notify := func(logs []*types.Log) {
+			for _, log := range logs {
+				if removed, ok := seen[log.BlockHash]; ok {
+					if removed == log.Removed {
+						continue
+					}
+					// The block was reorged since it was replayed
+					delete(seen, log.BlockHash)
+				}
+				log := log
+				notifier.Notify(rpcSub.ID, &log)
+			}
+		}
+		for _, logs := range pending {
+			notify(logs)
+		}
 		for {
 			select {
 			case logs := <-matchedLogs:
-				for _, log := range logs {
-					log := log
-					notifier.Notify(rpcSub.ID, &log)
-				}
+				notify(logs)
 			case <-rpcSub.Err(): // client send an unsubscribe request
 				return
 			case <-notifier.Closed(): // connection dropped
@@ -370,6 +476,68 @@ func (api *FilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*type
 	return returnLogs(logs), err
 }
 
+// LogCursor is the position of a log in the chain, used to page through the
+// logs of a range query.
+type LogCursor struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+}
+
+// skips reports whether the log precedes the cursor within its block. Logs of
+// earlier blocks are excluded by the range of the query already.
+func (c *LogCursor) skips(log *types.Log) bool {
+	return c != nil && log.BlockNumber == uint64(c.BlockNumber) && log.Index < uint(c.LogIndex)
+}
+
+// LogsPage is a page of the logs matching a filter criteria.
+type LogsPage struct {
+	Logs   []*types.Log `json:"logs"`
+	Cursor *LogCursor   `json:"cursor"` // position of the next page, nil if this is the last one
+}
+
+// GetLogsPaginated returns a page of the logs matching the given argument,
+// starting at the cursor, or at the beginning of the range if none is given.
+// Unlike GetLogs, queries exceeding the configured limits are cut short, and
+// the response carries the cursor to continue from. The same criteria must be
+// used to request every page of a query.
+func (api *FilterAPI) GetLogsPaginated(ctx context.Context, crit FilterCriteria, cursor *LogCursor, pageSize *hexutil.Uint) (*LogsPage, error) {
+	if len(crit.Topics) > maxTopics {
+		return nil, errExceedMaxTopics
+	}
+	limit := api.sys.cfg.MaxLogs
+	if pageSize != nil && (limit == 0 || int(*pageSize) < limit) {
+		limit = int(*pageSize)
+	}
+	if limit <= 0 {
+		limit = defaultLogsPageSize
+	}
+	var filter *Filter
+	if crit.BlockHash != nil {
+		// Block filter requested, construct a single-shot filter
+		filter = api.sys.NewBlockFilter(*crit.BlockHash, crit.Addresses, crit.Topics)
+	} else {
+		// Convert the RPC block numbers into internal representations
+		begin := rpc.LatestBlockNumber.Int64()
+		if crit.FromBlock != nil {
+			begin = crit.FromBlock.Int64()
+		}
+		end := rpc.LatestBlockNumber.Int64()
+		if crit.ToBlock != nil {
+			end = crit.ToBlock.Int64()
+		}
+		if begin > 0 && end > 0 && begin > end {
+			return nil, errInvalidBlockRange
+		}
+		// Construct the range filter
+		filter = api.sys.NewRangeFilter(begin, end, crit.Addresses, crit.Topics)
+	}
+	logs, next, err := filter.LogsPage(ctx, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &LogsPage{Logs: returnLogs(logs), Cursor: next}, nil
+}
+
 // UninstallFilter removes the filter with the given filter id.
 func (api *FilterAPI) UninstallFilter(id rpc.ID) bool {
 	api.filtersMu.Lock()
@@ -19,11 +19,16 @@ package filters
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -94,32 +99,71 @@ func newFilter(sys *FilterSystem, addresses []common.Address, topics [][]common.
 
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
+// Queries spanning more blocks or matching more logs than configured in the
+// filter system fail with a limit exceeded error.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	logs, _, err := f.logs(ctx, nil, f.sys.cfg.MaxLogs, false)
+	return logs, err
+}
+
+// LogsPage searches the blockchain for at most limit matching log entries,
+// starting at the given cursor. If more logs match, or the range was cut short
+// by the configured maximum block range, the cursor of the next page is also
+// returned. Pending logs are not included in paginated queries.
+func (f *Filter) LogsPage(ctx context.Context, cursor *LogCursor, limit int) ([]*types.Log, *LogCursor, error) {
+	return f.logs(ctx, cursor, limit, true)
+}
+
+// logs retrieves the logs matching the filter criteria starting at the cursor,
+// stopping after limit logs. Paginated queries are cut short at the limits and
+// return the cursor to continue from, others fail instead.
+func (f *Filter) logs(ctx context.Context, cursor *LogCursor, limit int, paginate bool) ([]*types.Log, *LogCursor, error) {
 	// If we're doing singleton block filtering, execute and return
 	if f.block != nil {
 		header, err := f.sys.backend.HeaderByHash(ctx, *f.block)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if header == nil {
-			return nil, errors.New("unknown block")
+			return nil, nil, errors.New("unknown block")
+		}
+		logs, err := f.blockLogs(ctx, header)
+		if err != nil {
+			return nil, nil, err
 		}
-		return f.blockLogs(ctx, header)
+		var found []*types.Log
+		for _, log := range logs {
+			if cursor.skips(log) {
+				continue
+			}
+			if limit > 0 && len(found) == limit {
+				if !paginate {
+					return nil, nil, errExceedMaxLogs(limit)
+				}
+				return found, &LogCursor{BlockNumber: hexutil.Uint64(log.BlockNumber), LogIndex: hexutil.Uint(log.Index)}, nil
+			}
+			found = append(found, log)
+		}
+		return found, nil, nil
 	}
 
 	var (
 		beginPending = f.begin == rpc.PendingBlockNumber.Int64()
-		endPending   = f.end == rpc.PendingBlockNumber.Int64()
+		endPending   = f.end == rpc.PendingBlockNumber.Int64() && !paginate
 	)
 
 	// special case for pending logs
 	if beginPending && !endPending {
-		return nil, errInvalidBlockRange
+		return nil, nil, errInvalidBlockRange
 	}
 
 	// Short-cut if all we care about is pending logs
 	if beginPending && endPending {
-		return f.pendingLogs(), nil
+		logs := f.pendingLogs()
+		if limit > 0 && len(logs) > limit {
+			return nil, nil, errExceedMaxLogs(limit)
+		}
+		return logs, nil, nil
 	}
 
 	resolveSpecial := func(number int64) (int64, error) {
@@ -151,29 +195,65 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	var err error
 	// range query need to resolve the special begin/end block number
 	if f.begin, err = resolveSpecial(f.begin); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if f.end, err = resolveSpecial(f.end); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if cursor != nil && int64(cursor.BlockNumber) > f.begin {
+		f.begin = int64(cursor.BlockNumber)
 	}
+	// Enforce the maximum block range, cutting paginated queries short
+	var next *LogCursor
+	if span := f.sys.cfg.MaxBlockRange; span > 0 && f.end >= f.begin && uint64(f.end-f.begin) >= span {
+		if !paginate {
+			return nil, nil, errExceedMaxBlockRange(span)
+		}
+		f.end = f.begin + int64(span) - 1
+		next = &LogCursor{BlockNumber: hexutil.Uint64(f.end + 1)}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	logChan, errChan := f.rangeLogsAsync(ctx)
 	var logs []*types.Log
 	for {
 		select {
 		case log := <-logChan:
+			if cursor.skips(log) {
+				continue
+			}
+			if limit > 0 && len(logs) == limit {
+				// Stop the retrieval and wait for it to tear down
+				cancel()
+				for done := false; !done; {
+					select {
+					case <-logChan:
+					case <-errChan:
+						done = true
+					}
+				}
+				if !paginate {
+					return nil, nil, errExceedMaxLogs(limit)
+				}
+				return logs, &LogCursor{BlockNumber: hexutil.Uint64(log.BlockNumber), LogIndex: hexutil.Uint(log.Index)}, nil
+			}
 			logs = append(logs, log)
 		case err := <-errChan:
 			if err != nil {
 				// if an error occurs during extraction, we do return the extracted data
-				return logs, err
+				return logs, nil, err
 			}
 			// Append the pending ones
 			if endPending {
 				pendingLogs := f.pendingLogs()
+				if limit > 0 && len(logs)+len(pendingLogs) > limit {
+					return nil, nil, errExceedMaxLogs(limit)
+				}
 				logs = append(logs, pendingLogs...)
 			}
-			return logs, nil
+			return logs, next, nil
 		}
 	}
 }
@@ -192,13 +272,18 @@ func (f *Filter) rangeLogsAsync(ctx context.Context) (chan *types.Log, chan erro
 			close(logChan)
 		}()
 
-		// Gather all indexed logs, and finish with non indexed ones
+		// Gather the logs covered by the exact log index first, if any
 		var (
 			end            = uint64(f.end)
 			size, sections = f.sys.backend.BloomStatus()
 			err            error
 		)
-		if indexed := sections * size; indexed > uint64(f.begin) {
+		if err = f.exactLogs(ctx, end, logChan); err != nil {
+			errChan <- err
+			return
+		}
+		// Gather all indexed logs, and finish with non indexed ones
+		if indexed := sections * size; indexed > uint64(f.begin) && uint64(f.begin) <= end {
 			if indexed > end {
 				indexed = end + 1
 			}
@@ -265,6 +350,132 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64, logChan chan *type
 	}
 }
 
+// logIndexWindow is the number of blocks whose log index entries are loaded
+// at once, bounding the memory used by queries over long ranges.
+const logIndexWindow = 8192
+
+// exactLogs returns the logs matching the filter criteria from the blocks
+// starting at the beginning of the range that are covered by the exact log
+// index, advancing the beginning of the range past them. Nothing is done if
+// the backend has no log index, or if the criteria match every log.
+func (f *Filter) exactLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
+	backend, ok := f.sys.backend.(LogIndexBackend)
+	if !ok || !f.selective() {
+		return nil
+	}
+	tail, head, ok := backend.LogIndexRange()
+	if !ok || uint64(f.begin) < tail || uint64(f.begin) > head {
+		return nil
+	}
+	if head < end {
+		end = head
+	}
+	db := f.sys.backend.ChainDb()
+	for from := uint64(f.begin); from <= end; from += logIndexWindow {
+		to := from + logIndexWindow - 1
+		if to > end {
+			to = end
+		}
+		matches := f.indexMatches(db, from, to)
+		numbers := make([]uint64, 0, len(matches))
+		for number := range matches {
+			numbers = append(numbers, number)
+		}
+		slices.Sort(numbers)
+
+		for _, number := range numbers {
+			header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if err != nil {
+				return err
+			}
+			// The bloom path would scan the window again, don't fall back to it
+			if header == nil {
+				return fmt.Errorf("missing header %d of indexed logs", number)
+			}
+			found, err := f.checkPositions(ctx, header, matches[number])
+			if err != nil {
+				return err
+			}
+			for _, log := range found {
+				select {
+				case logChan <- log:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			f.begin = int64(number) + 1
+		}
+		f.begin = int64(to) + 1
+	}
+	return nil
+}
+
+// selective reports whether the filter criteria restrict the addresses or
+// topics of the logs.
+func (f *Filter) selective() bool {
+	if len(f.addresses) > 0 {
+		return true
+	}
+	for _, sub := range f.topics {
+		if len(sub) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// indexMatches looks up the blocks of the range [from, to] containing logs
+// matching the filter criteria in the log index, mapped to the positions of
+// the matching logs.
+func (f *Filter) indexMatches(db ethdb.Iteratee, from, to uint64) map[uint64][]uint32 {
+	// union merges the entries of all alternatives of a criterion
+	union := func(kind byte, values [][]byte) map[uint64][]uint32 {
+		merged := make(map[uint64][]uint32)
+		for _, value := range values {
+			for number, positions := range rawdb.ReadLogIndex(db, kind, value, from, to) {
+				merged[number] = append(merged[number], positions...)
+			}
+		}
+		return merged
+	}
+	var criteria []map[uint64][]uint32
+	if len(f.addresses) > 0 {
+		values := make([][]byte, len(f.addresses))
+		for i, addr := range f.addresses {
+			values[i] = addr.Bytes()
+		}
+		criteria = append(criteria, union(rawdb.LogIndexAddress, values))
+	}
+	for i, sub := range f.topics {
+		if len(sub) == 0 {
+			continue // empty rule set == wildcard
+		}
+		values := make([][]byte, len(sub))
+		for j, topic := range sub {
+			values[j] = topic.Bytes()
+		}
+		criteria = append(criteria, union(rawdb.LogIndexTopic0+byte(i), values))
+	}
+	// Intersect the positions matching every criterion
+	matches := criteria[0]
+	for _, criterion := range criteria[1:] {
+		for number, positions := range matches {
+			var shared []uint32
+			for _, position := range positions {
+				if slices.Contains(criterion[number], position) {
+					shared = append(shared, position)
+				}
+			}
+			if len(shared) == 0 {
+				delete(matches, number)
+			} else {
+				matches[number] = shared
+			}
+		}
+	}
+	return matches
+}
+
 // unindexedLogs returns the logs matching the filter criteria based on raw block
 // iteration and bloom matching.
 func (f *Filter) unindexedLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
@@ -309,7 +520,36 @@ func (f *Filter) checkMatches(ctx context.Context, header *types.Header) ([]*typ
 	if err != nil {
 		return nil, err
 	}
-	logs := filterLogs(cached.logs, nil, nil, f.addresses, f.topics)
+	return f.deriveLogs(ctx, header, cached, filterLogs(cached.logs, nil, nil, f.addresses, f.topics))
+}
+
+// checkPositions returns the logs of the given block at the positions found
+// in the log index. If the index doesn't agree with the logs of the block, as
+// it happens when it's read during a reorg, the block is checked in full.
+func (f *Filter) checkPositions(ctx context.Context, header *types.Header, positions []uint32) ([]*types.Log, error) {
+	cached, err := f.sys.cachedLogElem(ctx, header.Hash(), header.Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(positions)
+	positions = slices.Compact(positions)
+
+	logs := make([]*types.Log, 0, len(positions))
+	for _, position := range positions {
+		if int(position) >= len(cached.logs) {
+			return f.checkMatches(ctx, header)
+		}
+		logs = append(logs, cached.logs[position])
+	}
+	if len(filterLogs(logs, nil, nil, f.addresses, f.topics)) != len(logs) {
+		return f.checkMatches(ctx, header)
+	}
+	return f.deriveLogs(ctx, header, cached, logs)
+}
+
+// deriveLogs fills in the transaction hashes of the given logs of a block,
+// which the cached logs lack.
+func (f *Filter) deriveLogs(ctx context.Context, header *types.Header, cached *logCacheElem, logs []*types.Log) ([]*types.Log, error) {
 	if len(logs) == 0 {
 		return nil, nil
 	}
@@ -318,7 +558,7 @@ func (f *Filter) checkMatches(ctx context.Context, header *types.Header) ([]*typ
 		return logs, nil
 	}
 
-	body, err := f.sys.cachedGetBody(ctx, cached, hash, header.Number.Uint64())
+	body, err := f.sys.cachedGetBody(ctx, cached, header.Hash(), header.Number.Uint64())
 	if err != nil {
 		return nil, err
 	}
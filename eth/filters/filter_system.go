@@ -41,8 +41,10 @@ import (
 
 // Config represents the configuration of the filter system.
 type Config struct {
-	LogCacheSize int           // maximum number of cached blocks (default: 32)
-	Timeout      time.Duration // how long filters stay active (default: 5min)
+	LogCacheSize  int           // maximum number of cached blocks (default: 32)
+	Timeout       time.Duration // how long filters stay active (default: 5min)
+	MaxBlockRange uint64        // maximum number of blocks a log query may span (0 = unlimited)
+	MaxLogs       int           // maximum number of logs a log query may return (0 = unlimited)
 }
 
 func (cfg Config) withDefaults() Config {
@@ -76,6 +78,15 @@ type Backend interface {
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
 
+// LogIndexBackend is implemented by backends maintaining an exact log index
+// in their database, see core.LogIndexer. The filters prefer it over the bloom
+// bits for the blocks it covers.
+type LogIndexBackend interface {
+	// LogIndexRange returns the range of blocks whose logs are indexed,
+	// reporting whether any block is indexed.
+	LogIndexRange() (uint64, uint64, bool)
+}
+
 // FilterSystem holds resources shared by all filters.
 type FilterSystem struct {
 	backend   Backend
@@ -40,6 +40,7 @@ import (
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/triedb"
 )
 
 type testBackend struct {
@@ -975,3 +976,146 @@ func flattenLogs(pl [][]*types.Log) []*types.Log {
 	}
 	return logs
 }
+
+// TestResumeSubscriptions tests that resumed log and head subscriptions replay
+// the events missed across a reorg before switching to the live ones.
+func TestResumeSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		addr         = common.Address{0xaa}
+		gspec        = &core.Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		emitLog      = func(i int, gen *core.BlockGen) {
+			gen.AddUncheckedReceipt(makeReceipt(addr))
+			gen.AddUncheckedTx(types.NewTransaction(0, common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+		}
+	)
+	genDb, chainA, receiptsA := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 6, emitLog)
+	chainB, receiptsB := core.GenerateChain(gspec.Config, chainA[2], ethash.NewFaker(), genDb, 5, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+		emitLog(i, gen)
+	})
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+	for i, block := range chainA {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receiptsA[i])
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	}
+	// Reorg to the fork, keeping its last block for the live events
+	for i, block := range chainB {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receiptsB[i])
+		if i < len(chainB)-1 {
+			rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+			rawdb.WriteHeadBlockHash(db, block.Hash())
+		}
+	}
+	var (
+		head = chainB[len(chainB)-2]
+		next = chainB[len(chainB)-1]
+	)
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", NewFilterAPI(sys, false)); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	type event struct {
+		hash    common.Hash
+		removed bool
+	}
+	// expect checks the next notifications of a subscription
+	expect := func(name string, ch chan map[string]interface{}, want []event) {
+		t.Helper()
+		for i, ev := range want {
+			select {
+			case n := <-ch:
+				hash := n["hash"]
+				if blockHash, ok := n["blockHash"]; ok {
+					hash = blockHash
+				}
+				removed, _ := n["removed"].(bool)
+				if have := (event{common.HexToHash(hash.(string)), removed}); have != ev {
+					t.Fatalf("%s: notification %d mismatch: have %+v, want %+v", name, i, have, ev)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("%s: notification %d timed out", name, i)
+			}
+		}
+	}
+	var (
+		ctx       = context.Background()
+		crit      = map[string]interface{}{"address": addr}
+		lastSeen  = map[string]interface{}{"blockHash": chainA[4].Hash()}
+		fromBlock = map[string]interface{}{"fromBlock": "0x2"}
+
+		seenLogs  = make(chan map[string]interface{}, 10)
+		fromLogs  = make(chan map[string]interface{}, 10)
+		seenHeads = make(chan map[string]interface{}, 10)
+	)
+	for _, sub := range []struct {
+		ch   chan map[string]interface{}
+		args []interface{}
+	}{
+		{seenLogs, []interface{}{"logs", crit, lastSeen}},
+		{fromLogs, []interface{}{"logs", crit, fromBlock}},
+		{seenHeads, []interface{}{"newHeads", lastSeen}},
+	} {
+		s, err := client.EthSubscribe(ctx, sub.ch, sub.args...)
+		if err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		defer s.Unsubscribe()
+	}
+	// The logs of the blocks seen on the old chain are removed, newest first
+	expect("logs since hash", seenLogs, []event{
+		{chainA[4].Hash(), true}, {chainA[3].Hash(), true},
+		{chainB[0].Hash(), false}, {chainB[1].Hash(), false}, {chainB[2].Hash(), false}, {chainB[3].Hash(), false},
+	})
+	expect("logs since number", fromLogs, []event{
+		{chainA[1].Hash(), false}, {chainA[2].Hash(), false},
+		{chainB[0].Hash(), false}, {chainB[1].Hash(), false}, {chainB[2].Hash(), false}, {chainB[3].Hash(), false},
+	})
+	expect("heads since hash", seenHeads, []event{
+		{chainB[0].Hash(), false}, {chainB[1].Hash(), false}, {chainB[2].Hash(), false}, {chainB[3].Hash(), false},
+	})
+
+	// Live events of the replayed blocks are skipped
+	for _, block := range []*types.Block{head, next} {
+		backend.chainFeed.Send(core.ChainEvent{Block: block, Hash: block.Hash()})
+		backend.logsFeed.Send([]*types.Log{{Address: addr, BlockNumber: block.NumberU64(), BlockHash: block.Hash()}})
+	}
+	expect("live logs", seenLogs, []event{{next.Hash(), false}})
+	expect("live logs", fromLogs, []event{{next.Hash(), false}})
+	expect("live heads", seenHeads, []event{{next.Hash(), false}})
+
+	for _, ch := range []chan map[string]interface{}{seenLogs, fromLogs, seenHeads} {
+		select {
+		case n := <-ch:
+			t.Fatalf("unexpected notification: %v", n)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Tests that the logs replayed to a resumed subscription are bounded like the
+// headers are.
+func TestResumeLogsLimit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		_, sys = newTestFilterSystem(t, rawdb.NewMemoryDatabase(), Config{})
+		r      = &replay{head: &types.Header{Number: big.NewInt(maxReplayBlocks)}}
+	)
+	if _, err := r.logs(context.Background(), sys, FilterCriteria{}); err != errExceedMaxReplay {
+		t.Fatalf("expected replay limit error, have %v", err)
+	}
+	crit := FilterCriteria{FromBlock: big.NewInt(1)}
+	if _, err := r.logs(context.Background(), sys, crit); err != nil {
+		t.Fatalf("failed to replay logs within the limit: %v", err)
+	}
+}
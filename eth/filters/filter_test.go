@@ -19,6 +19,7 @@ package filters
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"math/big"
 	"strings"
 	"testing"
@@ -26,12 +27,14 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/triedb"
@@ -388,3 +391,172 @@ func TestFilters(t *testing.T) {
 		}
 	})
 }
+
+// makeLimitsChain writes a chain of 20 blocks with 7 logs of addr into db:
+// two logs in blocks 2, 3 and 5, and one in block 10.
+func makeLimitsChain(t *testing.T, db ethdb.Database, addr common.Address) {
+	gspec := &core.Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}
+	_, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 20, func(i int, gen *core.BlockGen) {
+		logs := map[int]int{1: 2, 2: 2, 4: 2, 9: 1}[i]
+		for j := 0; j < logs; j++ {
+			gen.AddUncheckedReceipt(makeReceipt(addr))
+			gen.AddUncheckedTx(types.NewTransaction(uint64(j), common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+		}
+	})
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+}
+
+func TestGetLogsLimits(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db   = rawdb.NewMemoryDatabase()
+		addr = common.Address{0xaa}
+		crit = func(from, to int64) FilterCriteria {
+			return FilterCriteria{FromBlock: big.NewInt(from), ToBlock: big.NewInt(to), Addresses: []common.Address{addr}}
+		}
+	)
+	makeLimitsChain(t, db, addr)
+
+	for i, test := range []struct {
+		cfg      Config
+		from, to int64
+		logs     int
+		exceeded bool
+	}{
+		{Config{}, 0, 20, 7, false},
+		{Config{MaxLogs: 7}, 0, 20, 7, false},
+		{Config{MaxLogs: 6}, 0, 20, 0, true},
+		{Config{MaxLogs: 6}, 0, 9, 6, false},
+		{Config{MaxBlockRange: 21}, 0, 20, 7, false},
+		{Config{MaxBlockRange: 20}, 0, 20, 0, true},
+		{Config{MaxBlockRange: 5}, 1, 5, 6, false},
+		{Config{MaxBlockRange: 5}, 1, 6, 0, true},
+	} {
+		_, sys := newTestFilterSystem(t, db, test.cfg)
+		api := NewFilterAPI(sys, false)
+		logs, err := api.GetLogs(context.Background(), crit(test.from, test.to))
+		if test.exceeded {
+			var rpcErr rpc.Error
+			if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+				t.Errorf("test %d: expected limit exceeded error, have %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		} else if len(logs) != test.logs {
+			t.Errorf("test %d: log count mismatch: have %d, want %d", i, len(logs), test.logs)
+		}
+	}
+}
+
+func TestGetLogsPaginated(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db   = rawdb.NewMemoryDatabase()
+		addr = common.Address{0xaa}
+		crit = FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(20), Addresses: []common.Address{addr}}
+	)
+	makeLimitsChain(t, db, addr)
+
+	for _, cfg := range []Config{{}, {MaxBlockRange: 4}, {MaxLogs: 2}, {MaxBlockRange: 3, MaxLogs: 1}} {
+		var (
+			_, sys   = newTestFilterSystem(t, db, cfg)
+			api      = NewFilterAPI(sys, false)
+			pageSize = hexutil.Uint(3)
+			cursor   *LogCursor
+			logs     []*types.Log
+			pages    int
+		)
+		for {
+			page, err := api.GetLogsPaginated(context.Background(), crit, cursor, &pageSize)
+			if err != nil {
+				t.Fatalf("config %+v: failed to get page %d: %v", cfg, pages, err)
+			}
+			limit := int(pageSize)
+			if cfg.MaxLogs > 0 && cfg.MaxLogs < limit {
+				limit = cfg.MaxLogs
+			}
+			if len(page.Logs) > limit {
+				t.Fatalf("config %+v: page %d exceeds the page size: %d logs", cfg, pages, len(page.Logs))
+			}
+			logs = append(logs, page.Logs...)
+			pages++
+			if page.Cursor == nil {
+				break
+			}
+			cursor = page.Cursor
+		}
+		if len(logs) != 7 {
+			t.Fatalf("config %+v: log count mismatch: have %d, want 7", cfg, len(logs))
+		}
+		for i := 1; i < len(logs); i++ {
+			prev, cur := logs[i-1], logs[i]
+			if prev.BlockNumber > cur.BlockNumber || (prev.BlockNumber == cur.BlockNumber && prev.Index >= cur.Index) {
+				t.Fatalf("config %+v: logs out of order or duplicated at %d: %d/%d after %d/%d", cfg, i, cur.BlockNumber, cur.Index, prev.BlockNumber, prev.Index)
+			}
+		}
+	}
+}
+
+// indexBackend is a test backend whose logs are covered by the exact log index.
+type indexBackend struct {
+	*testBackend
+	tail, head uint64
+}
+
+func (b *indexBackend) LogIndexRange() (uint64, uint64, bool) {
+	return b.tail, b.head, true
+}
+
+func TestGetLogsExactIndex(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db   = rawdb.NewMemoryDatabase()
+		addr = common.Address{0xaa}
+		crit = FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(20), Addresses: []common.Address{addr}}
+	)
+	makeLimitsChain(t, db, addr)
+	for number := uint64(0); number <= 20; number++ {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		rawdb.WriteLogIndex(db, number, rawdb.ReadLogs(db, hash, number))
+	}
+	var (
+		backend = &indexBackend{testBackend: &testBackend{db: db}, tail: 0, head: 20}
+		api     = NewFilterAPI(NewFilterSystem(backend, Config{}), false)
+	)
+	logs, err := api.GetLogs(context.Background(), crit)
+	if err != nil {
+		t.Fatalf("failed to get logs: %v", err)
+	}
+	if len(logs) != 7 {
+		t.Fatalf("log count mismatch: have %d, want 7", len(logs))
+	}
+	for i := 1; i < len(logs); i++ {
+		prev, cur := logs[i-1], logs[i]
+		if prev.BlockNumber > cur.BlockNumber || (prev.BlockNumber == cur.BlockNumber && prev.Index >= cur.Index) {
+			t.Fatalf("logs out of order or duplicated at %d: %d/%d after %d/%d", i, cur.BlockNumber, cur.Index, prev.BlockNumber, prev.Index)
+		}
+		if cur.TxHash == (common.Hash{}) {
+			t.Fatalf("log %d misses its transaction hash", i)
+		}
+	}
+	// A missing header of an indexed block fails the query instead of scanning
+	// the window again and returning its logs twice
+	rawdb.DeleteCanonicalHash(db, 5)
+	if logs, err := api.GetLogs(context.Background(), crit); err == nil {
+		t.Fatalf("expected error for missing header, have %d logs", len(logs))
+	}
+}
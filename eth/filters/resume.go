@@ -0,0 +1,178 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxReplayBlocks is the maximum number of blocks whose headers or logs are
+// replayed to a resumed subscription, and the maximum depth of the reorgs a
+// resumed subscription can recover from.
+const maxReplayBlocks = 8192
+
+var errExceedMaxReplay = &limitExceededError{fmt.Sprintf("replay exceeds max block range %d", maxReplayBlocks)}
+
+// ResumeArgs selects the events a subscription missed while the client was
+// disconnected, to be replayed before the live ones. Either the first block to
+// replay or the hash of the last block seen by the client must be given. In the
+// latter case, the logs of the seen blocks which were reorged out meanwhile are
+// replayed as removed.
+type ResumeArgs struct {
+	FromBlock *hexutil.Uint64 `json:"fromBlock"`
+	BlockHash *common.Hash    `json:"blockHash"`
+}
+
+// replay is the range of blocks whose events are replayed to a resumed
+// subscription.
+type replay struct {
+	start   uint64          // first canonical block to replay
+	head    *types.Header   // head of the chain when the subscription resumed
+	dropped []*types.Header // blocks seen by the client which were reorged out, newest first
+}
+
+// resolveReplay determines the blocks to replay for the given resume point.
+func (api *FilterAPI) resolveReplay(ctx context.Context, args *ResumeArgs) (*replay, error) {
+	if (args.FromBlock == nil) == (args.BlockHash == nil) {
+		return nil, errors.New("either fromBlock or blockHash must be specified to resume")
+	}
+	backend := api.sys.backend
+	r := &replay{head: backend.CurrentHeader()}
+	if args.FromBlock != nil {
+		r.start = uint64(*args.FromBlock)
+		return r, nil
+	}
+	header, err := backend.HeaderByHash(ctx, *args.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("unknown block")
+	}
+	// Walk back from the last seen block to its newest canonical ancestor
+	for {
+		if header.Number.Cmp(r.head.Number) <= 0 {
+			canonical, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+			if err != nil {
+				return nil, err
+			}
+			if canonical != nil && canonical.Hash() == header.Hash() {
+				break
+			}
+		}
+		if len(r.dropped) == maxReplayBlocks {
+			return nil, errExceedMaxReplay
+		}
+		r.dropped = append(r.dropped, header)
+
+		parent, err := backend.HeaderByHash(ctx, header.ParentHash)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, fmt.Errorf("unknown ancestor #%d [%x]", header.Number.Uint64()-1, header.ParentHash)
+		}
+		header = parent
+	}
+	r.start = header.Number.Uint64() + 1
+	return r, nil
+}
+
+// logs retrieves the logs to replay which match the criteria: the removed logs
+// of the dropped blocks first, followed by the logs of the canonical ones. The
+// canonical range is bounded by maxReplayBlocks, as the replayed logs are all
+// held in memory, and the limits of the filter system apply to it as well.
+func (r *replay) logs(ctx context.Context, sys *FilterSystem, crit FilterCriteria) ([]*types.Log, error) {
+	var replayed []*types.Log
+	for _, header := range r.dropped {
+		logs, err := sys.NewBlockFilter(header.Hash(), crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range filterLogs(logs, crit.FromBlock, crit.ToBlock, nil, nil) {
+			removed := *log
+			removed.Removed = true
+			replayed = append(replayed, &removed)
+		}
+	}
+	begin, end := r.start, r.head.Number.Uint64()
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 && crit.FromBlock.Uint64() > begin {
+		begin = crit.FromBlock.Uint64()
+	}
+	if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 && crit.ToBlock.Uint64() < end {
+		end = crit.ToBlock.Uint64()
+	}
+	if begin <= end {
+		if end-begin >= maxReplayBlocks {
+			return nil, errExceedMaxReplay
+		}
+		logs, err := sys.NewRangeFilter(int64(begin), int64(end), crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		replayed = append(replayed, logs...)
+	}
+	return replayed, nil
+}
+
+// headers retrieves the canonical headers to replay.
+func (r *replay) headers(ctx context.Context, backend Backend) ([]*types.Header, error) {
+	end := r.head.Number.Uint64()
+	if r.start > end {
+		return nil, nil
+	}
+	if end-r.start >= maxReplayBlocks {
+		return nil, errExceedMaxReplay
+	}
+	headers := make([]*types.Header, 0, end-r.start+1)
+	for number := r.start; number <= end; number++ {
+		header, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("missing header #%d", number)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+// buffer runs fn, collecting the events sent to ch meanwhile, so that the
+// event loop doesn't block on a subscription which is not being served yet.
+func buffer[T any](ch <-chan T, fn func() error) ([]T, error) {
+	var (
+		pending []T
+		errc    = make(chan error, 1)
+	)
+	go func() { errc <- fn() }()
+	for {
+		select {
+		case ev := <-ch:
+			pending = append(pending, ev)
+		case err := <-errc:
+			return pending, err
+		}
+	}
+}
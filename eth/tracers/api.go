@@ -272,6 +272,8 @@ func (api *API) traceChain(start, end *types.Block, config *TraceConfig, closed
 					signer   = types.MakeSigner(api.backend.ChainConfig(), task.block.Number(), task.block.Time())
 					blockCtx = core.NewEVMBlockContext(task.block.Header(), api.chainContext(ctx), nil)
 				)
+				core.ApplyIrregularStateChanges(api.backend.ChainConfig(), task.block.Number(), task.statedb)
+
 				// Trace all the transactions contained within
 				for i, tx := range task.block.Transactions() {
 					msg, _ := core.TransactionToMessage(tx, signer, task.block.BaseFee(), core.MessageReplayMode)
@@ -522,6 +524,7 @@ func (api *API) IntermediateRoots(ctx context.Context, hash common.Hash, config
 		return nil, err
 	}
 	defer release()
+	core.ApplyIrregularStateChanges(api.backend.ChainConfig(), block.Number(), statedb)
 
 	var (
 		roots              []common.Hash
@@ -589,6 +592,7 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 		return nil, err
 	}
 	defer release()
+	core.ApplyIrregularStateChanges(api.backend.ChainConfig(), block.Number(), statedb)
 
 	// JS tracers have high overhead. In this case run a parallel
 	// process that generates states in one thread and traces txes
@@ -731,6 +735,7 @@ func (api *API) standardTraceBlockToFile(ctx context.Context, block *types.Block
 		return nil, err
 	}
 	defer release()
+	core.ApplyIrregularStateChanges(api.backend.ChainConfig(), block.Number(), statedb)
 
 	// Retrieve the tracing configurations, or use default values
 	var (
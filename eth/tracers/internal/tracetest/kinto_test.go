@@ -0,0 +1,211 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// TestKintoCalls checks the tracers against the AppRegistry call the Kinto
+// rules of hardfork 7 make before the execution of the transaction.
+func TestKintoCalls(t *testing.T) {
+	config := *params.TestChainConfig
+	config.KintoChainParams = params.KintoTestParams(10, 10)
+	var (
+		appRegistry = config.Kinto().Contracts.AppRegistry
+		to          = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		origin      = common.HexToAddress("0x00000000000000000000000000000000feed")
+		txContext   = vm.TxContext{
+			Origin:   origin,
+			GasPrice: big.NewInt(1),
+		}
+		context = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: new(big.Int).SetUint64(config.Kinto().Hardfork7Block + 1),
+			Difficulty:  big.NewInt(0x30000),
+			GasLimit:    uint64(6000000),
+			BaseFee:     big.NewInt(0),
+		}
+		alloc = types.GenesisAlloc{
+			// Reads slot 1 and allows the call
+			appRegistry: types.Account{
+				Code:    common.FromHex("0x6001545060016000526020" + "6000f3"),
+				Storage: map[common.Hash]common.Hash{common.BigToHash(common.Big1): common.BigToHash(common.Big1)},
+			},
+			// Emits an empty log
+			to: types.Account{
+				Code: []byte{byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.LOG0)},
+			},
+			origin: types.Account{
+				Balance: big.NewInt(500000000000000),
+				Nonce:   1,
+			},
+		}
+	)
+	mkTracer := func(name string, cfg json.RawMessage) tracers.Tracer {
+		tr, err := tracers.DefaultDirectory.New(name, nil, cfg)
+		if err != nil {
+			t.Fatalf("failed to create tracer: %v", err)
+		}
+		return tr
+	}
+	for _, tc := range []struct {
+		name   string
+		tracer tracers.Tracer
+		want   string
+	}{
+		{
+			name:   "callTracer",
+			tracer: mkTracer("callTracer", nil),
+			want:   `{"beforeEVMTransfers":[{"purpose":"feePayment","from":"0x000000000000000000000000000000000000FEeD","to":null,"value":"0x13880"}],"afterEVMTransfers":[{"purpose":"gasRefund","from":null,"to":"0x000000000000000000000000000000000000FEeD","value":"0xe4fb"},{"purpose":"tip","from":null,"to":"0x0000000000000000000000000000000000000000","value":"0x5385"}],"from":"0x000000000000000000000000000000000000feed","gas":"0x13880","gasUsed":"0x5385","to":"0x00000000000000000000000000000000deadbeef","input":"0x","value":"0x0","type":"CALL"}`,
+		},
+		{
+			name:   "callTracer withKintoCalls",
+			tracer: mkTracer("callTracer", json.RawMessage(`{"withKintoCalls": true, "withLog": true}`)),
+			want:   `{"beforeEVMTransfers":[{"purpose":"feePayment","from":"0x000000000000000000000000000000000000FEeD","to":null,"value":"0x13880"}],"afterEVMTransfers":[{"purpose":"gasRefund","from":null,"to":"0x000000000000000000000000000000000000FEeD","value":"0xe4fb"},{"purpose":"tip","from":null,"to":"0x0000000000000000000000000000000000000000","value":"0x5385"}],"from":"0x000000000000000000000000000000000000feed","gas":"0x13880","gasUsed":"0x5385","to":"0x00000000000000000000000000000000deadbeef","input":"0x","calls":[{"from":"0x000000000000000000000000000000000000feed","gas":"0x186a0","gasUsed":"0x84b","to":"0x6e1b1a6220efc0671a6362ca3b5bb7bce60dc12b","input":"0x2357eda3000000000000000000000000000000000000000000000000000000000000feed00000000000000000000000000000000000000000000000000000000deadbeef000000000000000000000000000000000000000000000000000000000000008000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000","output":"0x0000000000000000000000000000000000000000000000000000000000000001","label":"kinto:isContractCallAllowedFromEOA","value":"0x0","type":"CALL"}],"logs":[{"address":"0x00000000000000000000000000000000deadbeef","topics":[],"data":"0x","position":"0x1"}],"value":"0x0","type":"CALL"}`,
+		},
+		{
+			name:   "prestateTracer",
+			tracer: mkTracer("prestateTracer", nil),
+			want:   `{"0x0000000000000000000000000000000000000000":{"balance":"0x0"},"0x000000000000000000000000000000000000feed":{"balance":"0x1c6bf52634000","nonce":1},"0x00000000000000000000000000000000deadbeef":{"balance":"0x0","code":"0x60006000a0"},"0x6e1b1a6220efc0671a6362ca3b5bb7bce60dc12b":{"balance":"0x0","code":"0x60015450600160005260206000f3","storage":{"0x0000000000000000000000000000000000000000000000000000000000000001":"0x0000000000000000000000000000000000000000000000000000000000000001"}}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false, rawdb.HashScheme)
+			defer state.Close()
+
+			evm := vm.NewEVM(context, txContext, state.StateDB, &config, vm.Config{Tracer: tc.tracer})
+			msg := &core.Message{
+				To:        &to,
+				From:      origin,
+				Nonce:     1,
+				Value:     big.NewInt(0),
+				GasLimit:  80000,
+				GasPrice:  big.NewInt(1),
+				GasFeeCap: big.NewInt(1),
+				GasTipCap: big.NewInt(1),
+				TxRunMode: core.MessageReplayMode,
+			}
+			st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+			if _, err := st.TransitionDb(); err != nil {
+				t.Fatalf("failed to execute transaction: %v", err)
+			}
+			res, err := tc.tracer.GetResult()
+			if err != nil {
+				t.Fatalf("failed to retrieve trace result: %v", err)
+			}
+			if string(res) != tc.want {
+				t.Errorf("trace mismatch\n have: %v\n want: %v\n", string(res), tc.want)
+			}
+			// The result is the same when retrieved again
+			if again, _ := tc.tracer.GetResult(); string(again) != string(res) {
+				t.Errorf("trace changed when retrieved again\n have: %v\n want: %v\n", string(again), string(res))
+			}
+		})
+	}
+}
+
+// TestKintoSelfDestructTracer checks the kintoSelfDestructTracer reports the
+// selfdestructs redirected by the Kinto rules of hardfork 2, but not those of
+// the reverted frames.
+func TestKintoSelfDestructTracer(t *testing.T) {
+	config := *params.TestChainConfig
+	config.KintoChainParams = params.KintoTestParams(10, 10)
+	var (
+		wallet    = config.Kinto().SelfDestructWallet
+		contract  = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+		requested = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+		caller    = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+		reverter  = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+		origin    = common.HexToAddress("0x00000000000000000000000000000000feed")
+		// Calls the contract and returns or reverts
+		callContract = append(append(common.FromHex("0x60006000600060006000"), byte(vm.PUSH20)), contract.Bytes()...)
+		context      = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: new(big.Int).SetUint64(config.Kinto().Hardfork2Block + 5),
+			Difficulty:  big.NewInt(0x30000),
+			GasLimit:    uint64(6000000),
+			BaseFee:     big.NewInt(0),
+		}
+		alloc = types.GenesisAlloc{
+			contract: types.Account{
+				Code:    append(append([]byte{byte(vm.PUSH20)}, requested.Bytes()...), byte(vm.SELFDESTRUCT)),
+				Balance: big.NewInt(1000),
+			},
+			caller:   types.Account{Code: append(callContract, byte(vm.GAS), byte(vm.CALL), byte(vm.STOP))},
+			reverter: types.Account{Code: append(callContract, common.FromHex("0x5af160006000fd")...)},
+			origin:   types.Account{Balance: big.NewInt(500000000000000)},
+		}
+		redirect = fmt.Sprintf(`[{"contract":"%s","requestedBeneficiary":"%s","beneficiary":"%s","value":"0x3e8"}]`,
+			strings.ToLower(contract.Hex()), strings.ToLower(requested.Hex()), strings.ToLower(wallet.Hex()))
+	)
+	for _, tc := range []struct {
+		name string
+		to   common.Address
+		want string
+	}{
+		{name: "selfdestruct", to: contract, want: redirect},
+		{name: "nested selfdestruct", to: caller, want: redirect},
+		{name: "reverted selfdestruct", to: reverter, want: `[]`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false, rawdb.HashScheme)
+			defer state.Close()
+
+			tracer, err := tracers.DefaultDirectory.New("kintoSelfDestructTracer", new(tracers.Context), nil)
+			if err != nil {
+				t.Fatalf("failed to create tracer: %v", err)
+			}
+			evm := vm.NewEVM(context, vm.TxContext{Origin: origin, GasPrice: big.NewInt(1)}, state.StateDB, &config, vm.Config{Tracer: tracer})
+			msg := &core.Message{
+				To:        &tc.to,
+				From:      origin,
+				Value:     big.NewInt(0),
+				GasLimit:  100000,
+				GasPrice:  big.NewInt(1),
+				GasFeeCap: big.NewInt(1),
+				GasTipCap: big.NewInt(1),
+				TxRunMode: core.MessageEthcallMode,
+			}
+			if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.GasLimit)); err != nil {
+				t.Fatalf("failed to execute transaction: %v", err)
+			}
+			res, err := tracer.GetResult()
+			if err != nil {
+				t.Fatalf("failed to retrieve trace result: %v", err)
+			}
+			if string(res) != tc.want {
+				t.Errorf("trace mismatch\n have: %v\n want: %v\n", string(res), tc.want)
+			}
+		})
+	}
+}
@@ -59,8 +59,12 @@ type callFrame struct {
 	Output       []byte          `json:"output,omitempty" rlp:"optional"`
 	Error        string          `json:"error,omitempty" rlp:"optional"`
 	RevertReason string          `json:"revertReason,omitempty"`
-	Calls        []callFrame     `json:"calls,omitempty" rlp:"optional"`
-	Logs         []callLog       `json:"logs,omitempty" rlp:"optional"`
+	Label        string          `json:"label,omitempty"` // Kinto: label of the calls made outside of the execution
+	// Kinto: beneficiary requested by a SELFDESTRUCT whose balance was sent to
+	// the SelfDestructWallet, the To of the frame
+	RequestedBeneficiary *common.Address `json:"requestedBeneficiary,omitempty"`
+	Calls                []callFrame     `json:"calls,omitempty" rlp:"optional"`
+	Logs                 []callLog       `json:"logs,omitempty" rlp:"optional"`
 
 	// Placed at end on purpose. The RLP will be decoded to 0 instead of
 	// nil if there are non-empty elements after in the struct.
@@ -111,6 +115,13 @@ type callTracer struct {
 	beforeEVMTransfers []arbitrumTransfer
 	afterEVMTransfers  []arbitrumTransfer
 
+	// Kinto: calls made by the Kinto rules before the execution
+	kintoCalls []callFrame
+	// Kinto: label of the call of the Kinto rules being traced, if any
+	kintoLabel string
+	// Kinto: beneficiary on the stack of the last SELFDESTRUCT
+	selfDestructBeneficiary common.Address
+
 	noopTracer
 	callstack []callFrame
 	config    callTracerConfig
@@ -122,6 +133,8 @@ type callTracer struct {
 type callTracerConfig struct {
 	OnlyTopCall bool `json:"onlyTopCall"` // If true, call tracer won't collect any subcalls
 	WithLog     bool `json:"withLog"`     // If true, call tracer will collect event logs
+
+	WithKintoCalls bool `json:"withKintoCalls"` // If true, call tracer will include the calls of the Kinto rules
 }
 
 // newCallTracer returns a native go tracer which tracks
@@ -157,11 +170,18 @@ func (t *callTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Ad
 	if create {
 		t.callstack[0].Type = vm.CREATE
 	}
+	// Kinto: the calls of the Kinto rules have their own gas
+	if t.kintoLabel != "" {
+		t.callstack[0].Gas = gas
+	}
 }
 
 // CaptureEnd is called after the call finishes to finalize the tracing.
 func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
 	t.callstack[0].processOutput(output, err)
+	if t.kintoLabel != "" {
+		t.callstack[0].GasUsed = gasUsed
+	}
 }
 
 // CaptureState implements the EVMLogger interface to trace a single step of VM execution.
@@ -170,6 +190,14 @@ func (t *callTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, sco
 	if err != nil {
 		return
 	}
+	// Kinto: the beneficiary of a selfdestruct may be redirected, keep the
+	// requested one for its frame
+	if op == vm.SELFDESTRUCT {
+		if stackData := scope.Stack.Data(); len(stackData) >= 1 {
+			t.selfDestructBeneficiary = common.Address(stackData[len(stackData)-1].Bytes20())
+		}
+		return
+	}
 	// Only logs need to be captured via opcode processing
 	if !t.config.WithLog {
 		return
@@ -234,6 +262,10 @@ func (t *callTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.
 		Gas:   gas,
 		Value: value,
 	}
+	if typ == vm.SELFDESTRUCT && to != t.selfDestructBeneficiary {
+		requested := t.selfDestructBeneficiary
+		call.RequestedBeneficiary = &requested
+	}
 	t.callstack = append(t.callstack, call)
 }
 
@@ -280,6 +312,20 @@ func (t *callTracer) GetResult() (json.RawMessage, error) {
 	call := t.callstack[0]
 	call.BeforeEVMTransfers = &t.beforeEVMTransfers
 	call.AfterEVMTransfers = &t.afterEVMTransfers
+	// Kinto: prepend the calls of the Kinto rules, leaving the callstack as is
+	// for the result to be retrieved again
+	if len(t.kintoCalls) > 0 {
+		calls := make([]callFrame, 0, len(t.kintoCalls)+len(call.Calls))
+		calls = append(calls, t.kintoCalls...)
+		call.Calls = append(calls, call.Calls...)
+
+		logs := make([]callLog, len(call.Logs))
+		for i, l := range call.Logs {
+			l.Position += hexutil.Uint(len(t.kintoCalls))
+			logs[i] = l
+		}
+		call.Logs = logs
+	}
 
 	res, err := json.Marshal(call)
 	if err != nil {
@@ -16,21 +16,23 @@ var _ = (*callFrameMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (c callFrame) MarshalJSON() ([]byte, error) {
 	type callFrame0 struct {
-		BeforeEVMTransfers *[]arbitrumTransfer `json:"beforeEVMTransfers,omitempty"`
-		AfterEVMTransfers  *[]arbitrumTransfer `json:"afterEVMTransfers,omitempty"`
-		Type               vm.OpCode           `json:"-"`
-		From               common.Address      `json:"from"`
-		Gas                hexutil.Uint64      `json:"gas"`
-		GasUsed            hexutil.Uint64      `json:"gasUsed"`
-		To                 *common.Address     `json:"to,omitempty" rlp:"optional"`
-		Input              hexutil.Bytes       `json:"input" rlp:"optional"`
-		Output             hexutil.Bytes       `json:"output,omitempty" rlp:"optional"`
-		Error              string              `json:"error,omitempty" rlp:"optional"`
-		RevertReason       string              `json:"revertReason,omitempty"`
-		Calls              []callFrame         `json:"calls,omitempty" rlp:"optional"`
-		Logs               []callLog           `json:"logs,omitempty" rlp:"optional"`
-		Value              *hexutil.Big        `json:"value,omitempty" rlp:"optional"`
-		TypeString         string              `json:"type"`
+		BeforeEVMTransfers   *[]arbitrumTransfer `json:"beforeEVMTransfers,omitempty"`
+		AfterEVMTransfers    *[]arbitrumTransfer `json:"afterEVMTransfers,omitempty"`
+		Type                 vm.OpCode           `json:"-"`
+		From                 common.Address      `json:"from"`
+		Gas                  hexutil.Uint64      `json:"gas"`
+		GasUsed              hexutil.Uint64      `json:"gasUsed"`
+		To                   *common.Address     `json:"to,omitempty" rlp:"optional"`
+		Input                hexutil.Bytes       `json:"input" rlp:"optional"`
+		Output               hexutil.Bytes       `json:"output,omitempty" rlp:"optional"`
+		Error                string              `json:"error,omitempty" rlp:"optional"`
+		RevertReason         string              `json:"revertReason,omitempty"`
+		Label                string              `json:"label,omitempty"`
+		RequestedBeneficiary *common.Address     `json:"requestedBeneficiary,omitempty"`
+		Calls                []callFrame         `json:"calls,omitempty" rlp:"optional"`
+		Logs                 []callLog           `json:"logs,omitempty" rlp:"optional"`
+		Value                *hexutil.Big        `json:"value,omitempty" rlp:"optional"`
+		TypeString           string              `json:"type"`
 	}
 	var enc callFrame0
 	enc.BeforeEVMTransfers = c.BeforeEVMTransfers
@@ -44,6 +46,8 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 	enc.Output = c.Output
 	enc.Error = c.Error
 	enc.RevertReason = c.RevertReason
+	enc.Label = c.Label
+	enc.RequestedBeneficiary = c.RequestedBeneficiary
 	enc.Calls = c.Calls
 	enc.Logs = c.Logs
 	enc.Value = (*hexutil.Big)(c.Value)
@@ -54,20 +58,22 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (c *callFrame) UnmarshalJSON(input []byte) error {
 	type callFrame0 struct {
-		BeforeEVMTransfers *[]arbitrumTransfer `json:"beforeEVMTransfers,omitempty"`
-		AfterEVMTransfers  *[]arbitrumTransfer `json:"afterEVMTransfers,omitempty"`
-		Type               *vm.OpCode          `json:"-"`
-		From               *common.Address     `json:"from"`
-		Gas                *hexutil.Uint64     `json:"gas"`
-		GasUsed            *hexutil.Uint64     `json:"gasUsed"`
-		To                 *common.Address     `json:"to,omitempty" rlp:"optional"`
-		Input              *hexutil.Bytes      `json:"input" rlp:"optional"`
-		Output             *hexutil.Bytes      `json:"output,omitempty" rlp:"optional"`
-		Error              *string             `json:"error,omitempty" rlp:"optional"`
-		RevertReason       *string             `json:"revertReason,omitempty"`
-		Calls              []callFrame         `json:"calls,omitempty" rlp:"optional"`
-		Logs               []callLog           `json:"logs,omitempty" rlp:"optional"`
-		Value              *hexutil.Big        `json:"value,omitempty" rlp:"optional"`
+		BeforeEVMTransfers   *[]arbitrumTransfer `json:"beforeEVMTransfers,omitempty"`
+		AfterEVMTransfers    *[]arbitrumTransfer `json:"afterEVMTransfers,omitempty"`
+		Type                 *vm.OpCode          `json:"-"`
+		From                 *common.Address     `json:"from"`
+		Gas                  *hexutil.Uint64     `json:"gas"`
+		GasUsed              *hexutil.Uint64     `json:"gasUsed"`
+		To                   *common.Address     `json:"to,omitempty" rlp:"optional"`
+		Input                *hexutil.Bytes      `json:"input" rlp:"optional"`
+		Output               *hexutil.Bytes      `json:"output,omitempty" rlp:"optional"`
+		Error                *string             `json:"error,omitempty" rlp:"optional"`
+		RevertReason         *string             `json:"revertReason,omitempty"`
+		Label                *string             `json:"label,omitempty"`
+		RequestedBeneficiary *common.Address     `json:"requestedBeneficiary,omitempty"`
+		Calls                []callFrame         `json:"calls,omitempty" rlp:"optional"`
+		Logs                 []callLog           `json:"logs,omitempty" rlp:"optional"`
+		Value                *hexutil.Big        `json:"value,omitempty" rlp:"optional"`
 	}
 	var dec callFrame0
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -106,6 +112,12 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 	if dec.RevertReason != nil {
 		c.RevertReason = *dec.RevertReason
 	}
+	if dec.Label != nil {
+		c.Label = *dec.Label
+	}
+	if dec.RequestedBeneficiary != nil {
+		c.RequestedBeneficiary = dec.RequestedBeneficiary
+	}
 	if dec.Calls != nil {
 		c.Calls = dec.Calls
 	}
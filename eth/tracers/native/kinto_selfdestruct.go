@@ -0,0 +1,122 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("kintoSelfDestructTracer", newKintoSelfDestructTracer, false)
+}
+
+// kintoSelfDestructRedirect is a selfdestruct whose balance was sent to the
+// SelfDestructWallet by the Kinto rules instead of the requested beneficiary.
+type kintoSelfDestructRedirect struct {
+	Contract             common.Address `json:"contract"`
+	RequestedBeneficiary common.Address `json:"requestedBeneficiary"`
+	Beneficiary          common.Address `json:"beneficiary"`
+	Value                *hexutil.Big   `json:"value"`
+}
+
+// kintoSelfDestructTracer collects the selfdestructs of a transaction whose
+// balance was redirected by the Kinto rules. The redirects of the reverted
+// frames are dropped, their balance having not moved.
+type kintoSelfDestructTracer struct {
+	noopTracer
+	requested common.Address // beneficiary on the stack of the last SELFDESTRUCT
+	frames    []int          // first redirect of every entered frame
+	redirects []kintoSelfDestructRedirect
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newKintoSelfDestructTracer returns a native go tracer which lists the
+// selfdestructs redirected to the SelfDestructWallet.
+func newKintoSelfDestructTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &kintoSelfDestructTracer{redirects: []kintoSelfDestructRedirect{}}, nil
+}
+
+// CaptureArbitrumTransfer records the redirected balances of the selfdestructs.
+func (t *kintoSelfDestructTracer) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.Address, value *big.Int, before bool, purpose string) {
+	if purpose != vm.SelfDestructRedirectTransfer || from == nil || to == nil || t.interrupt.Load() {
+		return
+	}
+	t.redirects = append(t.redirects, kintoSelfDestructRedirect{
+		Contract:             *from,
+		RequestedBeneficiary: t.requested,
+		Beneficiary:          *to,
+		Value:                (*hexutil.Big)(new(big.Int).Set(value)),
+	})
+}
+
+// CaptureState records the beneficiary requested by a SELFDESTRUCT.
+func (t *kintoSelfDestructTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if op != vm.SELFDESTRUCT || err != nil {
+		return
+	}
+	if stackData := scope.Stack.Data(); len(stackData) >= 1 {
+		t.requested = common.Address(stackData[len(stackData)-1].Bytes20())
+	}
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *kintoSelfDestructTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames, len(t.redirects))
+}
+
+// CaptureExit drops the redirects of the frame if it reverted.
+func (t *kintoSelfDestructTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	start := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if err != nil {
+		t.redirects = t.redirects[:start]
+	}
+}
+
+// CaptureEnd drops the redirects of the transaction if it reverted.
+func (t *kintoSelfDestructTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if err != nil {
+		t.redirects = t.redirects[:0]
+	}
+}
+
+// GetResult returns the json-encoded list of the redirected selfdestructs, and
+// any error arising from the encoding or forceful termination (via `Stop`).
+func (t *kintoSelfDestructTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.redirects)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *kintoSelfDestructTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
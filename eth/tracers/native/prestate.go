@@ -67,6 +67,7 @@ type prestateTracer struct {
 	reason    error       // Textual reason for the interruption
 	created   map[common.Address]bool
 	deleted   map[common.Address]bool
+	kintoCall bool // Kinto: whether a call of the Kinto rules is being traced
 }
 
 type prestateTracerConfig struct {
@@ -92,6 +93,12 @@ func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Trace
 // CaptureStart implements the EVMLogger interface to initialize the tracing operation.
 func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
 	t.env = env
+	// Kinto: the calls of the Kinto rules are made before the sender nonce is
+	// incremented, it's looked up with the top call of the transaction
+	if t.kintoCall {
+		t.lookupAccount(to)
+		return
+	}
 	t.create = create
 	t.to = to
 
@@ -119,7 +126,7 @@ func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to commo
 
 // CaptureEnd is called after the call finishes to finalize the tracing.
 func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
-	if t.config.DiffMode {
+	if t.config.DiffMode || t.kintoCall {
 		return
 	}
 
@@ -0,0 +1,78 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+var (
+	_ vm.KintoCallLogger = (*callTracer)(nil)
+	_ vm.KintoCallLogger = (*prestateTracer)(nil)
+	_ vm.KintoCallLogger = (*muxTracer)(nil)
+)
+
+// CaptureKintoCallStart marks the top call frame traced next as a call of the
+// Kinto rules.
+func (t *callTracer) CaptureKintoCallStart(label string) {
+	t.kintoLabel = label
+}
+
+// CaptureKintoCallEnd records the call of the Kinto rules as a labeled call of
+// the top frame, preceding the calls of the execution, if the tracer is
+// configured withKintoCalls.
+func (t *callTracer) CaptureKintoCallEnd() {
+	call := t.callstack[0]
+	call.Label = t.kintoLabel
+	t.kintoLabel = ""
+	t.callstack = append(t.callstack[:0], callFrame{})
+
+	if !t.config.WithKintoCalls || t.config.OnlyTopCall || t.interrupt.Load() {
+		return
+	}
+	if t.config.WithLog {
+		clearFailedLogs(&call, false)
+	}
+	t.kintoCalls = append(t.kintoCalls, call)
+}
+
+// CaptureKintoCallStart marks the top call frame traced next as a call of the
+// Kinto rules. The state it reads is part of the prestate, but it doesn't pay
+// for the gas and value of the transaction.
+func (t *prestateTracer) CaptureKintoCallStart(label string) {
+	t.kintoCall = true
+}
+
+func (t *prestateTracer) CaptureKintoCallEnd() {
+	t.kintoCall = false
+}
+
+func (t *muxTracer) CaptureKintoCallStart(label string) {
+	for _, t := range t.tracers {
+		if t, ok := t.(vm.KintoCallLogger); ok {
+			t.CaptureKintoCallStart(label)
+		}
+	}
+}
+
+func (t *muxTracer) CaptureKintoCallEnd() {
+	for _, t := range t.tracers {
+		if t, ok := t.(vm.KintoCallLogger); ok {
+			t.CaptureKintoCallEnd()
+		}
+	}
+}
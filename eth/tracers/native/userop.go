@@ -0,0 +1,254 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("userOpTracer", newUserOpTracer, false)
+}
+
+var (
+	// handleOpsSelectors are the selectors of handleOps of the EntryPoint v0.6
+	// and v0.7.
+	handleOpsSelectors = [][]byte{
+		crypto.Keccak256([]byte("handleOps((address,uint256,bytes,bytes,uint256,uint256,uint256,uint256,uint256,bytes,bytes)[],address)"))[:4],
+		crypto.Keccak256([]byte("handleOps((address,uint256,bytes,bytes,bytes32,uint256,bytes32,bytes,bytes)[],address)"))[:4],
+	}
+	userOperationRevertReasonTopic = crypto.Keccak256Hash([]byte("UserOperationRevertReason(bytes32,address,uint256,bytes)"))
+)
+
+// userOpBundle is a handleOps call of an EntryPoint, split by user operation.
+type userOpBundle struct {
+	EntryPoint common.Address `json:"entryPoint"`
+	Error      string         `json:"error,omitempty"`
+	UserOps    []userOpFrame  `json:"userOps"`
+}
+
+// userOpFrame holds the outcome of a user operation, from its
+// UserOperationEvent, and the call frames made by the EntryPoint for it.
+type userOpFrame struct {
+	UserOpHash    common.Hash    `json:"userOpHash"`
+	Sender        common.Address `json:"sender"`
+	Nonce         *hexutil.Big   `json:"nonce"`
+	Paymaster     common.Address `json:"paymaster"`
+	Success       bool           `json:"success"`
+	ActualGasCost *hexutil.Big   `json:"actualGasCost"`
+	ActualGasUsed *hexutil.Big   `json:"actualGasUsed"`
+	RevertReason  hexutil.Bytes  `json:"revertReason,omitempty"`
+
+	// Validation holds the calls of the validation phase: the deployment of
+	// the sender, and the validation by the sender and the paymaster.
+	Validation []callFrame `json:"validation"`
+	// Execution holds the inner call of the EntryPoint executing the operation,
+	// and the postOp call of the paymaster when the execution reverted.
+	Execution []callFrame `json:"execution"`
+}
+
+// userOpTracer reports the ERC-4337 bundles executed by a transaction, with the
+// call frames of the callTracer grouped by user operation. Transactions not
+// calling handleOps result in an empty list.
+type userOpTracer struct {
+	*callTracer
+}
+
+// newUserOpTracer returns a native go tracer splitting the handleOps calls of
+// a transaction by user operation.
+func newUserOpTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	tracer, err := newCallTracer(ctx, json.RawMessage(`{"withLog":true}`))
+	if err != nil {
+		return nil, err
+	}
+	return &userOpTracer{tracer.(*callTracer)}, nil
+}
+
+// GetResult returns the json-encoded list of the bundles executed by the
+// transaction, and any error arising from the encoding or forceful termination
+// (via `Stop`).
+func (t *userOpTracer) GetResult() (json.RawMessage, error) {
+	if len(t.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	bundles := []userOpBundle{}
+	collectBundles(&t.callstack[0], &bundles)
+
+	res, err := json.Marshal(bundles)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// collectBundles appends the handleOps calls of the frame and its subcalls.
+// The contracts forwarding a handleOps call as is to the EntryPoint aren't
+// bundles themselves.
+func collectBundles(frame *callFrame, bundles *[]userOpBundle) {
+	if frame.To != nil && len(frame.Input) >= 4 && !forwardsInput(frame) {
+		for _, selector := range handleOpsSelectors {
+			if bytes.Equal(frame.Input[:4], selector) {
+				*bundles = append(*bundles, splitBundle(frame))
+				return
+			}
+		}
+	}
+	for i := range frame.Calls {
+		collectBundles(&frame.Calls[i], bundles)
+	}
+}
+
+// forwardsInput reports whether the frame calls another contract with its own
+// input.
+func forwardsInput(frame *callFrame) bool {
+	for _, call := range frame.Calls {
+		if call.Type == vm.CALL && call.To != nil && *call.To != *frame.To && bytes.Equal(call.Input, frame.Input) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitBundle splits a handleOps call frame by user operation. The operations
+// are identified by the UserOperationEvents the EntryPoint emits in order, the
+// validation calls are matched to them by target, and the inner calls of the
+// EntryPoint to itself are the executions of the operations in order.
+func splitBundle(frame *callFrame) userOpBundle {
+	entryPoint := *frame.To
+	bundle := userOpBundle{EntryPoint: entryPoint, Error: frame.Error, UserOps: []userOpFrame{}}
+
+	reasons := make(map[common.Hash][]byte)
+	walkLogs(frame, func(l *callLog) {
+		if l.Address != entryPoint || len(l.Topics) < 2 {
+			return
+		}
+		switch {
+		case l.Topics[0] == core.UserOperationEventTopic && len(l.Topics) == 4 && len(l.Data) == 4*32:
+			bundle.UserOps = append(bundle.UserOps, userOpFrame{
+				UserOpHash:    l.Topics[1],
+				Sender:        common.BytesToAddress(l.Topics[2].Bytes()),
+				Paymaster:     common.BytesToAddress(l.Topics[3].Bytes()),
+				Nonce:         (*hexutil.Big)(new(big.Int).SetBytes(l.Data[:32])),
+				Success:       new(big.Int).SetBytes(l.Data[32:64]).Sign() != 0,
+				ActualGasCost: (*hexutil.Big)(new(big.Int).SetBytes(l.Data[64:96])),
+				ActualGasUsed: (*hexutil.Big)(new(big.Int).SetBytes(l.Data[96:128])),
+				Validation:    []callFrame{},
+				Execution:     []callFrame{},
+			})
+		case l.Topics[0] == userOperationRevertReasonTopic:
+			reasons[l.Topics[1]] = decodeRevertReason(l.Data)
+		}
+	})
+	ops := bundle.UserOps
+	if len(ops) == 0 {
+		return bundle
+	}
+	for i := range ops {
+		ops[i].RevertReason = reasons[ops[i].UserOpHash]
+	}
+	var (
+		validated = -1        // index of the last operation matched by a validation call
+		unmatched []callFrame // validation calls preceding the next matched call
+		executed  = -1        // index of the last operation executed
+	)
+	for _, call := range frame.Calls {
+		if call.To != nil && *call.To == entryPoint {
+			// Inner call of the EntryPoint executing the next operation
+			if executed+1 < len(ops) {
+				executed++
+				ops[executed].Execution = append(ops[executed].Execution, call)
+			}
+			continue
+		}
+		if executed >= 0 {
+			// Calls made after an execution are postOp calls of its paymaster,
+			// others being the compensation of the beneficiary
+			if op := &ops[executed]; call.To != nil && *call.To == op.Paymaster && op.Paymaster != (common.Address{}) {
+				op.Execution = append(op.Execution, call)
+			}
+			continue
+		}
+		matched, start := -1, validated
+		if start < 0 {
+			start = 0
+		}
+		for j := start; j < len(ops) && call.To != nil; j++ {
+			if *call.To == ops[j].Sender || (*call.To == ops[j].Paymaster && ops[j].Paymaster != (common.Address{})) {
+				matched = j
+				break
+			}
+		}
+		if matched < 0 {
+			// The deployment of the sender precedes its validation
+			unmatched = append(unmatched, call)
+			continue
+		}
+		validated = matched
+		ops[matched].Validation = append(append(ops[matched].Validation, unmatched...), call)
+		unmatched = nil
+	}
+	if len(unmatched) > 0 {
+		if validated < 0 {
+			validated = 0
+		}
+		ops[validated].Validation = append(ops[validated].Validation, unmatched...)
+	}
+	return bundle
+}
+
+// walkLogs calls fn on the logs of the frame and its subcalls, in the order
+// they were emitted.
+func walkLogs(frame *callFrame, fn func(*callLog)) {
+	next := 0
+	for i := range frame.Calls {
+		for ; next < len(frame.Logs) && int(frame.Logs[next].Position) <= i; next++ {
+			fn(&frame.Logs[next])
+		}
+		walkLogs(&frame.Calls[i], fn)
+	}
+	for ; next < len(frame.Logs); next++ {
+		fn(&frame.Logs[next])
+	}
+}
+
+// decodeRevertReason returns the revertReason of the data of a
+// UserOperationRevertReason event, (uint256 nonce, bytes revertReason).
+func decodeRevertReason(data []byte) []byte {
+	if len(data) < 3*32 {
+		return nil
+	}
+	offset := new(big.Int).SetBytes(data[32:64])
+	if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(data)) {
+		return nil
+	}
+	start := offset.Uint64() + 32
+	size := new(big.Int).SetBytes(data[start-32 : start])
+	if !size.IsUint64() || start+size.Uint64() > uint64(len(data)) {
+		return nil
+	}
+	return common.CopyBytes(data[start : start+size.Uint64()])
+}
@@ -333,7 +333,9 @@ func (ec *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, err
 }
 
 // SubscribeNewHead subscribes to notifications about the current blockchain head
-// on the given channel.
+// on the given channel. If the client was dialed with rpc.WithReconnect, the
+// subscription is re-established after a connection loss and keeps delivering on
+// the same channel.
 func (ec *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
 	sub, err := ec.c.EthSubscribe(ctx, ch, "newHeads")
 	if err != nil {
@@ -433,7 +435,9 @@ func (ec *Client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]typ
 	return result, err
 }
 
-// SubscribeFilterLogs subscribes to the results of a streaming filter query.
+// SubscribeFilterLogs subscribes to the results of a streaming filter query. If the
+// client was dialed with rpc.WithReconnect, the subscription is re-established after
+// a connection loss and keeps delivering on the same channel.
 func (ec *Client) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
 	arg, err := toFilterArg(q)
 	if err != nil {
@@ -0,0 +1,48 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package arbera
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	ssz "github.com/ferranbt/fastssz"
+)
+
+// ComputeAccumulator calculates the SSZ hash tree root of the ArbEra
+// accumulator of block hashes.
+func ComputeAccumulator(hashes []common.Hash) (common.Hash, error) {
+	if len(hashes) > MaxSize {
+		return common.Hash{}, fmt.Errorf("too many records: have %d, max %d", len(hashes), MaxSize)
+	}
+	hh := ssz.NewHasher()
+	for i := range hashes {
+		hh.Append(hashes[i][:])
+	}
+	hh.MerkleizeWithMixin(0, uint64(len(hashes)), uint64(MaxSize))
+	return hh.HashRoot()
+}
+
+// uint256LE converts a big.Int into a little-endian 32-byte array.
+func uint256LE(n *big.Int) (b [32]byte) {
+	n.FillBytes(b[:])
+	for i := 0; i < 16; i++ {
+		b[i], b[32-i-1] = b[32-i-1], b[i]
+	}
+	return
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package arbera
+
+import (
+	"math/big"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// makeChain creates a chain of blocks carrying Arbitrum transactions and
+// receipts with Arbitrum specific fields set.
+func makeChain(start uint64, n int) ([]*types.Block, []types.Receipts) {
+	var (
+		blocks   []*types.Block
+		receipts []types.Receipts
+		parent   common.Hash
+		chainID  = big.NewInt(412346)
+	)
+	for i := 0; i < n; i++ {
+		number := start + uint64(i)
+		txs := []*types.Transaction{
+			types.NewTx(&types.ArbitrumUnsignedTx{
+				ChainId:   chainID,
+				From:      common.Address{0x01},
+				Nonce:     number,
+				GasFeeCap: big.NewInt(1),
+				Gas:       100000,
+				Value:     common.Big0,
+			}),
+			types.NewTx(&types.DynamicFeeTx{
+				ChainID:   chainID,
+				Nonce:     number,
+				GasTipCap: common.Big0,
+				GasFeeCap: big.NewInt(1),
+				Gas:       21000,
+				To:        &common.Address{0x02},
+				Value:     big.NewInt(int64(i)),
+			}),
+		}
+		rs := types.Receipts{
+			{
+				Type:              types.ArbitrumUnsignedTxType,
+				Status:            types.ReceiptStatusSuccessful,
+				CumulativeGasUsed: 50000,
+				GasUsedForL1:      1000 + uint64(i),
+				ContractAddress:   common.Address{0x03, byte(i)},
+				Logs:              []*types.Log{{Address: common.Address{0x03, byte(i)}, Topics: []common.Hash{{0x04}}, Data: []byte{byte(i)}}},
+			},
+			{
+				Type:              types.DynamicFeeTxType,
+				Status:            types.ReceiptStatusFailed,
+				CumulativeGasUsed: 71000,
+				GasUsedForL1:      7,
+				Logs:              []*types.Log{},
+			},
+		}
+		for _, r := range rs {
+			r.Bloom = types.CreateBloom(types.Receipts{r})
+		}
+		header := &types.Header{
+			ParentHash: parent,
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: common.Big1,
+			GasLimit:   1 << 50,
+			BaseFee:    big.NewInt(100000000),
+		}
+		block := types.NewBlock(header, txs, nil, rs, trie.NewStackTrie(nil))
+		parent = block.Hash()
+		blocks = append(blocks, block)
+		receipts = append(receipts, rs)
+	}
+	return blocks, receipts
+}
+
+func TestArbEraRoundTrip(t *testing.T) {
+	var (
+		dir              = t.TempDir()
+		chainID          = big.NewInt(412346)
+		blocks, receipts = makeChain(22207817, 16)
+		filename         = path.Join(dir, Filename("kinto", 2710, common.Hash{}))
+	)
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	builder := NewBuilder(f, chainID)
+	for i := range blocks {
+		if err := builder.Add(blocks[i], receipts[i]); err != nil {
+			t.Fatalf("error adding block %d: %v", i, err)
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("error finalizing: %v", err)
+	}
+	f.Close()
+
+	entries, err := ReadDir(dir, "kinto")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("failed to read dir: %v %v", entries, err)
+	}
+	e, err := Open(path.Join(dir, entries[0]))
+	if err != nil {
+		t.Fatalf("failed to open arbera: %v", err)
+	}
+	defer e.Close()
+
+	if e.Start() != blocks[0].NumberU64() || e.Count() != uint64(len(blocks)) {
+		t.Fatalf("metadata mismatch: have start %d count %d", e.Start(), e.Count())
+	}
+	if id, err := e.ChainID(); err != nil || id.Cmp(chainID) != 0 {
+		t.Fatalf("chain id mismatch: have %v, want %v (err %v)", id, chainID, err)
+	}
+	if have, err := e.Accumulator(); err != nil || have != root {
+		t.Fatalf("accumulator mismatch: have %x, want %x (err %v)", have, root, err)
+	}
+	if err := Verify(e); err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+	it, err := NewIterator(e)
+	if err != nil {
+		t.Fatalf("failed to create iterator: %v", err)
+	}
+	for i := 0; it.Next(); i++ {
+		block, rs, err := it.BlockAndReceipts()
+		if err != nil {
+			t.Fatalf("block %d: failed to read: %v", i, err)
+		}
+		if block.Hash() != blocks[i].Hash() {
+			t.Fatalf("block %d: hash mismatch", i)
+		}
+		for j, want := range receipts[i] {
+			have := rs[j]
+			if have.Type != want.Type || have.GasUsedForL1 != want.GasUsedForL1 || have.Status != want.Status || have.CumulativeGasUsed != want.CumulativeGasUsed {
+				t.Errorf("block %d receipt %d: mismatch: have %+v, want %+v", i, j, have, want)
+			}
+			if want.Type == types.ArbitrumUnsignedTxType && have.ContractAddress != want.ContractAddress {
+				t.Errorf("block %d receipt %d: contract address mismatch: have %x, want %x", i, j, have.ContractAddress, want.ContractAddress)
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iterator error: %v", err)
+	}
+	if block, err := e.GetBlockByNumber(blocks[5].NumberU64()); err != nil || block.Hash() != blocks[5].Hash() {
+		t.Fatalf("failed to read block by number: %v", err)
+	}
+}
+
+func TestArbEraNonContiguous(t *testing.T) {
+	blocks, receipts := makeChain(100, 2)
+	builder := NewBuilder(new(nopWriter), common.Big1)
+	if err := builder.Add(blocks[1], receipts[1]); err != nil {
+		t.Fatalf("failed to add first block: %v", err)
+	}
+	if err := builder.Add(blocks[0], receipts[0]); err == nil {
+		t.Fatal("expected error adding non-contiguous block")
+	}
+}
+
+type nopWriter struct{}
+
+func (nopWriter) Write(b []byte) (int, error) { return len(b), nil }
@@ -0,0 +1,89 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package arbera
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Verify checks that the ArbEra is well-formed and that its accumulator matches
+// the data it contains. To fully verify an archive the following attributes
+// must be checked:
+//
+//  1. the block index is constructed correctly
+//  2. the tx root matches the value in the block
+//  3. the receipt root matches the value in the block
+//  4. the blocks link up through their parent hashes
+//  5. the accumulator is correct by recomputing it locally, which verifies
+//     the blocks are all correct (via hash)
+//
+// The attributes 1) to 4) are checked for each block. 5) requires accumulation
+// across the entire set and is verified at the end.
+func Verify(e *Era) error {
+	want, err := e.Accumulator()
+	if err != nil {
+		return fmt.Errorf("error reading accumulator: %w", err)
+	}
+	it, err := NewIterator(e)
+	if err != nil {
+		return fmt.Errorf("error making arbera iterator: %w", err)
+	}
+	var (
+		hashes = make([]common.Hash, 0, e.Count())
+		parent common.Hash
+	)
+	for it.Next() {
+		// 1) next() walks the block index, so we're able to implicitly verify it.
+		if err := it.Error(); err != nil {
+			return fmt.Errorf("error reading block %d: %w", it.Number(), err)
+		}
+		block, receipts, err := it.BlockAndReceipts()
+		if err != nil {
+			return fmt.Errorf("error reading block %d: %w", it.Number(), err)
+		}
+		// 2) recompute tx root and verify against header.
+		if tr := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); tr != block.TxHash() {
+			return fmt.Errorf("tx root in block %d mismatch: want %s, got %s", block.NumberU64(), block.TxHash(), tr)
+		}
+		// 3) recompute receipt root and check value against block.
+		if rr := types.DeriveSha(receipts, trie.NewStackTrie(nil)); rr != block.ReceiptHash() {
+			return fmt.Errorf("receipt root in block %d mismatch: want %s, got %s", block.NumberU64(), block.ReceiptHash(), rr)
+		}
+		// 4) check the chain links up.
+		if len(hashes) > 0 && block.ParentHash() != parent {
+			return fmt.Errorf("block %d parent mismatch: want %s, got %s", block.NumberU64(), parent, block.ParentHash())
+		}
+		parent = block.Hash()
+		hashes = append(hashes, parent)
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	// 5) Verify accumulator.
+	got, err := ComputeAccumulator(hashes)
+	if err != nil {
+		return fmt.Errorf("error computing accumulator: %w", err)
+	}
+	if got != want {
+		return fmt.Errorf("expected accumulator root does not match calculated: got %s, want %s", got, want)
+	}
+	return nil
+}
@@ -190,6 +190,16 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'methodRules',
+			call: 'admin_methodRules',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setMethodRules',
+			call: 'admin_setMethodRules',
+			params: 2
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
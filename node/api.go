@@ -179,6 +179,7 @@ func (api *adminAPI) StartHTTP(host *string, port *int, cors *string, apis *stri
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			methodFilter:           api.node.methodFilters[TransportHTTP],
 		},
 	}
 	if cors != nil {
@@ -257,6 +258,7 @@ func (api *adminAPI) StartWS(host *string, port *int, allowedOrigins *string, ap
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			methodFilter:           api.node.methodFilters[TransportWS],
 		},
 	}
 	if apis != nil {
@@ -295,6 +297,22 @@ func (api *adminAPI) StopWS() (bool, error) {
 	return true, nil
 }
 
+// MethodRules returns the rules selecting the methods served on the given
+// transport: http, ws or ipc.
+func (api *adminAPI) MethodRules(transport string) (rpc.MethodRules, error) {
+	return api.node.MethodRules(transport)
+}
+
+// SetMethodRules replaces the rules selecting the methods served on the given
+// transport: http, ws or ipc. The running endpoints apply them immediately.
+func (api *adminAPI) SetMethodRules(transport string, rules rpc.MethodRules) (bool, error) {
+	if err := api.node.SetMethodRules(transport, rules); err != nil {
+		return false, err
+	}
+	api.node.log.Info("Updated RPC method rules", "transport", transport, "allow", rules.Allow, "deny", rules.Deny)
+	return true, nil
+}
+
 // Peers retrieves all the information we know about each individual peer at the
 // protocol granularity.
 func (api *adminAPI) Peers() ([]*p2p.PeerInfo, error) {
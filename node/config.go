@@ -214,6 +214,28 @@ type Config struct {
 	// BatchResponseMaxSize is the maximum number of bytes returned from a batched rpc call.
 	BatchResponseMaxSize int `toml:",omitempty"`
 
+	// HTTPMethodRules, WSMethodRules and IPCMethodRules select the methods served
+	// on the respective transports, by name or wildcard pattern. The rules can be
+	// replaced at runtime with admin_setMethodRules.
+	HTTPMethodRules rpc.MethodRules `toml:",omitempty"`
+	WSMethodRules   rpc.MethodRules `toml:",omitempty"`
+	IPCMethodRules  rpc.MethodRules `toml:",omitempty"`
+
+	// RPCCapture configures the recording of the calls served by the HTTP and
+	// WebSocket endpoints, for replaying them with rpcreplay. Recording is
+	// disabled if no path is set. If no redacted methods are configured, the
+	// parameters of rpc.DefaultRedactedMethods are redacted.
+	RPCCapture rpc.RecorderConfig `toml:",omitempty"`
+
+	// RPCRateLimit configures the per-client rate limiting of the HTTP and
+	// WebSocket RPC endpoints. The authenticated endpoints are not limited.
+	RPCRateLimit RateLimitConfig `toml:",omitempty"`
+
+	// RPCExecutionLimits bounds the concurrent executions and the execution time
+	// of the calls of the HTTP, WebSocket and IPC RPC endpoints, by method name or
+	// by namespace. The endpoints share the limits.
+	RPCExecutionLimits map[string]rpc.ExecutionLimit `toml:",omitempty"`
+
 	// JWTSecret is the path to the hex-encoded jwt secret.
 	JWTSecret string `toml:",omitempty"`
 
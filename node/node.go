@@ -71,9 +71,21 @@ type Node struct {
 
 	databases map[*closeTrackingDB]struct{} // All open databases
 
-	apiFilter map[string]bool // Whitelisting API methods
+	apiFilter     map[string]bool              // Whitelisting API methods
+	methodFilters map[string]*rpc.MethodFilter // Method rules of the public endpoints, by transport
+	responseCache rpc.ResponseCache            // Cache of immutable results of the public endpoints
+	recorder      *rpc.Recorder                // Capture of the calls of the public endpoints
+
+	executionLimiter *rpc.ExecutionLimiter // Execution limits shared by the public endpoints
 }
 
+// The transports whose methods can be selected by method rules.
+const (
+	TransportHTTP = "http"
+	TransportWS   = "ws"
+	TransportIPC  = "ipc"
+)
+
 const (
 	initializingState = iota
 	runningState
@@ -155,11 +167,30 @@ func New(conf *Config) (*Node, error) {
 	}
 
 	// Configure RPC servers.
+	node.methodFilters = make(map[string]*rpc.MethodFilter)
+	for transport, rules := range map[string]rpc.MethodRules{
+		TransportHTTP: conf.HTTPMethodRules,
+		TransportWS:   conf.WSMethodRules,
+		TransportIPC:  conf.IPCMethodRules,
+	} {
+		filter, err := rpc.NewMethodFilter(rules)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s method rules: %v", transport, err)
+		}
+		node.methodFilters[transport] = filter
+	}
 	node.http = newHTTPServer(node.log, conf.HTTPTimeouts)
 	node.httpAuth = newHTTPServer(node.log, conf.HTTPTimeouts)
 	node.ws = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
 	node.wsAuth = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
-	node.ipc = newIPCServer(node.log, conf.IPCEndpoint())
+	if len(conf.RPCExecutionLimits) > 0 {
+		limiter, err := rpc.NewExecutionLimiter(conf.RPCExecutionLimits)
+		if err != nil {
+			return nil, err
+		}
+		node.executionLimiter = limiter
+	}
+	node.ipc = newIPCServer(node.log, conf.IPCEndpoint(), node.methodFilters[TransportIPC], node.executionLimiter)
 
 	return node, nil
 }
@@ -387,6 +418,32 @@ func (n *Node) ApplyAPIFilter(apiFilter map[string]bool) {
 	n.apiFilter = apiFilter
 }
 
+// SetResponseCache sets the cache serving the results of repeated calls on the
+// HTTP and WebSocket endpoints. It must be called before the node is started.
+func (n *Node) SetResponseCache(cache rpc.ResponseCache) {
+	n.responseCache = cache
+}
+
+// MethodRules returns the rules selecting the methods served on the given transport.
+func (n *Node) MethodRules(transport string) (rpc.MethodRules, error) {
+	filter, ok := n.methodFilters[transport]
+	if !ok {
+		return rpc.MethodRules{}, fmt.Errorf("unknown transport %q", transport)
+	}
+	return filter.Rules(), nil
+}
+
+// SetMethodRules replaces the rules selecting the methods served on the given
+// transport. The rules take effect immediately, also on the running endpoints.
+// The authenticated endpoints are not affected.
+func (n *Node) SetMethodRules(transport string, rules rpc.MethodRules) error {
+	filter, ok := n.methodFilters[transport]
+	if !ok {
+		return fmt.Errorf("unknown transport %q", transport)
+	}
+	return filter.SetRules(rules)
+}
+
 // startRPC is a helper method to configure all the various RPC endpoints during node
 // startup. It's not meant to be called at any time afterwards as it makes certain
 // assumptions about the state of the node.
@@ -422,6 +479,21 @@ func (n *Node) startRPC() error {
 		batchItemLimit:         n.config.BatchRequestLimit,
 		batchResponseSizeLimit: n.config.BatchResponseMaxSize,
 		apiFilter:              n.apiFilter,
+		responseCache:          n.responseCache,
+	}
+	if n.config.RPCCapture.Path != "" {
+		config := n.config.RPCCapture
+		config.Path = n.config.ResolvePath(config.Path)
+		if config.Redact == nil {
+			config.Redact = rpc.DefaultRedactedMethods
+		}
+		recorder, err := rpc.NewRecorder(config)
+		if err != nil {
+			return fmt.Errorf("failed to open RPC capture: %v", err)
+		}
+		n.log.Info("Recording RPC calls", "path", config.Path, "sample", config.SampleRate)
+		n.recorder = recorder
+		rpcConfig.recorder = recorder
 	}
 	if n.config.HTTPBodyLimit != 0 {
 		rpcConfig.httpBodyLimit = n.config.HTTPBodyLimit
@@ -429,17 +501,35 @@ func (n *Node) startRPC() error {
 	if n.config.WSReadLimit != 0 {
 		rpcConfig.wsReadLimit = n.config.WSReadLimit
 	}
+	if n.config.RPCRateLimit.Rate > 0 {
+		// A bucket without capacity would reject every call
+		if n.config.RPCRateLimit.Burst <= 0 {
+			return fmt.Errorf("invalid RPC rate limit burst %d, must be positive", n.config.RPCRateLimit.Burst)
+		}
+		// The HTTP and WebSocket endpoints share the buckets of the clients
+		rpcConfig.rateLimiter = newRateLimiter(n.config.RPCRateLimit)
+		rpcConfig.rateLimitKeyHeader = n.config.RPCRateLimit.KeyHeader
+
+		proxies, err := parseTrustedProxies(n.config.RPCRateLimit.TrustedProxies)
+		if err != nil {
+			return err
+		}
+		rpcConfig.rateLimitProxies = proxies
+	}
+	rpcConfig.executionLimiter = n.executionLimiter
 
 	initHttp := func(server *httpServer, port int) error {
 		if err := server.setListenAddr(n.config.HTTPHost, port); err != nil {
 			return err
 		}
+		config := rpcConfig
+		config.methodFilter = n.methodFilters[TransportHTTP]
 		if err := server.enableRPC(openAPIs, httpConfig{
 			CorsAllowedOrigins: n.config.HTTPCors,
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
 			prefix:             n.config.HTTPPathPrefix,
-			rpcEndpointConfig:  rpcConfig,
+			rpcEndpointConfig:  config,
 		}); err != nil {
 			return err
 		}
@@ -452,11 +542,13 @@ func (n *Node) startRPC() error {
 		if err := server.setListenAddr(n.config.WSHost, port); err != nil {
 			return err
 		}
+		config := rpcConfig
+		config.methodFilter = n.methodFilters[TransportWS]
 		if err := server.enableWS(openAPIs, wsConfig{
 			Modules:           n.config.WSModules,
 			Origins:           n.config.WSOrigins,
 			prefix:            n.config.WSPathPrefix,
-			rpcEndpointConfig: rpcConfig,
+			rpcEndpointConfig: config,
 		}); err != nil {
 			return err
 		}
@@ -562,6 +654,14 @@ func (n *Node) StopRPC() {
 }
 
 func (n *Node) stopRPC() {
+	if n.recorder != nil {
+		defer func() {
+			if err := n.recorder.Close(); err != nil {
+				n.log.Warn("Failed to close RPC capture", "err", err)
+			}
+			n.recorder = nil
+		}()
+	}
 	if atomic.SwapInt32(&n.rpcRunning, 0) == 0 {
 		// The RPC was already stopped or was never started
 		return
@@ -641,6 +741,14 @@ func (n *Node) getAPIs() (unauthenticated, all []rpc.API) {
 	return unauthenticated, n.rpcAPIs
 }
 
+// APIs returns the APIs registered on the node.
+func (n *Node) APIs() []rpc.API {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return append([]rpc.API(nil), n.rpcAPIs...)
+}
+
 // RegisterHandler mounts a handler on the given path on the canonical HTTP server.
 //
 // The name of the handler is shown in a log message when the HTTP server starts
@@ -22,9 +22,11 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -479,6 +481,127 @@ func TestWebsocketHTTPOnSeparatePort_WSRequest(t *testing.T) {
 	}
 }
 
+func TestNodeMethodRules(t *testing.T) {
+	conf := &Config{
+		HTTPHost:        "127.0.0.1",
+		WSHost:          "127.0.0.1",
+		HTTPTimeouts:    rpc.DefaultHTTPTimeouts,
+		HTTPMethodRules: rpc.MethodRules{Allow: []string{"web3_*", "rpc_modules"}, Deny: []string{"web3_sha3"}},
+	}
+	node, err := New(conf)
+	if err != nil {
+		t.Fatalf("could not create a new node: %v", err)
+	}
+	if err := node.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	defer node.Close()
+
+	call := func(url, method string, args ...interface{}) error {
+		t.Helper()
+		c, err := rpc.Dial(url)
+		if err != nil {
+			t.Fatalf("could not dial %s: %v", url, err)
+		}
+		defer c.Close()
+		var result interface{}
+		return c.Call(&result, method, args...)
+	}
+	// The rules of a transport don't affect the others
+	if err := call(node.HTTPEndpoint(), "web3_clientVersion"); err != nil {
+		t.Errorf("http: unexpected error: %v", err)
+	}
+	if err := call(node.HTTPEndpoint(), "web3_sha3", "0x00"); err == nil {
+		t.Error("http: web3_sha3 allowed")
+	}
+	if err := call(node.WSEndpoint(), "web3_sha3", "0x00"); err != nil {
+		t.Errorf("ws: unexpected error: %v", err)
+	}
+	// Replace the rules at runtime
+	if err := node.SetMethodRules(TransportWS, rpc.MethodRules{Deny: []string{"web3_*"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := call(node.WSEndpoint(), "web3_sha3", "0x00"); err == nil {
+		t.Error("ws: web3_sha3 allowed after reload")
+	}
+	if err := node.SetMethodRules(TransportHTTP, rpc.MethodRules{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := call(node.HTTPEndpoint(), "web3_sha3", "0x00"); err != nil {
+		t.Errorf("http: unexpected error after reload: %v", err)
+	}
+	if err := node.SetMethodRules("grpc", rpc.MethodRules{}); err == nil {
+		t.Error("expected error for unknown transport")
+	}
+}
+
+// Tests that a rate limit without burst, which would reject every call, fails
+// the startup of the node.
+func TestNodeRateLimitBurst(t *testing.T) {
+	conf := &Config{
+		HTTPHost:     "127.0.0.1",
+		HTTPTimeouts: rpc.DefaultHTTPTimeouts,
+		RPCRateLimit: RateLimitConfig{Rate: 10},
+	}
+	node, err := New(conf)
+	if err != nil {
+		t.Fatalf("could not create a new node: %v", err)
+	}
+	defer node.Close()
+	if err := node.Start(); err == nil {
+		t.Fatal("node started with a zero rate limit burst")
+	}
+}
+
+// Tests that the calls served over IPC share the execution limits of the other
+// endpoints.
+func TestNodeExecutionLimitsIPC(t *testing.T) {
+	conf := &Config{
+		HTTPHost:           "127.0.0.1",
+		HTTPModules:        []string{"test"},
+		HTTPTimeouts:       rpc.DefaultHTTPTimeouts,
+		IPCPath:            filepath.Join(t.TempDir(), "geth.ipc"),
+		RPCExecutionLimits: map[string]rpc.ExecutionLimit{"test": {MaxConcurrent: 1, QueueTimeout: 100 * time.Millisecond}},
+	}
+	node, err := New(conf)
+	if err != nil {
+		t.Fatalf("could not create a new node: %v", err)
+	}
+	node.RegisterAPIs(apis())
+	if err := node.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	defer node.Close()
+
+	ipc, err := rpc.Dial(node.IPCEndpoint())
+	if err != nil {
+		t.Fatalf("could not dial IPC: %v", err)
+	}
+	defer ipc.Close()
+	httpc, err := rpc.Dial(node.HTTPEndpoint())
+	if err != nil {
+		t.Fatalf("could not dial HTTP: %v", err)
+	}
+	defer httpc.Close()
+
+	// Occupy the execution slot of the namespace over IPC
+	running := make(chan error, 1)
+	go func() { running <- ipc.Call(nil, "test_sleep") }()
+	time.Sleep(200 * time.Millisecond)
+
+	var rpcErr rpc.Error
+	if err := httpc.Call(nil, "test_greet"); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32006 {
+		t.Errorf("expected queue timeout over HTTP, got %v", err)
+	}
+	if err := <-running; err != nil {
+		t.Errorf("unexpected IPC error: %v", err)
+	}
+	conf.RPCExecutionLimits = map[string]rpc.ExecutionLimit{"test": {MaxQueued: 1}}
+	if _, err := New(conf); err == nil {
+		t.Error("expected error for queue limit without concurrency limit")
+	}
+}
+
 type rpcPrefixTest struct {
 	httpPrefix, wsPrefix string
 	// These lists paths on which JSON-RPC should be served / not served.
@@ -0,0 +1,297 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitedClients is the number of clients whose token buckets are tracked.
+// The least recently seen clients start over with a full bucket.
+const rateLimitedClients = 16384
+
+// rateLimitMeteredClients is the number of clients with their own meters,
+// rpc/ratelimit/client/<key>/{accepted,rejected}. The meters of the least
+// recently seen clients are unregistered, bounding the number of series.
+const rateLimitMeteredClients = 256
+
+var (
+	rateLimitAcceptedMeter  = metrics.NewRegisteredMeter("rpc/ratelimit/accepted", nil)
+	rateLimitRejectedMeter  = metrics.NewRegisteredMeter("rpc/ratelimit/rejected", nil)
+	rateLimitTooCostlyMeter = metrics.NewRegisteredMeter("rpc/ratelimit/toocostly", nil)
+	rateLimitClientsGauge   = metrics.NewRegisteredGauge("rpc/ratelimit/clients", nil)
+)
+
+// clientMeters are the meters of a client of the rate limiter.
+type clientMeters struct {
+	accepted metrics.Meter
+	rejected metrics.Meter
+}
+
+// RateLimitConfig configures the rate limiting of the RPC calls served over HTTP
+// and WebSocket. Every client has a token bucket, refilled at Rate tokens per
+// second up to Burst tokens, which each call drains by the cost of its method.
+//
+// Clients are identified by the API key sent in KeyHeader, by the subject of
+// their JWT token, or by their IP address, in this order. The API key is not
+// authenticated, so KeyHeader should only be set if it's checked by a proxy.
+// Requests relayed by one of the TrustedProxies are attributed to the address
+// reported in their X-Forwarded-For header instead of the one of the proxy.
+type RateLimitConfig struct {
+	Rate           float64        `toml:",omitempty"` // tokens per second, zero disables rate limiting
+	Burst          int            `toml:",omitempty"` // bucket capacity, required if rate limiting
+	KeyHeader      string         `toml:",omitempty"` // HTTP header carrying the API key of the client
+	TrustedProxies []string       `toml:",omitempty"` // addresses or CIDR ranges of the reverse proxies
+	MethodCosts    map[string]int `toml:",omitempty"` // cost of the methods, 1 if unlisted, 0 exempts a method
+}
+
+// Error codes of the calls rejected by the rate limiter.
+const (
+	errcodeRateLimited = -32029
+	errcodeTooCostly   = -32030
+)
+
+// rateLimitError is returned for calls rejected by the rate limiter, which can
+// be retried later.
+type rateLimitError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string  { return "rate limit exceeded" }
+func (e *rateLimitError) ErrorCode() int { return errcodeRateLimited }
+
+// ErrorData reports when the call can be retried, in seconds.
+func (e *rateLimitError) ErrorData() interface{} {
+	return map[string]interface{}{"retryAfter": int(math.Ceil(e.retryAfter.Seconds()))}
+}
+
+// tooCostlyError is returned for calls costing more than the capacity of the
+// token buckets, which are never served.
+type tooCostlyError struct {
+	cost, burst int
+}
+
+func (e *tooCostlyError) Error() string {
+	return fmt.Sprintf("call cost %d exceeds the rate limit burst %d, do not retry", e.cost, e.burst)
+}
+func (e *tooCostlyError) ErrorCode() int { return errcodeTooCostly }
+
+// rateLimiter is a token bucket rate limiter for RPC calls, keyed by client.
+type rateLimiter struct {
+	config RateLimitConfig
+
+	mu      sync.Mutex
+	clients lru.BasicLRU[string, *rate.Limiter]
+	meters  lru.BasicLRU[string, *clientMeters]
+}
+
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		config:  config,
+		clients: lru.NewBasicLRU[string, *rate.Limiter](rateLimitedClients),
+		meters:  lru.NewBasicLRU[string, *clientMeters](rateLimitMeteredClients),
+	}
+}
+
+// Allow implements rpc.RateLimiter.
+func (l *rateLimiter) Allow(peer rpc.PeerInfo, method string) error {
+	cost, ok := l.config.MethodCosts[method]
+	if !ok {
+		cost = 1
+	}
+	if cost == 0 {
+		return nil
+	}
+	if cost > l.config.Burst {
+		// The call can never be served, retrying it is pointless
+		rateLimitTooCostlyMeter.Mark(1)
+		return &tooCostlyError{cost: cost, burst: l.config.Burst}
+	}
+	bucket, meters := l.client(rateLimitKey(peer))
+
+	now := time.Now()
+	r := bucket.ReserveN(now, cost)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		meters.rejected.Mark(1)
+		rateLimitRejectedMeter.Mark(1)
+		return &rateLimitError{retryAfter: delay}
+	}
+	meters.accepted.Mark(1)
+	rateLimitAcceptedMeter.Mark(1)
+	return nil
+}
+
+// client returns the token bucket and the meters of the client with the given key.
+func (l *rateLimiter) client(key string) (*rate.Limiter, *clientMeters) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.clients.Get(key)
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(l.config.Rate), l.config.Burst)
+		l.clients.Add(key, bucket)
+		rateLimitClientsGauge.Update(int64(l.clients.Len()))
+	}
+	meters, ok := l.meters.Get(key)
+	if !ok {
+		if l.meters.Len() >= rateLimitMeteredClients {
+			if oldest, _, ok := l.meters.RemoveOldest(); ok {
+				metrics.Unregister(fmt.Sprintf("rpc/ratelimit/client/%s/accepted", oldest))
+				metrics.Unregister(fmt.Sprintf("rpc/ratelimit/client/%s/rejected", oldest))
+			}
+		}
+		meters = &clientMeters{
+			accepted: metrics.GetOrRegisterMeter(fmt.Sprintf("rpc/ratelimit/client/%s/accepted", key), nil),
+			rejected: metrics.GetOrRegisterMeter(fmt.Sprintf("rpc/ratelimit/client/%s/rejected", key), nil),
+		}
+		l.meters.Add(key, meters)
+	}
+	return bucket, meters
+}
+
+// rateLimitKey returns the key identifying the client in the rate limiter.
+func rateLimitKey(peer rpc.PeerInfo) string {
+	if peer.Identity != "" {
+		return peer.Identity
+	}
+	host, _, err := net.SplitHostPort(peer.RemoteAddr)
+	if err != nil {
+		host = peer.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// apiKeyHandler is a http.Handler which identifies clients by the API key sent in
+// a request header. The key is hashed, so it doesn't leak into logs and metrics.
+type apiKeyHandler struct {
+	header string
+	next   http.Handler
+}
+
+func newAPIKeyHandler(header string, next http.Handler) http.Handler {
+	return &apiKeyHandler{header: header, next: next}
+}
+
+// ServeHTTP implements http.Handler
+func (h *apiKeyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if key := r.Header.Get(h.header); key != "" {
+		hash := sha256.Sum256([]byte(key))
+		r = r.WithContext(rpc.WithPeerIdentity(r.Context(), "apikey:"+hex.EncodeToString(hash[:8])))
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// parseTrustedProxies parses the addresses and CIDR ranges of the reverse
+// proxies whose forwarded client addresses are trusted.
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address %q", proxy)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy range %q: %v", proxy, err)
+		}
+		nets = append(nets, ipnet)
+	}
+	return nets, nil
+}
+
+// forwardedHandler is a http.Handler which identifies the clients of requests
+// relayed by trusted reverse proxies by the address they forwarded. Clients
+// already identified by an API key or a JWT token are left as they are.
+type forwardedHandler struct {
+	proxies []*net.IPNet
+	next    http.Handler
+}
+
+func newForwardedHandler(proxies []*net.IPNet, next http.Handler) http.Handler {
+	return &forwardedHandler{proxies: proxies, next: next}
+}
+
+// ServeHTTP implements http.Handler
+func (h *forwardedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rpc.PeerIdentityFromContext(r.Context()) == "" {
+		if client := h.client(r); client != "" {
+			r = r.WithContext(rpc.WithPeerIdentity(r.Context(), "ip:"+client))
+		}
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// client returns the address of the client of a request relayed by trusted
+// proxies, or an empty string if it didn't come through one. The forwarded
+// addresses are checked from the nearest hop, as the ones appended before the
+// first trusted proxy may be forged by the client.
+func (h *forwardedHandler) client(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !h.trusted(host) {
+		return ""
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if net.ParseIP(hop) == nil || !h.trusted(hop) {
+			return hop
+		}
+	}
+	return ""
+}
+
+// trusted reports whether the given address belongs to a trusted proxy.
+func (h *forwardedHandler) trusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range h.proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -57,8 +57,15 @@ type rpcEndpointConfig struct {
 	batchItemLimit         int
 	batchResponseSizeLimit int
 	apiFilter              map[string]bool
+	methodFilter           *rpc.MethodFilter // optional filter applied on every call
+	responseCache          rpc.ResponseCache // optional cache of immutable results
+	recorder               *rpc.Recorder     // optional capture of the calls
 	httpBodyLimit          int
 	wsReadLimit            int64
+	rateLimiter            rpc.RateLimiter       // optional per-client rate limiter
+	rateLimitKeyHeader     string                // header carrying the API key of the client
+	rateLimitProxies       []*net.IPNet          // reverse proxies forwarding the client addresses
+	executionLimiter       *rpc.ExecutionLimiter // optional per-method execution limits
 }
 
 type rpcHandler struct {
@@ -316,6 +323,21 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 		srv.SetHTTPBodyLimit(config.httpBodyLimit)
 	}
 	srv.ApplyAPIFilter(config.apiFilter)
+	if config.methodFilter != nil {
+		srv.SetMethodFilter(config.methodFilter)
+	}
+	if config.responseCache != nil {
+		srv.SetResponseCache(config.responseCache)
+	}
+	if config.recorder != nil {
+		srv.SetRecorder(config.recorder)
+	}
+	if config.rateLimiter != nil {
+		srv.SetRateLimiter(config.rateLimiter)
+	}
+	if config.executionLimiter != nil {
+		srv.SetExecutionLimiter(config.executionLimiter)
+	}
 	if err := RegisterApis(apis, config.Modules, srv); err != nil {
 		return err
 	}
@@ -324,6 +346,12 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	if err != nil {
 		return err
 	}
+	if len(config.rateLimitProxies) > 0 {
+		httpHandler = newForwardedHandler(config.rateLimitProxies, httpHandler)
+	}
+	if config.rateLimitKeyHeader != "" {
+		httpHandler = newAPIKeyHandler(config.rateLimitKeyHeader, httpHandler)
+	}
 
 	h.httpConfig = config
 	h.httpHandler.Store(&rpcHandler{
@@ -358,12 +386,34 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 		srv.SetHTTPBodyLimit(config.httpBodyLimit)
 	}
 	srv.ApplyAPIFilter(config.apiFilter)
+	if config.methodFilter != nil {
+		srv.SetMethodFilter(config.methodFilter)
+	}
+	if config.responseCache != nil {
+		srv.SetResponseCache(config.responseCache)
+	}
+	if config.recorder != nil {
+		srv.SetRecorder(config.recorder)
+	}
+	if config.rateLimiter != nil {
+		srv.SetRateLimiter(config.rateLimiter)
+	}
+	if config.executionLimiter != nil {
+		srv.SetExecutionLimiter(config.executionLimiter)
+	}
 	if err := RegisterApis(apis, config.Modules, srv); err != nil {
 		return err
 	}
+	wsHandler := srv.WebsocketHandler(config.Origins, config.wsReadLimit)
+	if len(config.rateLimitProxies) > 0 {
+		wsHandler = newForwardedHandler(config.rateLimitProxies, wsHandler)
+	}
+	if config.rateLimitKeyHeader != "" {
+		wsHandler = newAPIKeyHandler(config.rateLimitKeyHeader, wsHandler)
+	}
 	h.wsConfig = config
 	h.wsHandler.Store(&rpcHandler{
-		Handler: NewWSHandlerStack(srv.WebsocketHandler(config.Origins, config.wsReadLimit), config.jwtSecret),
+		Handler: NewWSHandlerStack(wsHandler, config.jwtSecret),
 		server:  srv,
 	})
 	return nil
@@ -601,14 +651,16 @@ func newGzipHandler(next http.Handler) http.Handler {
 type ipcServer struct {
 	log      log.Logger
 	endpoint string
+	filter   *rpc.MethodFilter
+	limiter  *rpc.ExecutionLimiter
 
 	mu       sync.Mutex
 	listener net.Listener
 	srv      *rpc.Server
 }
 
-func newIPCServer(log log.Logger, endpoint string) *ipcServer {
-	return &ipcServer{log: log, endpoint: endpoint}
+func newIPCServer(log log.Logger, endpoint string, filter *rpc.MethodFilter, limiter *rpc.ExecutionLimiter) *ipcServer {
+	return &ipcServer{log: log, endpoint: endpoint, filter: filter, limiter: limiter}
 }
 
 // Start starts the httpServer's http.Server
@@ -619,7 +671,14 @@ func (is *ipcServer) start(apis []rpc.API) error {
 	if is.listener != nil {
 		return nil // already running
 	}
-	listener, srv, err := rpc.StartIPCEndpoint(is.endpoint, apis)
+	srv := rpc.NewServer()
+	if is.filter != nil {
+		srv.SetMethodFilter(is.filter)
+	}
+	if is.limiter != nil {
+		srv.SetExecutionLimiter(is.limiter)
+	}
+	listener, err := rpc.ServeIPCEndpoint(is.endpoint, apis, srv)
 	if err != nil {
 		is.log.Warn("IPC opening failed", "url", is.endpoint, "error", err)
 		return err
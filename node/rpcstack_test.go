@@ -18,6 +18,7 @@ package node
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -30,6 +31,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/internal/testlog"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/gorilla/websocket"
@@ -596,6 +598,105 @@ func TestHTTPWriteTimeout(t *testing.T) {
 	})
 }
 
+func TestRateLimit(t *testing.T) {
+	limiter := newRateLimiter(RateLimitConfig{
+		Rate:        0.01,
+		Burst:       3,
+		MethodCosts: map[string]int{"test_greet": 2, "test_sleep": 4, "rpc_modules": 0},
+	})
+	proxies, err := parseTrustedProxies([]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &httpConfig{Modules: []string{"test"}, rpcEndpointConfig: rpcEndpointConfig{
+		rateLimiter:        limiter,
+		rateLimitKeyHeader: "X-Api-Key",
+		rateLimitProxies:   proxies,
+	}}
+	srv := createAndStartServer(t, cfg, false, &wsConfig{}, nil)
+	url := fmt.Sprintf("http://%v", srv.listenAddr())
+
+	type response struct {
+		Error *struct {
+			Code int
+			Data struct{ RetryAfter int }
+		}
+	}
+	batch := func(methods []string, headers ...string) []response {
+		t.Helper()
+		var res []response
+		if err := json.NewDecoder(batchRpcRequest(t, url, methods, headers...).Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	// The items of a batch are charged separately, the exempted ones are free
+	res := batch([]string{"test_greet", "rpc_modules", "test_greet"})
+	if res[0].Error != nil || res[1].Error != nil {
+		t.Fatalf("unexpected errors: %v %v", res[0].Error, res[1].Error)
+	}
+	if res[2].Error == nil || res[2].Error.Code != errcodeRateLimited || res[2].Error.Data.RetryAfter == 0 {
+		t.Fatalf("expected rate limit error with retry hint, got %+v", res[2].Error)
+	}
+	// Clients sending an API key have their own buckets
+	res = batch([]string{"test_greet"}, "X-Api-Key", "key")
+	if res[0].Error != nil {
+		t.Fatalf("unexpected error for API key client: %+v", res[0].Error)
+	}
+	res = batch([]string{"test_greet"}, "X-Api-Key", "key")
+	if res[0].Error == nil || res[0].Error.Code != errcodeRateLimited {
+		t.Fatalf("expected rate limit error for API key client, got %+v", res[0].Error)
+	}
+	// Clients behind a trusted proxy are identified by the forwarded address,
+	// only the hop appended by the proxy is trusted
+	res = batch([]string{"test_greet"}, "X-Forwarded-For", "10.0.0.1, 192.0.2.1")
+	if res[0].Error != nil {
+		t.Fatalf("unexpected error for forwarded client: %+v", res[0].Error)
+	}
+	res = batch([]string{"test_greet"}, "X-Forwarded-For", "10.0.0.2, 192.0.2.1")
+	if res[0].Error == nil || res[0].Error.Code != errcodeRateLimited {
+		t.Fatalf("expected rate limit error for forwarded client, got %+v", res[0].Error)
+	}
+	res = batch([]string{"test_greet"}, "X-Forwarded-For", "192.0.2.2")
+	if res[0].Error != nil {
+		t.Fatalf("unexpected error for another forwarded client: %+v", res[0].Error)
+	}
+	// Calls costing more than the burst are never served
+	res = batch([]string{"test_sleep"}, "X-Api-Key", "other")
+	if res[0].Error == nil || res[0].Error.Code != errcodeTooCostly || res[0].Error.Data.RetryAfter != 0 {
+		t.Fatalf("expected too costly error, got %+v", res[0].Error)
+	}
+	res = batch([]string{"rpc_modules"})
+	if res[0].Error != nil {
+		t.Fatalf("unexpected error for exempted method: %+v", res[0].Error)
+	}
+}
+
+func TestRateLimitClientMeters(t *testing.T) {
+	limiter := newRateLimiter(RateLimitConfig{Rate: 1, Burst: 1})
+	name := func(i int) string {
+		return fmt.Sprintf("rpc/ratelimit/client/ip:192.0.2.%d/accepted", i)
+	}
+	for i := 0; i <= rateLimitMeteredClients; i++ {
+		metrics.Unregister(name(i))
+	}
+	// The clients have their own meters, the least recently seen ones being
+	// unregistered
+	for i := 0; i <= rateLimitMeteredClients; i++ {
+		if err := limiter.Allow(rpc.PeerInfo{RemoteAddr: fmt.Sprintf("192.0.2.%d:80", i)}, "test_greet"); err != nil {
+			t.Fatalf("client %d: unexpected error: %v", i, err)
+		}
+	}
+	if metrics.DefaultRegistry.Get(name(0)) != nil {
+		t.Error("meter of the least recently seen client not unregistered")
+	}
+	if metrics.DefaultRegistry.Get(name(rateLimitMeteredClients)) == nil {
+		t.Error("meter of the last client not registered")
+	}
+	for i := 1; i <= rateLimitMeteredClients; i++ {
+		metrics.Unregister(name(i))
+	}
+}
 func apis() []rpc.API {
 	return []rpc.API{
 		{
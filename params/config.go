@@ -371,6 +371,10 @@ type ChainConfig struct {
 	Clique *CliqueConfig `json:"clique,omitempty"`
 
 	ArbitrumChainParams ArbitrumChainParams `json:"arbitrum,omitempty"`
+
+	// KintoChainParams configures the Kinto rules, the ones of the Kinto mainnet
+	// apply if it's not set.
+	KintoChainParams *KintoChainParams `json:"kinto,omitempty"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -713,6 +717,9 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int,
 	if err := c.checkArbitrumCompatible(newcfg, headNumber); err != nil {
 		return err
 	}
+	if err := c.checkKintoCompatible(newcfg, headNumber); err != nil {
+		return err
+	}
 	if isForkBlockIncompatible(c.ByzantiumBlock, newcfg.ByzantiumBlock, headNumber) {
 		return newBlockCompatError("Byzantium fork block", c.ByzantiumBlock, newcfg.ByzantiumBlock)
 	}
@@ -0,0 +1,242 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// KintoContracts are the addresses of the contracts the Kinto rules refer to.
+type KintoContracts struct {
+	EntryPoint      common.Address
+	EntryPointV7    common.Address
+	KintoID         common.Address
+	WalletFactory   common.Address
+	Paymaster       common.Address
+	AppRegistry     common.Address
+	UpgradeExecutor common.Address
+	CustomGateway   common.Address
+	GatewayRouter   common.Address
+	StandardGateway common.Address
+	WethGateway     common.Address
+	BundleBulker    common.Address
+	ArbRetryableTx  common.Address
+	Create2Factory  common.Address
+
+	Socket                      common.Address
+	SocketExecutionManager      common.Address
+	SocketTransmitManager       common.Address
+	SocketFastSwitchboard       common.Address
+	SocketOptimisticSwitchboard common.Address
+	SocketBatcher               common.Address
+	SocketSimulator             common.Address
+	SocketSimulatorUtils        common.Address
+	SocketSwitchboardSimulator  common.Address
+	SocketCapacitorSimulator    common.Address
+}
+
+// KintoChainParams are the network specific parameters of the Kinto rules. The
+// rules apply to the blocks after RulesBlockStart, and the rules of a hardfork
+// to the blocks after its block number. The bytecode replacements of hardforks
+// 5, 6 and 7 happen at their block numbers.
+type KintoChainParams struct {
+	Contracts KintoContracts
+
+	RulesBlockStart uint64
+	Hardfork1Block  uint64
+	Hardfork2Block  uint64
+	Hardfork3Block  uint64
+	Hardfork4Block  uint64
+	Hardfork5Block  uint64
+	Hardfork6Block  uint64
+	Hardfork7Block  uint64
+
+	// SelfDestructWallet receives the balances of the self-destructed contracts
+	// after hardfork 2 (SELFDESTRUCT) and hardfork 5 (SELFDESTRUCT with EIP-6780).
+	SelfDestructWallet common.Address
+}
+
+// kintoMainnet are the parameters of the chain configs without Kinto section.
+var kintoMainnet = KintoMainnetParams()
+
+// Kinto returns the Kinto parameters of the chain, the ones of the Kinto mainnet
+// if the config has none.
+func (c *ChainConfig) Kinto() *KintoChainParams {
+	if c.KintoChainParams == nil {
+		return kintoMainnet
+	}
+	return c.KintoChainParams
+}
+
+// IsKintoRules reports whether the Kinto rules apply to the block.
+func (p *KintoChainParams) IsKintoRules(num *big.Int) bool {
+	return num.Cmp(new(big.Int).SetUint64(p.RulesBlockStart)) > 0
+}
+
+// Hardfork returns the latest Kinto hardfork whose rules apply to the block, or
+// zero if it's before the first one.
+func (p *KintoChainParams) Hardfork(num *big.Int) int {
+	hardfork := 0
+	for i, block := range p.hardforkBlocks() {
+		if num.Cmp(new(big.Int).SetUint64(block)) > 0 {
+			hardfork = i + 1
+		}
+	}
+	return hardfork
+}
+
+// IsHardforkBlock reports whether the block is the one of the given hardfork.
+func (p *KintoChainParams) IsHardforkBlock(hardfork int, num *big.Int) bool {
+	blocks := p.hardforkBlocks()
+	if hardfork < 1 || hardfork > len(blocks) || !num.IsUint64() {
+		return false
+	}
+	return blocks[hardfork-1] == num.Uint64()
+}
+
+func (p *KintoChainParams) hardforkBlocks() []uint64 {
+	return []uint64{
+		p.Hardfork1Block, p.Hardfork2Block, p.Hardfork3Block, p.Hardfork4Block,
+		p.Hardfork5Block, p.Hardfork6Block, p.Hardfork7Block,
+	}
+}
+
+func (c *ChainConfig) checkKintoCompatible(newcfg *ChainConfig, head *big.Int) *ConfigCompatError {
+	cKinto, newKinto := c.Kinto(), newcfg.Kinto()
+	forks := []struct {
+		what        string
+		stored, new uint64
+	}{
+		{"Kinto rules start block", cKinto.RulesBlockStart, newKinto.RulesBlockStart},
+		{"Kinto hardfork 1 block", cKinto.Hardfork1Block, newKinto.Hardfork1Block},
+		{"Kinto hardfork 2 block", cKinto.Hardfork2Block, newKinto.Hardfork2Block},
+		{"Kinto hardfork 3 block", cKinto.Hardfork3Block, newKinto.Hardfork3Block},
+		{"Kinto hardfork 4 block", cKinto.Hardfork4Block, newKinto.Hardfork4Block},
+		{"Kinto hardfork 5 block", cKinto.Hardfork5Block, newKinto.Hardfork5Block},
+		{"Kinto hardfork 6 block", cKinto.Hardfork6Block, newKinto.Hardfork6Block},
+		{"Kinto hardfork 7 block", cKinto.Hardfork7Block, newKinto.Hardfork7Block},
+	}
+	for _, fork := range forks {
+		storedBlock, newBlock := new(big.Int).SetUint64(fork.stored), new(big.Int).SetUint64(fork.new)
+		if isForkBlockIncompatible(storedBlock, newBlock, head) {
+			return newBlockCompatError(fork.what, storedBlock, newBlock)
+		}
+	}
+	start := new(big.Int).SetUint64(cKinto.RulesBlockStart)
+	if cKinto.Contracts != newKinto.Contracts && isBlockForked(start, head) {
+		return newBlockCompatError("Kinto contracts", start, start)
+	}
+	hardfork2 := new(big.Int).SetUint64(cKinto.Hardfork2Block)
+	if cKinto.SelfDestructWallet != newKinto.SelfDestructWallet && isBlockForked(hardfork2, head) {
+		return newBlockCompatError("Kinto self-destruct wallet", hardfork2, hardfork2)
+	}
+	return nil
+}
+
+// KintoMainnetParams returns the parameters of the Kinto mainnet.
+func KintoMainnetParams() *KintoChainParams {
+	return &KintoChainParams{
+		Contracts: KintoContracts{
+			EntryPoint:      common.HexToAddress("0x2843C269D2a64eCfA63548E8B3Fc0FD23B7F70cb"),
+			EntryPointV7:    common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032"),
+			KintoID:         common.HexToAddress("0xf369f78E3A0492CC4e96a90dae0728A38498e9c7"),
+			WalletFactory:   common.HexToAddress("0x8a4720488CA32f1223ccFE5A087e250fE3BC5D75"),
+			Paymaster:       common.HexToAddress("0x1842a4EFf3eFd24c50B63c3CF89cECEe245Fc2bd"),
+			AppRegistry:     common.HexToAddress("0x5A2b641b84b0230C8e75F55d5afd27f4Dbd59d5b"),
+			UpgradeExecutor: common.HexToAddress("0x88e03D41a6EAA9A0B93B0e2d6F1B34619cC4319b"),
+			CustomGateway:   common.HexToAddress("0x06FcD8264caF5c28D86eb4630c20004aa1faAaA8"),
+			GatewayRouter:   common.HexToAddress("0x340487b92808B84c2bd97C87B590EE81267E04a7"),
+			StandardGateway: common.HexToAddress("0x87799989341A07F495287B1433eea98398FD73aA"),
+			WethGateway:     common.HexToAddress("0xd563ECBDF90EBA783d0a218EFf158C1263ad02BE"),
+			BundleBulker:    common.HexToAddress("0x8d2D899402ed84b6c0510bB1ad34ee436ADDD20d"),
+			ArbRetryableTx:  common.HexToAddress("0x000000000000000000000000000000000000006E"),
+			Create2Factory:  common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C"),
+
+			Socket:                      common.HexToAddress("0x3e9727470C66B1e77034590926CDe0242B5A3dCc"),
+			SocketExecutionManager:      common.HexToAddress("0x6c914cc610e9a05eaFFfD79c10c60Ad1704717E5"),
+			SocketTransmitManager:       common.HexToAddress("0x6332e56A423480A211E301Cb85be12814e9238Bb"),
+			SocketFastSwitchboard:       common.HexToAddress("0x516302D1b25e5F6d1ac90eF7256270cd799524CF"),
+			SocketOptimisticSwitchboard: common.HexToAddress("0x2B98775aBE9cDEb041e3c2E56C76ce2560AF57FB"),
+			SocketBatcher:               common.HexToAddress("0x12FF8947a2524303C13ca7dA9bE4914381f6557a"),
+			SocketSimulator:             common.HexToAddress("0x72846179EF1467B2b71F2bb7525fcD4450E46B2A"),
+			SocketSimulatorUtils:        common.HexToAddress("0x897DA4D039f64090bfdb33cd2Ed2Da81adD6FB02"),
+			SocketSwitchboardSimulator:  common.HexToAddress("0xa7527C270f30cF3dAFa6e82603b4978e1A849359"),
+			SocketCapacitorSimulator:    common.HexToAddress("0x6dbB5ee7c63775013FaF810527DBeDe2810d7Aee"),
+		},
+		RulesBlockStart:    100,
+		Hardfork1Block:     57000,
+		Hardfork2Block:     118000,
+		Hardfork3Block:     125000,
+		Hardfork4Block:     133000,
+		Hardfork5Block:     186000,
+		Hardfork6Block:     210000,
+		Hardfork7Block:     245000,
+		SelfDestructWallet: common.HexToAddress("0x660ad4B5A74130a4796B4d54BC6750Ae93C86e6c"),
+	}
+}
+
+// KintoDevnetParams returns the parameters of the Kinto devnet, which shares
+// the hardfork blocks of the mainnet.
+func KintoDevnetParams() *KintoChainParams {
+	params := KintoMainnetParams()
+	params.Contracts = KintoContracts{
+		EntryPoint:      common.HexToAddress("0x3Ac318F6E5479606d95659EDE2A01c92A8dd8c42"),
+		EntryPointV7:    common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032"),
+		KintoID:         common.HexToAddress("0x951499DDb7af748186CEa5bE861F355998E2e527"),
+		WalletFactory:   common.HexToAddress("0x9905f57926c0E679Eb3a2974b7B18F0C97b79d2c"),
+		Paymaster:       common.HexToAddress("0x9CC6A053764c30eB8eA9c9cac0d714F476a39F8c"),
+		AppRegistry:     common.HexToAddress("0x6E1B1A6220eFc0671A6362cA3B5BB7BCE60dC12B"),
+		UpgradeExecutor: common.HexToAddress("0x6B0d3F40DeD9720938DB274f752F1e11532c2640"),
+		CustomGateway:   common.HexToAddress("0x094F8C3eA1b5671dd19E15eCD93C80d2A33fCA99"),
+		GatewayRouter:   common.HexToAddress("0xf3AC740Fcc64eEd76dFaE663807749189A332d54"),
+		StandardGateway: common.HexToAddress("0x6A8d32c495df943212B7788114e41103047150a5"),
+		WethGateway:     common.HexToAddress("0x79B47F0695608aD8dc90E400a3E123b02eB72D24"),
+		BundleBulker:    common.HexToAddress("0x2291d967F4f8E7B062D0eAA977C5adBbd33B99BB"),
+		ArbRetryableTx:  common.HexToAddress("0x000000000000000000000000000000000000006E"),
+		Create2Factory:  common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C"),
+
+		Socket:                      common.HexToAddress("0x62B421B7dbc6207CC010318a4ba567786137de29"),
+		SocketExecutionManager:      common.HexToAddress("0x4518D09052D6f40f83d489a3E9F81EF369dB0753"),
+		SocketTransmitManager:       common.HexToAddress("0x956b0c4d2f3f050bDB6A5b6B6a95050af9fA3A62"),
+		SocketFastSwitchboard:       common.HexToAddress("0x38cACa8a8b5579Cb2d2870A73DbfAa54B6Ee490D"),
+		SocketOptimisticSwitchboard: common.HexToAddress("0xC94De3804d3c67620E7a70547bCB4a77b53952EC"),
+		SocketBatcher:               common.HexToAddress("0x1d1ef33231689d6057565f99d8B1864E6bE5eb94"),
+		SocketSimulator:             common.HexToAddress("0xbfd616DA87ebea4513aB633C9298218dd4a698dc"),
+		SocketSimulatorUtils:        common.HexToAddress("0x93f73A15272D4D46720234C32BC1eE7290Eb5F18"),
+		SocketSwitchboardSimulator:  common.HexToAddress("0x108eE40304fB1C3560eFF91f8E15B52ea4E2a257"),
+		SocketCapacitorSimulator:    common.HexToAddress("0x1390e33B8F1D6D92e27fcEF2c6E5641Be951A2bb"),
+	}
+	return params
+}
+
+// KintoTestParams returns the parameters of the Kinto devnet with the rules
+// starting after block start and a hardfork every interval blocks after, so
+// that the rules of hardfork N apply from block start+N*interval+1 on. They're
+// meant for the tests around the Kinto fork heights.
+func KintoTestParams(start, interval uint64) *KintoChainParams {
+	params := KintoDevnetParams()
+	params.RulesBlockStart = start
+	for i, block := range []*uint64{
+		&params.Hardfork1Block, &params.Hardfork2Block, &params.Hardfork3Block, &params.Hardfork4Block,
+		&params.Hardfork5Block, &params.Hardfork6Block, &params.Hardfork7Block,
+	} {
+		*block = start + uint64(i+1)*interval
+	}
+	return params
+}
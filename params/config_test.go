@@ -17,11 +17,13 @@
 package params
 
 import (
+	"encoding/json"
 	"math/big"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 )
 
@@ -140,3 +142,58 @@ func TestConfigRules(t *testing.T) {
 		t.Errorf("expected %v to be shanghai", currentArbosVersion)
 	}
 }
+
+func TestKintoChainParams(t *testing.T) {
+	// Configs without Kinto section follow the mainnet rules
+	if got := (&ChainConfig{}).Kinto(); !reflect.DeepEqual(got, KintoMainnetParams()) {
+		t.Errorf("default Kinto params differ from mainnet: %+v", got)
+	}
+	config := &ChainConfig{ChainID: big.NewInt(1), KintoChainParams: KintoDevnetParams()}
+	enc, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec ChainConfig
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dec.KintoChainParams, config.KintoChainParams) {
+		t.Errorf("Kinto params changed by JSON round trip:\nhave %+v\nwant %+v", dec.KintoChainParams, config.KintoChainParams)
+	}
+
+	params := &KintoChainParams{RulesBlockStart: 1, Hardfork1Block: 2, Hardfork2Block: 4, Hardfork3Block: 6, Hardfork4Block: 8, Hardfork5Block: 10, Hardfork6Block: 12, Hardfork7Block: 14}
+	for _, tt := range []struct {
+		block    int64
+		rules    bool
+		hardfork int
+	}{
+		{1, false, 0}, {2, true, 0}, {3, true, 1}, {4, true, 1}, {5, true, 2}, {14, true, 6}, {15, true, 7},
+	} {
+		num := big.NewInt(tt.block)
+		if rules := params.IsKintoRules(num); rules != tt.rules {
+			t.Errorf("block %d: IsKintoRules %v, want %v", tt.block, rules, tt.rules)
+		}
+		if hardfork := params.Hardfork(num); hardfork != tt.hardfork {
+			t.Errorf("block %d: hardfork %d, want %d", tt.block, hardfork, tt.hardfork)
+		}
+	}
+	if !params.IsHardforkBlock(5, big.NewInt(10)) || params.IsHardforkBlock(5, big.NewInt(11)) {
+		t.Error("wrong hardfork 5 block")
+	}
+
+	// Moving a passed hardfork or changing the contracts of an active chain is incompatible
+	stored := &ChainConfig{KintoChainParams: params}
+	moved := *params
+	moved.Hardfork3Block = 7
+	if err := stored.CheckCompatible(&ChainConfig{KintoChainParams: &moved}, 5, 0); err != nil {
+		t.Errorf("unexpected error moving future hardfork: %v", err)
+	}
+	if err := stored.CheckCompatible(&ChainConfig{KintoChainParams: &moved}, 8, 0); err == nil || err.What != "Kinto hardfork 3 block" || err.RewindToBlock != 5 {
+		t.Errorf("wrong error moving passed hardfork: %v", err)
+	}
+	contracts := *params
+	contracts.Contracts.AppRegistry = common.HexToAddress("0x01")
+	if err := stored.CheckCompatible(&ChainConfig{KintoChainParams: &contracts}, 3, 0); err == nil || err.What != "Kinto contracts" {
+		t.Errorf("wrong error changing contracts: %v", err)
+	}
+}
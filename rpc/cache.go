@@ -0,0 +1,103 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// ResponseCache stores the results of calls which can't change anymore. The
+// cache decides which calls it stores, the server only offers it the results of
+// the successful ones.
+type ResponseCache interface {
+	// Cacheable reports whether the cache stores results of the method. The
+	// calls of the other methods bypass the cache.
+	Cacheable(method string) bool
+
+	// Get returns the cached result of the call, if any.
+	Get(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, bool)
+
+	// Put offers the result of a successful call to the cache. The result must
+	// not be retained, it may be modified after Put returns.
+	Put(ctx context.Context, method string, params json.RawMessage, result json.RawMessage)
+}
+
+// SetResponseCache sets the cache serving the results of repeated calls.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetResponseCache(cache ResponseCache) {
+	s.services.responseCache = cache
+}
+
+// canonicalParams returns the positional parameters of a call in a canonical
+// encoding, so that equivalent calls share their cache entries: the encoding is
+// compact with sorted object keys, hex strings are lowercase, and the trailing
+// null parameters, which are equivalent to missing ones, are dropped.
+func canonicalParams(raw json.RawMessage) (json.RawMessage, error) {
+	var params []interface{}
+	if len(raw) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&params); err != nil {
+			return nil, err
+		}
+	}
+	for len(params) > 0 && params[len(params)-1] == nil {
+		params = params[:len(params)-1]
+	}
+	for i := range params {
+		params[i] = canonicalValue(params[i])
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	return json.Marshal(params)
+}
+
+func canonicalValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		if isHexString(v) {
+			return strings.ToLower(v)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = canonicalValue(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = canonicalValue(v[key])
+		}
+	}
+	return v
+}
+
+func isHexString(s string) bool {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return false
+	}
+	for _, c := range s[2:] {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
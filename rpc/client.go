@@ -28,6 +28,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -92,6 +93,11 @@ type Client struct {
 	// This function, if non-nil, is called when the connection is lost.
 	reconnectFunc reconnectFunc
 
+	// Automatic reconnection, enabled if reconnectMinBackoff is non-zero.
+	reconnectMinBackoff time.Duration
+	reconnectMaxBackoff time.Duration
+	reconnectFeed       event.Feed
+
 	// config fields
 	batchItemLimit       int
 	batchResponseMaxSize int
@@ -147,6 +153,7 @@ type requestOp struct {
 	err         error
 	resp        chan []*jsonrpcMessage // the response goes here
 	sub         *ClientSubscription    // set for Subscribe requests.
+	resubscribe bool                   // true if sub is re-established after a reconnect
 	hadResponse bool                   // true when the request was responded to
 }
 
@@ -254,6 +261,8 @@ func initClient(conn ServerCodec, services *serviceRegistry, cfg *clientConfig)
 		idgen:                cfg.idgen,
 		batchItemLimit:       cfg.batchItemLimit,
 		batchResponseMaxSize: cfg.batchResponseLimit,
+		reconnectMinBackoff:  cfg.reconnectMinBackoff,
+		reconnectMaxBackoff:  cfg.reconnectMaxBackoff,
 		writeConn:            conn,
 		close:                make(chan struct{}),
 		closing:              make(chan struct{}),
@@ -534,6 +543,7 @@ func (c *Client) Subscribe(ctx context.Context, namespace string, channel interf
 		resp: make(chan []*jsonrpcMessage, 1),
 		sub:  newClientSubscription(c, namespace, chanVal),
 	}
+	op.sub.params = msg.Params
 
 	// Send the subscription request.
 	// The arrival and validity of the response is signaled on sub.quit.
@@ -623,6 +633,114 @@ func (c *Client) reconnect(ctx context.Context) error {
 	}
 }
 
+// ReconnectEvent is posted by a client when its connection was re-established
+// automatically after it was lost, see WithReconnect.
+type ReconnectEvent struct {
+	Err           error // error which broke the connection
+	Attempts      int   // number of dials, zero if a concurrent call reconnected first
+	Subscriptions int   // number of subscriptions re-established on the new connection
+}
+
+// SubscribeReconnects subscribes to notifications about the client reconnecting
+// automatically. The events are only posted for clients created with WithReconnect.
+func (c *Client) SubscribeReconnects(ch chan<- ReconnectEvent) event.Subscription {
+	return c.reconnectFeed.Subscribe(ch)
+}
+
+func (c *Client) autoReconnect() bool {
+	return c.reconnectFunc != nil && c.reconnectMinBackoff > 0
+}
+
+// reconnectLoop redials the lost connection until it succeeds, then re-issues the
+// subscriptions which were active on it. It holds the write lock while dialing, so
+// requests wait for the new connection instead of failing on the broken one.
+func (c *Client) reconnectLoop(dead ServerCodec, cause error, subs []*ClientSubscription) {
+	lock := new(requestOp)
+	select {
+	case c.reqInit <- lock:
+	case <-c.closing:
+		closeClientSubscriptions(subs, ErrClientQuit)
+		return
+	}
+	var attempts int
+	if c.writeConn == nil || c.writeConn == dead {
+		for backoff := c.reconnectMinBackoff; ; backoff *= 2 {
+			if backoff > c.reconnectMaxBackoff {
+				backoff = c.reconnectMaxBackoff
+			}
+			attempts++
+			err := c.reconnect(context.Background())
+			if err == nil {
+				break
+			}
+			if err == ErrClientQuit {
+				closeClientSubscriptions(subs, ErrClientQuit)
+				return
+			}
+			log.Debug("RPC client reconnect failed", "attempts", attempts, "backoff", backoff, "err", err)
+			select {
+			case <-time.After(backoff):
+			case <-c.closing:
+				closeClientSubscriptions(subs, ErrClientQuit)
+				return
+			}
+		}
+	}
+	c.reqSent <- nil
+
+	resubscribed := c.resubscribe(subs)
+	log.Debug("RPC client reconnected after connection loss", "err", cause, "attempts", attempts, "subscriptions", resubscribed)
+	c.reconnectFeed.Send(ReconnectEvent{Err: cause, Attempts: attempts, Subscriptions: resubscribed})
+}
+
+// resubscribe re-issues the given subscriptions on the current connection, so they
+// keep delivering notifications to their channels. Subscriptions which cannot be
+// re-established are ended with the error. It returns the number of subscriptions
+// re-established.
+func (c *Client) resubscribe(subs []*ClientSubscription) int {
+	var resubscribed int
+	for _, sub := range subs {
+		select {
+		case <-sub.forwardDone:
+			continue // unsubscribed meanwhile
+		default:
+		}
+		msg := &jsonrpcMessage{Version: vsn, ID: c.nextID(), Method: sub.namespace + subscribeMethodSuffix, Params: sub.params}
+		op := &requestOp{
+			ids:         []json.RawMessage{msg.ID},
+			resp:        make(chan []*jsonrpcMessage, 1),
+			sub:         sub,
+			resubscribe: true,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+		err := c.send(ctx, op, msg)
+		if err == nil {
+			_, err = op.wait(ctx, c)
+		}
+		cancel()
+		if err != nil {
+			log.Debug("RPC client failed to resubscribe", "method", msg.Method, "err", err)
+			sub.close(err)
+			continue
+		}
+		select {
+		case <-sub.forwardDone:
+			// Unsubscribed while the subscription was being re-established, which
+			// may have sent the previous ID. Unsubscribe again to be sure.
+			sub.requestUnsubscribe()
+		default:
+			resubscribed++
+		}
+	}
+	return resubscribed
+}
+
+func closeClientSubscriptions(subs []*ClientSubscription, err error) {
+	for _, sub := range subs {
+		sub.close(err)
+	}
+}
+
 // dispatch is the main loop of the client.
 // It sends read messages to waiting calls to Call and BatchCall
 // and subscription notifications to registered subscriptions.
@@ -660,6 +778,10 @@ func (c *Client) dispatch(codec ServerCodec) {
 
 		case err := <-c.readErr:
 			conn.handler.log.Debug("RPC connection read error", "err", err)
+			if c.autoReconnect() {
+				subs := conn.handler.takeClientSubscriptions()
+				go c.reconnectLoop(conn.codec, err, subs)
+			}
 			conn.close(err, lastOp)
 			reading = false
 
@@ -672,6 +794,10 @@ func (c *Client) dispatch(codec ServerCodec) {
 				// In those cases the caller will notice first and reconnect. Closing the
 				// handler terminates all waiting requests (closing op.resp) except for
 				// lastOp, which will be transferred to the new handler.
+				if c.autoReconnect() {
+					subs := conn.handler.takeClientSubscriptions()
+					go c.resubscribe(subs)
+				}
 				conn.close(errClientReconnected, lastOp)
 				c.drainRead()
 			}
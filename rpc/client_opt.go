@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -41,6 +42,10 @@ type clientConfig struct {
 	idgen              func() ID
 	batchItemLimit     int
 	batchResponseLimit int
+
+	// Automatic reconnection, disabled if minBackoff is zero
+	reconnectMinBackoff time.Duration
+	reconnectMaxBackoff time.Duration
 }
 
 func (cfg *clientConfig) initHeaders() {
@@ -142,3 +147,23 @@ func WithBatchResponseSizeLimit(sizeLimit int) ClientOption {
 		cfg.batchResponseLimit = sizeLimit
 	})
 }
+
+// WithReconnect enables automatic reconnection for WebSocket and IPC clients. When the
+// connection is lost, the client redials in the background, waiting between attempts
+// for a backoff which starts at minBackoff and doubles up to maxBackoff. Active
+// subscriptions are re-established on the new connection and keep delivering to their
+// channels. Use Client.SubscribeReconnects to be notified of reconnections.
+//
+// Note: notifications sent by the server while the client is disconnected are lost.
+func WithReconnect(minBackoff, maxBackoff time.Duration) ClientOption {
+	if minBackoff <= 0 {
+		panic("non-positive reconnect backoff")
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.reconnectMinBackoff = minBackoff
+		cfg.reconnectMaxBackoff = maxBackoff
+	})
+}
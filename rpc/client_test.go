@@ -806,6 +806,80 @@ func TestClientReconnect(t *testing.T) {
 	}
 }
 
+// This test checks that a client created with WithReconnect redials a lost connection
+// and re-establishes its subscriptions, which keep delivering to the same channel.
+func TestClientReconnectResubscribe(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer()
+	srv.RegisterName("nftest", new(notificationTestService))
+	defer srv.Stop()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("can't listen:", err)
+	}
+	listener := &trackingListener{Listener: l}
+	defer listener.Close()
+	go http.Serve(listener, srv.WebsocketHandler([]string{"*"}, 0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := DialOptions(ctx, "ws://"+l.Addr().String(), WithReconnect(10*time.Millisecond, 100*time.Millisecond))
+	if err != nil {
+		t.Fatal("can't dial", err)
+	}
+	defer client.Close()
+
+	reconnects := make(chan ReconnectEvent, 1)
+	rsub := client.SubscribeReconnects(reconnects)
+	defer rsub.Unsubscribe()
+
+	nc := make(chan int)
+	sub, err := client.Subscribe(ctx, "nftest", nc, "someSubscription", 2, 10)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+
+	expect := func(want ...int) {
+		t.Helper()
+		for _, w := range want {
+			select {
+			case v := <-nc:
+				if v != w {
+					t.Fatalf("wrong notification: have %d, want %d", v, w)
+				}
+			case err := <-sub.Err():
+				t.Fatalf("subscription failed: %v", err)
+			case <-ctx.Done():
+				t.Fatalf("timed out waiting for notification %d", w)
+			}
+		}
+	}
+	expect(10, 11)
+
+	// Drop the connection. The subscription is re-issued on the new one, so the
+	// server sends its notifications again.
+	listener.closeConns()
+	select {
+	case ev := <-reconnects:
+		if ev.Err == nil || ev.Subscriptions != 1 {
+			t.Fatalf("unexpected reconnect event: %+v", ev)
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for reconnect")
+	}
+	expect(10, 11)
+
+	// Calls work on the new connection too.
+	var resp echoResult
+	if err := client.CallContext(ctx, &resp, "test_echo", "", 1, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func httpTestClient(srv *Server, transport string, fl *flakeyListener) (*Client, *httptest.Server) {
 	// Create the HTTP server.
 	var hs *httptest.Server
@@ -858,6 +932,32 @@ func ipcTestClient(srv *Server, fl *flakeyListener) (*Client, net.Listener) {
 }
 
 // flakeyListener kills accepted connections after a random timeout.
+// trackingListener is a listener which can close all accepted connections.
+type trackingListener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, c)
+		l.mu.Unlock()
+	}
+	return c, err
+}
+
+func (l *trackingListener) closeConns() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.conns {
+		c.Close()
+	}
+	l.conns = nil
+}
+
 type flakeyListener struct {
 	net.Listener
 	maxKillTimeout time.Duration
@@ -61,6 +61,8 @@ const (
 	errcodeDefault          = -32000
 	errcodeTimeout          = -32002
 	errcodeResponseTooLarge = -32003
+	errcodeQueueFull        = -32007
+	errcodeQueueTimeout     = -32006
 	errcodePanic            = -32603
 	errcodeMarshalError     = -32603
 
@@ -71,6 +73,8 @@ const (
 	errMsgTimeout          = "request timed out"
 	errMsgResponseTooLarge = "response too large"
 	errMsgBatchTooLarge    = "batch too large"
+	errMsgQueueFull        = "too many pending requests"
+	errMsgQueueTimeout     = "request queue timeout"
 )
 
 type methodNotFoundError struct{ method string }
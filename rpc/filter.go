@@ -0,0 +1,158 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// MethodRules selects the methods served by a server. A method is served if it
+// matches none of the Deny patterns and, unless Allow is empty, any of the Allow
+// patterns. Patterns are method names in which '*' matches any sequence of
+// characters, e.g. "debug_*" selects the whole debug namespace.
+//
+// Like with the API filter, subscriptions are selected by their name in their
+// namespace rather than by the subscribe method, e.g. "eth_newHeads" or
+// "eth_logs". Unsubscribing is always allowed.
+type MethodRules struct {
+	Allow []string `json:"allow" toml:",omitempty"`
+	Deny  []string `json:"deny" toml:",omitempty"`
+}
+
+// Empty reports whether the rules allow every method.
+func (r MethodRules) Empty() bool {
+	return len(r.Allow) == 0 && len(r.Deny) == 0
+}
+
+// Validate checks that the patterns of the rules are well-formed.
+func (r MethodRules) Validate() error {
+	for _, patterns := range [][]string{r.Allow, r.Deny} {
+		for _, pattern := range patterns {
+			if pattern == "" {
+				return errors.New("empty method pattern")
+			}
+		}
+	}
+	return nil
+}
+
+// Allowed reports whether the rules allow the given method.
+func (r MethodRules) Allowed(method string) bool {
+	for _, pattern := range r.Deny {
+		if matchMethod(pattern, method) {
+			return false
+		}
+	}
+	if len(r.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range r.Allow {
+		if matchMethod(pattern, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// filteredMethod returns the name the method rules are checked against for a
+// call, which is the subscription name for subscribe calls. It returns false
+// for the calls which are not filtered.
+func filteredMethod(msg *jsonrpcMessage) (string, bool) {
+	switch {
+	case msg.isUnsubscribe():
+		return "", false
+	case msg.isSubscribe():
+		name, err := parseSubscriptionName(msg.Params)
+		if err != nil {
+			// Let the subscription handler report the invalid parameters
+			return "", false
+		}
+		return msg.namespace() + serviceMethodSeparator + name, true
+	default:
+		return msg.Method, true
+	}
+}
+
+// matchMethod reports whether the method name matches the pattern.
+func matchMethod(pattern, method string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == method
+	}
+	// The first part is anchored at the start, the last one at the end, and
+	// the ones in between match their leftmost occurrences.
+	if !strings.HasPrefix(method, parts[0]) {
+		return false
+	}
+	method = method[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(method, part)
+		if i < 0 {
+			return false
+		}
+		method = method[i+len(part):]
+	}
+	return strings.HasSuffix(method, parts[len(parts)-1])
+}
+
+// MethodFilter applies method rules to the calls served by a server. Unlike
+// the filter of ApplyAPIFilter, the rules are checked on every call, so they
+// can be replaced while the server is running.
+type MethodFilter struct {
+	mu    sync.RWMutex
+	rules MethodRules
+}
+
+// NewMethodFilter creates a filter applying the given rules.
+func NewMethodFilter(rules MethodRules) (*MethodFilter, error) {
+	f := new(MethodFilter)
+	if err := f.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetRules replaces the rules of the filter.
+func (f *MethodFilter) SetRules(rules MethodRules) error {
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+	rules = MethodRules{
+		Allow: append([]string(nil), rules.Allow...),
+		Deny:  append([]string(nil), rules.Deny...),
+	}
+	f.mu.Lock()
+	f.rules = rules
+	f.mu.Unlock()
+	return nil
+}
+
+// Rules returns the current rules of the filter.
+func (f *MethodFilter) Rules() MethodRules {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.rules
+}
+
+// Allowed reports whether the current rules allow the given method.
+func (f *MethodFilter) Allowed(method string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.rules.Allowed(method)
+}
@@ -354,6 +354,17 @@ func (h *handler) cancelAllRequests(err error, inflightReq *requestOp) {
 	}
 }
 
+// takeClientSubscriptions removes the active client subscriptions, without
+// closing them, so they can be re-established on a new connection.
+func (h *handler) takeClientSubscriptions() []*ClientSubscription {
+	subs := make([]*ClientSubscription, 0, len(h.clientSubs))
+	for id, sub := range h.clientSubs {
+		delete(h.clientSubs, id)
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
 func (h *handler) addSubscriptions(nn []*Notifier) {
 	h.subLock.Lock()
 	defer h.subLock.Unlock()
@@ -407,10 +418,14 @@ func (h *handler) handleResponses(batch []*jsonrpcMessage, handleCall func(*json
 			if msg.Error != nil {
 				op.err = msg.Error
 			} else {
-				op.err = json.Unmarshal(msg.Result, &op.sub.subid)
+				var subid string
+				op.err = json.Unmarshal(msg.Result, &subid)
 				if op.err == nil {
-					go op.sub.run()
-					h.clientSubs[op.sub.subid] = op.sub
+					op.sub.setID(subid)
+					if !op.resubscribe {
+						go op.sub.run()
+					}
+					h.clientSubs[subid] = op.sub
 				}
 			}
 		}
@@ -468,6 +483,9 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 	case msg.isCall():
 		resp := h.handleCall(ctx, msg)
+		if recorder := h.reg.recorder; recorder != nil {
+			recorder.record(PeerInfoFromContext(ctx.ctx).Transport, msg, resp, start)
+		}
 		var ctx []interface{}
 		ctx = append(ctx, "reqid", idForLog{msg.ID}, "duration", time.Since(start))
 		if resp.Error != nil {
@@ -491,6 +509,20 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if filter := h.reg.methodFilter; filter != nil {
+		if method, ok := filteredMethod(msg); ok && !filter.Allowed(method) {
+			if msg.isSubscribe() {
+				name, _ := parseSubscriptionName(msg.Params)
+				return msg.errorResponse(&subscriptionNotFoundError{msg.namespace(), name})
+			}
+			return msg.errorResponse(&methodNotFoundError{method: msg.Method})
+		}
+	}
+	if limiter := h.reg.rateLimiter; limiter != nil {
+		if err := limiter.Allow(PeerInfoFromContext(cp.ctx), msg.Method); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -509,7 +541,12 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	var answer *jsonrpcMessage
+	if callb != h.unsubscribeCb && h.reg.responseCache != nil && h.reg.responseCache.Cacheable(msg.Method) {
+		answer = h.runCachedMethod(cp.ctx, msg, callb, args)
+	} else {
+		answer = h.runMethod(cp.ctx, msg, callb, args)
+	}
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -562,13 +599,44 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 
 // runMethod runs the Go callback for an RPC method.
 func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
+	var exec *execution
+	if limiter := h.reg.executionLimiter; limiter != nil && callb != h.unsubscribeCb && !msg.isSubscribe() {
+		var err error
+		if exec, err = limiter.enter(ctx, msg.Method); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
+	if exec != nil {
+		defer exec.exit()
+		ctx = exec.ctx
+	}
 	result, err := callb.call(ctx, msg.Method, args)
+	if exec != nil && exec.timedOut() {
+		return msg.errorResponse(&internalServerError{errcodeTimeout, errMsgTimeout})
+	}
 	if err != nil {
 		return msg.errorResponse(err)
 	}
 	return msg.response(result)
 }
 
+// runCachedMethod runs the method, unless its result is in the response cache.
+func (h *handler) runCachedMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
+	params, err := canonicalParams(msg.Params)
+	if err != nil {
+		return h.runMethod(ctx, msg, callb, args)
+	}
+	cache := h.reg.responseCache
+	if result, ok := cache.Get(ctx, msg.Method, params); ok {
+		return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: result}
+	}
+	answer := h.runMethod(ctx, msg, callb, args)
+	if answer.Error == nil {
+		cache.Put(ctx, msg.Method, params, answer.Result)
+	}
+	return answer
+}
+
 // unsubscribe is the callback function for all *_unsubscribe calls.
 func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 	h.subLock.Lock()
@@ -0,0 +1,187 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// ExecutionLimit bounds the executions of the calls of a namespace or method.
+type ExecutionLimit struct {
+	MaxConcurrent int           // calls executed at once, zero for no limit
+	MaxQueued     int           // calls waiting for execution, beyond which calls are rejected, zero for no limit (requires MaxConcurrent)
+	QueueTimeout  time.Duration // time a call may wait for execution, zero waits until the request is cancelled (requires MaxConcurrent)
+	Timeout       time.Duration // execution time after which the context of the call is cancelled, zero for no limit
+}
+
+// ExecutionLimiter applies execution limits to the calls served, by method or
+// by namespace. The calls of the methods limited by their namespace share the
+// limit of the namespace, and a limiter set on several servers shares its limits
+// between them.
+type ExecutionLimiter struct {
+	limits map[string]*executionLimit // by method or namespace
+}
+
+// executionLimit is the state of an execution limit.
+type executionLimit struct {
+	ExecutionLimit
+	slots  chan struct{} // nil without concurrency limit
+	queued atomic.Int64
+
+	queuedGauge   metrics.Gauge
+	runningGauge  metrics.Gauge
+	rejectedMeter metrics.Meter
+	timeoutMeter  metrics.Meter
+}
+
+// NewExecutionLimiter creates a limiter applying the limits configured by method
+// name (e.g. "debug_traceCall") or by namespace (e.g. "debug"). The limit of a
+// method takes precedence over the one of its namespace.
+func NewExecutionLimiter(limits map[string]ExecutionLimit) (*ExecutionLimiter, error) {
+	l := &ExecutionLimiter{limits: make(map[string]*executionLimit)}
+	for name, limit := range limits {
+		if name == "" {
+			return nil, errors.New("execution limit without method or namespace")
+		}
+		if limit.MaxConcurrent < 0 || limit.MaxQueued < 0 || limit.QueueTimeout < 0 || limit.Timeout < 0 {
+			return nil, fmt.Errorf("invalid execution limit of %s", name)
+		}
+		// Calls only wait for execution if their concurrency is limited
+		if limit.MaxConcurrent == 0 && (limit.MaxQueued > 0 || limit.QueueTimeout > 0) {
+			return nil, fmt.Errorf("execution limit of %s bounds the queue without limiting concurrency", name)
+		}
+		prefix := "rpc/limits/" + name
+		el := &executionLimit{
+			ExecutionLimit: limit,
+			queuedGauge:    metrics.GetOrRegisterGauge(prefix+"/queued", nil),
+			runningGauge:   metrics.GetOrRegisterGauge(prefix+"/running", nil),
+			rejectedMeter:  metrics.GetOrRegisterMeter(prefix+"/rejected", nil),
+			timeoutMeter:   metrics.GetOrRegisterMeter(prefix+"/timeout", nil),
+		}
+		if limit.MaxConcurrent > 0 {
+			el.slots = make(chan struct{}, limit.MaxConcurrent)
+		}
+		l.limits[name] = el
+	}
+	return l, nil
+}
+
+// SetExecutionLimiter sets the limiter of the executions of the calls served.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetExecutionLimiter(limiter *ExecutionLimiter) {
+	s.services.executionLimiter = limiter
+}
+
+// limit returns the execution limit of the method, or nil if it's not limited.
+func (l *ExecutionLimiter) limit(method string) *executionLimit {
+	if limit, ok := l.limits[method]; ok {
+		return limit
+	}
+	namespace, _, _ := strings.Cut(method, serviceMethodSeparator)
+	return l.limits[namespace]
+}
+
+// enter waits until the call of the method may be executed. The returned
+// execution is nil if the method is not limited, otherwise the call has to be
+// executed with its context, and exit called once it returns.
+func (l *ExecutionLimiter) enter(ctx context.Context, method string) (*execution, error) {
+	limit := l.limit(method)
+	if limit == nil {
+		return nil, nil
+	}
+	if err := limit.acquire(ctx); err != nil {
+		return nil, err
+	}
+	limit.runningGauge.Inc(1)
+
+	exec := &execution{limit: limit, parent: ctx, ctx: ctx, cancel: func() {}}
+	if limit.Timeout > 0 {
+		exec.ctx, exec.cancel = context.WithTimeout(ctx, limit.Timeout)
+	}
+	return exec, nil
+}
+
+// acquire waits for an execution slot.
+func (l *executionLimit) acquire(ctx context.Context) error {
+	if l.slots == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	queued := l.queued.Add(1)
+	defer func() {
+		l.queuedGauge.Update(l.queued.Add(-1))
+	}()
+	if l.MaxQueued > 0 && queued > int64(l.MaxQueued) {
+		l.rejectedMeter.Mark(1)
+		return &internalServerError{errcodeQueueFull, errMsgQueueFull}
+	}
+	l.queuedGauge.Update(queued)
+
+	var timeout <-chan time.Time
+	if l.QueueTimeout > 0 {
+		timer := time.NewTimer(l.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		l.rejectedMeter.Mark(1)
+		return &internalServerError{errcodeQueueTimeout, errMsgQueueTimeout}
+	case <-ctx.Done():
+		return &internalServerError{errcodeTimeout, errMsgTimeout}
+	}
+}
+
+// execution is a call executed within an execution limit.
+type execution struct {
+	limit  *executionLimit
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// timedOut reports whether the execution was cancelled by the timeout of the limit.
+func (e *execution) timedOut() bool {
+	return e.limit.Timeout > 0 && errors.Is(e.ctx.Err(), context.DeadlineExceeded) && e.parent.Err() == nil
+}
+
+// exit releases the execution slot of the call.
+func (e *execution) exit() {
+	if e.timedOut() {
+		e.limit.timeoutMeter.Mark(1)
+	}
+	e.cancel()
+	e.limit.runningGauge.Dec(1)
+	if e.limit.slots != nil {
+		<-e.limit.slots
+	}
+}
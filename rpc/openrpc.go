@@ -0,0 +1,349 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// openRPCVersion is the version of the OpenRPC specification of the documents.
+const openRPCVersion = "1.2.6"
+
+// OpenRPCDocument describes the methods served by a server, following the
+// OpenRPC specification (https://spec.open-rpc.org).
+type OpenRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []OpenRPCMethod   `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+}
+
+// OpenRPCInfo is the metadata of an OpenRPC document.
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenRPCMethod describes a method. The parameters are positional, the names
+// of the Go parameters are not available, so they are named by position.
+type OpenRPCMethod struct {
+	Name   string              `json:"name"`
+	Params []OpenRPCDescriptor `json:"params"`
+	Result OpenRPCDescriptor   `json:"result"`
+
+	// Subscriptions holds the parameters following the name of the subscription,
+	// for the subscribe methods.
+	Subscriptions map[string][]OpenRPCDescriptor `json:"x-subscriptions,omitempty"`
+}
+
+// OpenRPCDescriptor describes a parameter or the result of a method.
+type OpenRPCDescriptor struct {
+	Name     string     `json:"name"`
+	Required bool       `json:"required,omitempty"`
+	Schema   JSONSchema `json:"schema"`
+}
+
+// OpenRPCComponents holds the schemas referenced by the methods.
+type OpenRPCComponents struct {
+	Schemas map[string]JSONSchema `json:"schemas"`
+}
+
+// JSONSchema is a JSON schema.
+type JSONSchema map[string]interface{}
+
+var (
+	hexPattern      = "^0x[0-9a-fA-F]*$"
+	quantityPattern = "^0x(0|[1-9a-fA-F][0-9a-fA-F]*)$"
+
+	quantitySchema    = JSONSchema{"type": "string", "pattern": quantityPattern}
+	hashSchema        = JSONSchema{"type": "string", "pattern": "^0x[0-9a-fA-F]{64}$"}
+	blockNumberSchema = JSONSchema{"oneOf": []JSONSchema{
+		{"type": "string", "enum": []string{"earliest", "latest", "pending", "safe", "finalized"}},
+		quantitySchema,
+	}}
+	blockNumberOrHashSchema = JSONSchema{"oneOf": []JSONSchema{
+		blockNumberSchema,
+		hashSchema,
+		{"type": "object", "properties": JSONSchema{
+			"blockNumber":      blockNumberSchema,
+			"blockHash":        hashSchema,
+			"requireCanonical": JSONSchema{"type": "boolean"},
+		}},
+	}}
+
+	// knownSchemas are the schemas of the types with custom JSON encodings.
+	knownSchemas = map[reflect.Type]JSONSchema{
+		reflect.TypeOf(common.Hash{}):       hashSchema,
+		reflect.TypeOf(common.Address{}):    {"type": "string", "pattern": "^0x[0-9a-fA-F]{40}$"},
+		reflect.TypeOf(hexutil.Bytes{}):     {"type": "string", "pattern": hexPattern},
+		reflect.TypeOf(hexutil.Big{}):       quantitySchema,
+		reflect.TypeOf(hexutil.U256{}):      quantitySchema,
+		reflect.TypeOf(hexutil.Uint64(0)):   quantitySchema,
+		reflect.TypeOf(hexutil.Uint(0)):     quantitySchema,
+		reflect.TypeOf(big.Int{}):           quantitySchema,
+		reflect.TypeOf(time.Time{}):         {"type": "string", "format": "date-time"},
+		reflect.TypeOf(json.RawMessage{}):   {},
+		reflect.TypeOf(BlockNumber(0)):      blockNumberSchema,
+		reflect.TypeOf(ID("")):              {"type": "string"},
+		reflect.TypeOf(BlockNumberOrHash{}): blockNumberOrHashSchema,
+	}
+
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// OpenRPC generates the OpenRPC document of the methods served, mapping the Go
+// types of their parameters and results to JSON schemas.
+func (s *Server) OpenRPC() *OpenRPCDocument {
+	return s.services.openRPC()
+}
+
+// Discover returns the OpenRPC document of the methods served.
+func (s *RPCService) Discover() *OpenRPCDocument {
+	return s.server.OpenRPC()
+}
+
+func (r *serviceRegistry) openRPC() *OpenRPCDocument {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var (
+		gen = &schemaGenerator{schemas: make(map[string]JSONSchema), names: make(map[reflect.Type]string)}
+		doc = &OpenRPCDocument{
+			OpenRPC: openRPCVersion,
+			Info:    OpenRPCInfo{Title: "JSON-RPC API", Version: "1.0.0"},
+			Methods: []OpenRPCMethod{},
+		}
+	)
+	allowed := func(method string) bool {
+		return r.methodFilter == nil || r.methodFilter.Allowed(method)
+	}
+	for _, svc := range r.services {
+		for name, cb := range svc.callbacks {
+			if method := svc.name + serviceMethodSeparator + name; allowed(method) {
+				doc.Methods = append(doc.Methods, gen.method(method, cb))
+			}
+		}
+		// Subscriptions are filtered by name, see MethodRules
+		subscriptions := make(map[string]*callback)
+		for name, cb := range svc.subscriptions {
+			if allowed(svc.name + serviceMethodSeparator + name) {
+				subscriptions[name] = cb
+			}
+		}
+		if len(subscriptions) == 0 {
+			continue
+		}
+		doc.Methods = append(doc.Methods, gen.subscribeMethod(svc.name+subscribeMethodSuffix, subscriptions))
+		doc.Methods = append(doc.Methods, OpenRPCMethod{
+			Name:   svc.name + unsubscribeMethodSuffix,
+			Params: []OpenRPCDescriptor{{Name: "subscription", Required: true, Schema: JSONSchema{"type": "string"}}},
+			Result: OpenRPCDescriptor{Name: "result", Schema: JSONSchema{"type": "boolean"}},
+		})
+	}
+	sort.Slice(doc.Methods, func(i, j int) bool { return doc.Methods[i].Name < doc.Methods[j].Name })
+	doc.Components.Schemas = gen.schemas
+	return doc
+}
+
+// schemaGenerator maps Go types to JSON schemas. The schemas of named struct
+// types are collected as components and referenced, which supports recursive
+// types.
+type schemaGenerator struct {
+	schemas map[string]JSONSchema
+	names   map[reflect.Type]string
+}
+
+// method describes a callback.
+func (g *schemaGenerator) method(name string, cb *callback) OpenRPCMethod {
+	m := OpenRPCMethod{Name: name, Params: []OpenRPCDescriptor{}}
+	for i, typ := range cb.argTypes {
+		m.Params = append(m.Params, g.param(i, typ))
+	}
+	m.Result = OpenRPCDescriptor{Name: "result", Schema: JSONSchema{"type": "null"}}
+	fntype := cb.fn.Type()
+	for i := 0; i < fntype.NumOut(); i++ {
+		if i != cb.errPos {
+			m.Result.Schema = g.schema(fntype.Out(i))
+		}
+	}
+	return m
+}
+
+// subscribeMethod describes the subscribe method of a namespace, whose first
+// parameter selects the subscription.
+func (g *schemaGenerator) subscribeMethod(name string, subscriptions map[string]*callback) OpenRPCMethod {
+	names := make([]string, 0, len(subscriptions))
+	for name := range subscriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	m := OpenRPCMethod{
+		Name:          name,
+		Params:        []OpenRPCDescriptor{{Name: "subscription", Required: true, Schema: JSONSchema{"type": "string", "enum": names}}},
+		Result:        OpenRPCDescriptor{Name: "subscriptionId", Schema: JSONSchema{"type": "string"}},
+		Subscriptions: make(map[string][]OpenRPCDescriptor),
+	}
+	for _, name := range names {
+		params := []OpenRPCDescriptor{}
+		for i, typ := range subscriptions[name].argTypes {
+			params = append(params, g.param(i, typ))
+		}
+		m.Subscriptions[name] = params
+	}
+	return m
+}
+
+// param describes the i-th parameter of a method. Pointer parameters are optional.
+func (g *schemaGenerator) param(i int, typ reflect.Type) OpenRPCDescriptor {
+	return OpenRPCDescriptor{
+		Name:     fmt.Sprintf("param%d", i+1),
+		Required: typ.Kind() != reflect.Ptr,
+		Schema:   g.schema(typ),
+	}
+}
+
+// schema returns the JSON schema of the Go type.
+func (g *schemaGenerator) schema(typ reflect.Type) JSONSchema {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if schema, ok := knownSchemas[typ]; ok {
+		return schema
+	}
+	// The types with custom encodings are opaque, their fields not being the
+	// ones encoded, as for the gencodec types
+	ptr := reflect.PointerTo(typ)
+	if typ.Implements(jsonMarshalerType) || ptr.Implements(jsonMarshalerType) {
+		return JSONSchema{"x-go-type": typ.String()}
+	}
+	if typ.Implements(textMarshalerType) || ptr.Implements(textMarshalerType) {
+		return JSONSchema{"type": "string", "x-go-type": typ.String()}
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return JSONSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return JSONSchema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return JSONSchema{"type": "number"}
+	case reflect.String:
+		return JSONSchema{"type": "string"}
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return JSONSchema{"type": "string", "contentEncoding": "base64"}
+		}
+		return JSONSchema{"type": "array", "items": g.schema(typ.Elem())}
+	case reflect.Array:
+		return JSONSchema{"type": "array", "items": g.schema(typ.Elem()), "minItems": typ.Len(), "maxItems": typ.Len()}
+	case reflect.Map:
+		return JSONSchema{"type": "object", "additionalProperties": g.schema(typ.Elem())}
+	case reflect.Struct:
+		return g.structSchema(typ)
+	default:
+		// Interfaces, and the types which can't be encoded
+		return JSONSchema{}
+	}
+}
+
+// structSchema returns a reference to the schema of a struct type, generating it
+// on first use. The schemas of anonymous structs are inlined.
+func (g *schemaGenerator) structSchema(typ reflect.Type) JSONSchema {
+	if typ.Name() == "" {
+		return g.objectSchema(typ)
+	}
+	name, ok := g.names[typ]
+	if !ok {
+		name = path.Base(typ.PkgPath()) + "." + typ.Name()
+		name = strings.Map(func(r rune) rune {
+			if r == '.' || r == '_' || r == '-' || '0' <= r && r <= '9' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' {
+				return r
+			}
+			return '_'
+		}, name)
+		for base, i := name, 2; ; i++ {
+			if _, taken := g.schemas[name]; !taken {
+				break
+			}
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		g.names[typ] = name
+		g.schemas[name] = nil // reserved while generating recursive types
+		g.schemas[name] = g.objectSchema(typ)
+	}
+	return JSONSchema{"$ref": "#/components/schemas/" + name}
+}
+
+// objectSchema returns the schema of the JSON object encoding the struct type.
+func (g *schemaGenerator) objectSchema(typ reflect.Type) JSONSchema {
+	var (
+		properties = make(JSONSchema)
+		required   []string
+	)
+	g.addFields(typ, properties, &required)
+	schema := JSONSchema{"type": "object", "properties": properties, "x-go-type": typ.String()}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the properties of the struct fields encoded by encoding/json,
+// including the ones of embedded structs.
+func (g *schemaGenerator) addFields(typ reflect.Type, properties JSONSchema, required *[]string) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			ftype := field.Type
+			if ftype.Kind() == reflect.Ptr {
+				ftype = ftype.Elem()
+			}
+			if ftype.Kind() == reflect.Struct {
+				g.addFields(ftype, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+		if field.Type.Kind() != reflect.Ptr && !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
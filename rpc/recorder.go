@@ -0,0 +1,264 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// recordQueueSize is the number of calls buffered for the writer of a recorder.
+// The calls recorded while the queue is full are dropped.
+const recordQueueSize = 1024
+
+var (
+	recordedCallsMeter = metrics.NewRegisteredMeter("rpc/recorder/recorded", nil)
+	droppedCallsMeter  = metrics.NewRegisteredMeter("rpc/recorder/dropped", nil)
+)
+
+// DefaultRedactedMethods are the methods whose parameters are redacted by default,
+// as they carry the signed payloads of the users.
+var DefaultRedactedMethods = []string{"eth_sendRawTransaction", "eth_sendRawTransactionConditional"}
+
+// RecorderConfig configures the capture of the calls served by a server.
+type RecorderConfig struct {
+	Path       string   // file the calls are appended to, as JSON lines
+	MaxSize    int64    // size in bytes at which the file is rotated, zero disables rotation
+	MaxFiles   int      // number of rotated files kept, as Path.1 (newest) to Path.N
+	SampleRate float64  // fraction of the calls recorded, zero records all of them
+	Redact     []string // methods whose parameters are replaced by their hash
+}
+
+// RecordedCall is a call captured by a Recorder.
+type RecordedCall struct {
+	Time      time.Time       `json:"time"`
+	Transport string          `json:"transport"`
+	Method    string          `json:"method"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	Redacted  bool            `json:"redacted,omitempty"` // params hold the sha256 hash of the original ones
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *RecordedError  `json:"error,omitempty"`
+	Duration  time.Duration   `json:"duration"` // nanoseconds
+}
+
+// RecordedError is the error response of a recorded call.
+type RecordedError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Recorder writes the calls served by a server to a rotating file of JSON lines.
+// Subscriptions are not recorded.
+type Recorder struct {
+	config RecorderConfig
+	redact map[string]bool
+	queue  chan *RecordedCall
+	done   chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+
+	file *os.File // only accessed by the writer, nil once recording is disabled
+	buf  *bufio.Writer
+	size int64
+}
+
+// NewRecorder opens the capture file and starts a recorder writing to it.
+func NewRecorder(config RecorderConfig) (*Recorder, error) {
+	if config.Path == "" {
+		return nil, errors.New("no capture file")
+	}
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("invalid sample rate %v", config.SampleRate)
+	}
+	r := &Recorder{
+		config: config,
+		redact: make(map[string]bool),
+		queue:  make(chan *RecordedCall, recordQueueSize),
+		done:   make(chan struct{}),
+	}
+	for _, method := range config.Redact {
+		r.redact[method] = true
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	go r.loop()
+	return r, nil
+}
+
+// SetRecorder sets the recorder capturing the calls served.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetRecorder(recorder *Recorder) {
+	s.services.recorder = recorder
+}
+
+// Close stops the recorder, flushing the recorded calls to the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.queue)
+	r.mu.Unlock()
+
+	<-r.done
+	if r.file == nil {
+		return nil // recording was disabled
+	}
+	if err := r.buf.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// record captures a served call, unless it's not sampled.
+func (r *Recorder) record(transport string, msg, resp *jsonrpcMessage, start time.Time) {
+	if r.config.SampleRate > 0 && r.config.SampleRate < 1 && rand.Float64() >= r.config.SampleRate {
+		return
+	}
+	call := &RecordedCall{
+		Time:      start,
+		Transport: transport,
+		Method:    msg.Method,
+		Params:    msg.Params,
+		Duration:  time.Since(start),
+	}
+	if r.redact[msg.Method] {
+		hash := sha256.Sum256(msg.Params)
+		call.Params, _ = json.Marshal("0x" + hex.EncodeToString(hash[:]))
+		call.Redacted = true
+	}
+	if resp.Error != nil {
+		call.Error = &RecordedError{Code: resp.Error.Code, Message: resp.Error.Message}
+		if resp.Error.Data != nil {
+			call.Error.Data, _ = json.Marshal(resp.Error.Data)
+		}
+	} else {
+		call.Result = resp.Result
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.queue <- call:
+	default:
+		droppedCallsMeter.Mark(1)
+	}
+}
+
+// loop writes the recorded calls to the file until the recorder is closed. If
+// the file can't be rotated, recording is disabled and the calls are dropped.
+func (r *Recorder) loop() {
+	defer close(r.done)
+	for call := range r.queue {
+		if r.file == nil {
+			droppedCallsMeter.Mark(1)
+			continue
+		}
+		line, err := json.Marshal(call)
+		if err != nil {
+			log.Warn("Failed to encode recorded RPC call", "method", call.Method, "err", err)
+			continue
+		}
+		line = append(line, '\n')
+		if r.config.MaxSize > 0 && r.size > 0 && r.size+int64(len(line)) > r.config.MaxSize {
+			if err := r.rotate(); err != nil {
+				log.Error("Failed to rotate RPC capture file, disabling recording", "path", r.config.Path, "err", err)
+				r.file.Close()
+				r.file, r.buf = nil, nil
+				droppedCallsMeter.Mark(1)
+				continue
+			}
+		}
+		n, err := r.buf.Write(line)
+		r.size += int64(n)
+		if err != nil {
+			log.Warn("Failed to write recorded RPC call", "path", r.config.Path, "err", err)
+			continue
+		}
+		recordedCallsMeter.Mark(1)
+		// Flush when idle, so the capture can be tailed
+		if len(r.queue) == 0 {
+			r.buf.Flush()
+		}
+	}
+}
+
+// open opens the capture file for appending.
+func (r *Recorder) open() error {
+	file, err := os.OpenFile(r.config.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.buf, r.size = file, bufio.NewWriter(file), stat.Size()
+	return nil
+}
+
+// rotate moves the capture file to Path.1, shifting the older rotated files and
+// deleting the ones beyond MaxFiles, then starts a new file. The old file is
+// only closed once the new one is open, and is kept on failure.
+func (r *Recorder) rotate() error {
+	if err := r.buf.Flush(); err != nil {
+		return err
+	}
+	if r.config.MaxFiles <= 0 {
+		if err := os.Remove(r.config.Path); err != nil {
+			return err
+		}
+	} else {
+		os.Remove(fmt.Sprintf("%s.%d", r.config.Path, r.config.MaxFiles))
+		for i := r.config.MaxFiles - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", r.config.Path, i), fmt.Sprintf("%s.%d", r.config.Path, i+1))
+		}
+		if err := os.Rename(r.config.Path, r.config.Path+".1"); err != nil {
+			return err
+		}
+	}
+	old := r.file
+	if err := r.open(); err != nil {
+		return err
+	}
+	if err := old.Close(); err != nil {
+		log.Warn("Failed to close rotated RPC capture file", "path", r.config.Path, "err", err)
+	}
+	return nil
+}
@@ -84,6 +84,30 @@ func (s *Server) ApplyAPIFilter(apiFilter map[string]bool) {
 	s.services.apiFilter = apiFilter
 }
 
+// SetMethodFilter sets the filter selecting the methods served. Calls of the other
+// methods are answered as if the methods didn't exist.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetMethodFilter(filter *MethodFilter) {
+	s.services.methodFilter = filter
+}
+
+// RateLimiter decides whether the calls of a client may be served.
+type RateLimiter interface {
+	// Allow is called before serving a call of the given method, including every
+	// item of a batch. If it returns an error, the call is answered with it.
+	Allow(peer PeerInfo, method string) error
+}
+
+// SetRateLimiter sets the rate limiter consulted before serving calls.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetRateLimiter(limiter RateLimiter) {
+	s.services.rateLimiter = limiter
+}
+
 // SetHTTPBodyLimit sets the size limit for HTTP requests.
 //
 // This method should be called before processing any requests via ServeHTTP.
@@ -214,6 +238,10 @@ type PeerInfo struct {
 	// Address of client. This will usually contain the IP address and port.
 	RemoteAddr string
 
+	// Identity of the client, if it was established by the HTTP handler stack
+	// serving the request, see WithPeerIdentity.
+	Identity string
+
 	// Additional information for HTTP and WebSocket connections.
 	HTTP struct {
 		// Protocol version, i.e. "HTTP/1.1". This is not set for WebSocket.
@@ -227,6 +255,23 @@ type PeerInfo struct {
 
 type peerInfoContextKey struct{}
 
+type peerIdentityContextKey struct{}
+
+// WithPeerIdentity returns a copy of the context of an HTTP request carrying the
+// identity of the client, e.g. the subject of its authentication token. It is
+// reported as PeerInfo.Identity for the calls served by the request, or by the
+// WebSocket connection upgraded from it.
+func WithPeerIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, peerIdentityContextKey{}, identity)
+}
+
+// PeerIdentityFromContext returns the identity of the client set on the context
+// by WithPeerIdentity, or an empty string if there is none.
+func PeerIdentityFromContext(ctx context.Context) string {
+	identity, _ := ctx.Value(peerIdentityContextKey{}).(string)
+	return identity
+}
+
 // PeerInfoFromContext returns information about the client's network connection.
 // Use this with the context passed to RPC method handler functions.
 //
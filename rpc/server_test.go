@@ -19,13 +19,19 @@ package rpc
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
 	"io"
+	"math/big"
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 func TestServerRegisterName(t *testing.T) {
@@ -192,3 +198,401 @@ func TestServerBatchResponseSizeLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestServerMethodFilter(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	filter, err := NewMethodFilter(MethodRules{
+		Allow: []string{"test_*", "rpc_modules"},
+		Deny:  []string{"test_*Error", "test_sleep"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetMethodFilter(filter)
+	client := DialInProc(server)
+	defer client.Close()
+
+	call := func(method string, args ...any) error {
+		var result any
+		return client.Call(&result, method, args...)
+	}
+	for _, method := range []string{"test_null", "rpc_modules"} {
+		if err := call(method); err != nil {
+			t.Errorf("%s: unexpected error: %v", method, err)
+		}
+	}
+	for _, method := range []string{"test_returnError", "test_marshalError", "test_sleep", "nftest_echo"} {
+		if err := call(method); err == nil || err.(Error).ErrorCode() != -32601 {
+			t.Errorf("%s: expected method not found, got %v", method, err)
+		}
+	}
+	// Replace the rules while serving
+	if err := filter.SetRules(MethodRules{Deny: []string{"*_null"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := call("test_null"); err == nil {
+		t.Error("test_null: expected error after reload")
+	}
+	if err := call("test_returnError"); err == nil || err.(Error).ErrorCode() == -32601 {
+		t.Errorf("test_returnError: expected method error after reload, got %v", err)
+	}
+	if err := filter.SetRules(MethodRules{Allow: []string{""}}); err == nil {
+		t.Error("expected error for empty pattern")
+	}
+}
+
+// Tests that subscriptions are filtered by their name, like the API filter does.
+func TestServerMethodFilterSubscriptions(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	filter, err := NewMethodFilter(MethodRules{Allow: []string{"nftest_someSubscription"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetMethodFilter(filter)
+	client := DialInProc(server)
+	defer client.Close()
+
+	sub, err := client.Subscribe(context.Background(), "nftest", make(chan int, 10), "someSubscription", 1, 0)
+	if err != nil {
+		t.Fatalf("allowed subscription failed: %v", err)
+	}
+	sub.Unsubscribe()
+	if err := <-sub.Err(); err != nil {
+		t.Fatalf("unsubscribe failed: %v", err)
+	}
+	if _, err := client.Subscribe(context.Background(), "nftest", make(chan int), "hangSubscription", 1); err == nil {
+		t.Fatal("expected error for filtered subscription")
+	}
+	// Allowing the subscribe method doesn't allow every subscription
+	if err := filter.SetRules(MethodRules{Allow: []string{"nftest_subscribe"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Subscribe(context.Background(), "nftest", make(chan int, 10), "someSubscription", 1, 0); err == nil {
+		t.Fatal("expected error for subscription not allowed by name")
+	}
+	doc := server.services.openRPC()
+	for _, m := range doc.Methods {
+		if strings.HasPrefix(m.Name, "nftest_") && strings.HasSuffix(m.Name, "subscribe") {
+			t.Errorf("unexpected method %s without allowed subscriptions", m.Name)
+		}
+	}
+}
+
+func TestMatchMethod(t *testing.T) {
+	tests := []struct {
+		pattern, method string
+		match           bool
+	}{
+		{"eth_call", "eth_call", true},
+		{"eth_call", "eth_callMany", false},
+		{"debug_*", "debug_traceTransaction", true},
+		{"debug_*", "debugx_trace", false},
+		{"*", "anything", true},
+		{"*_subscribe", "eth_subscribe", true},
+		{"*_subscribe", "eth_unsubscribe", false},
+		{"eth_*By*", "eth_getBlockByNumber", true},
+		{"eth_*By*Hash", "eth_getBlockByNumber", false},
+		{"a*a", "a", false},
+	}
+	for _, test := range tests {
+		if match := matchMethod(test.pattern, test.method); match != test.match {
+			t.Errorf("matchMethod(%q, %q) = %v, want %v", test.pattern, test.method, match, test.match)
+		}
+	}
+}
+
+type mapResponseCache map[string]json.RawMessage
+
+func (c mapResponseCache) Cacheable(method string) bool {
+	return method == "test_echo"
+}
+
+func (c mapResponseCache) Get(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, bool) {
+	result, ok := c[method+string(params)]
+	return result, ok
+}
+
+func (c mapResponseCache) Put(ctx context.Context, method string, params json.RawMessage, result json.RawMessage) {
+	c[method+string(params)] = append(json.RawMessage(nil), result...)
+}
+
+func TestServerResponseCache(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	cache := make(mapResponseCache)
+	server.SetResponseCache(cache)
+	client := DialInProc(server)
+	defer client.Close()
+
+	var first, second echoResult
+	if err := client.Call(&first, "test_echo", "0xAB", 1); err != nil {
+		t.Fatal(err)
+	}
+	// Equivalent parameters are served from the cache
+	if err := client.Call(&second, "test_echo", "0xab", 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if second.String != "0xAB" {
+		t.Errorf("expected cached result, got %+v", second)
+	}
+	if len(cache) != 1 {
+		t.Errorf("wrong number of cache entries: have %d, want 1", len(cache))
+	}
+	if _, ok := cache[`test_echo["0xab",1]`]; !ok {
+		t.Errorf("missing canonical cache entry, have %v", cache)
+	}
+	// Errors are not cached
+	if err := client.Call(nil, "test_returnError"); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(cache) != 1 {
+		t.Errorf("error cached")
+	}
+	// The methods which aren't cacheable bypass the cache
+	if err := client.Call(&first, "test_echoWithCtx", "0xAB", 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(cache) != 1 {
+		t.Errorf("result of a method which isn't cacheable cached")
+	}
+}
+
+func TestServerRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	recorder, err := NewRecorder(RecorderConfig{Path: path, MaxSize: 200, MaxFiles: 1, Redact: []string{"test_echo"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	defer server.Stop()
+	server.SetRecorder(recorder)
+	client := DialInProc(server)
+	defer client.Close()
+
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "secret", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(nil, "test_returnError"); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The first call was rotated out to the backup file
+	var calls []RecordedCall
+	for _, file := range []string{path + ".1", path} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 1 {
+			t.Fatalf("%s: wrong number of calls: %d", file, len(lines))
+		}
+		var call RecordedCall
+		if err := json.Unmarshal([]byte(lines[0]), &call); err != nil {
+			t.Fatal(err)
+		}
+		calls = append(calls, call)
+	}
+	if calls[0].Method != "test_echo" || !calls[0].Redacted || strings.Contains(string(calls[0].Params), "secret") {
+		t.Errorf("echo call not redacted: %+v", calls[0])
+	}
+	if calls[0].Transport == "" || len(calls[0].Result) == 0 {
+		t.Errorf("wrong echo call: %+v", calls[0])
+	}
+	if calls[1].Method != "test_returnError" || calls[1].Error == nil || calls[1].Error.Code != 444 {
+		t.Errorf("wrong error call: %+v", calls[1])
+	}
+}
+
+// Tests that a capture file which can't be rotated is kept, recording being
+// disabled instead of writing to a closed file.
+func TestServerRecorderRotateFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	recorder, err := NewRecorder(RecorderConfig{Path: path, MaxSize: 200, MaxFiles: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A non-empty directory in place of the backup file fails the rotation
+	if err := os.MkdirAll(filepath.Join(path+".1", "dir"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	defer server.Stop()
+	server.SetRecorder(recorder)
+	client := DialInProc(server)
+	defer client.Close()
+
+	var result echoResult
+	for i := 0; i < 3; i++ {
+		if err := client.Call(&result, "test_echo", "x", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 {
+		t.Fatalf("wrong number of calls: %d", len(lines))
+	}
+}
+
+func TestServerOpenRPC(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	filter, err := NewMethodFilter(MethodRules{Deny: []string{"test_panic"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetMethodFilter(filter)
+	client := DialInProc(server)
+	defer client.Close()
+
+	var doc OpenRPCDocument
+	if err := client.Call(&doc, "rpc_discover"); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenRPC != openRPCVersion {
+		t.Errorf("wrong openrpc version %q", doc.OpenRPC)
+	}
+	methods := make(map[string]OpenRPCMethod)
+	for _, m := range doc.Methods {
+		methods[m.Name] = m
+	}
+	for _, name := range []string{"rpc_discover", "rpc_modules", "test_echo", "test_returnError", "nftest_subscribe", "nftest_unsubscribe"} {
+		if _, ok := methods[name]; !ok {
+			t.Errorf("missing method %s", name)
+		}
+	}
+	if _, ok := methods["test_panic"]; ok {
+		t.Error("filtered method test_panic is documented")
+	}
+
+	echo := methods["test_echo"]
+	if len(echo.Params) != 3 {
+		t.Fatalf("test_echo: wrong number of params %d", len(echo.Params))
+	}
+	if echo.Params[0].Schema["type"] != "string" || echo.Params[1].Schema["type"] != "integer" {
+		t.Errorf("test_echo: wrong param schemas %v, %v", echo.Params[0].Schema, echo.Params[1].Schema)
+	}
+	if !echo.Params[0].Required || echo.Params[2].Required {
+		t.Error("test_echo: wrong required params")
+	}
+	if ref := echo.Result.Schema["$ref"]; ref != "#/components/schemas/rpc.echoResult" {
+		t.Errorf("test_echo: wrong result ref %v", ref)
+	}
+	result, ok := doc.Components.Schemas["rpc.echoResult"]
+	if !ok {
+		t.Fatal("missing component rpc.echoResult")
+	}
+	props, _ := result["properties"].(map[string]interface{})
+	for _, name := range []string{"String", "Int", "Args"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("rpc.echoResult: missing property %s", name)
+		}
+	}
+	if typ := methods["test_returnError"].Result.Schema["type"]; typ != "null" {
+		t.Errorf("test_returnError: wrong result type %v", typ)
+	}
+	sub := methods["nftest_subscribe"]
+	if enum, _ := sub.Params[0].Schema["enum"].([]interface{}); len(enum) == 0 {
+		t.Error("nftest_subscribe: no subscription names")
+	}
+	if _, ok := sub.Subscriptions["someSubscription"]; !ok {
+		t.Errorf("nftest_subscribe: missing someSubscription in %v", sub.Subscriptions)
+	}
+}
+
+// openRPCEncoded is a struct with a custom JSON encoding.
+type openRPCEncoded struct {
+	Field int
+}
+
+func (openRPCEncoded) MarshalJSON() ([]byte, error) { return []byte(`"encoded"`), nil }
+
+func TestOpenRPCSchema(t *testing.T) {
+	gen := &schemaGenerator{schemas: make(map[string]JSONSchema), names: make(map[reflect.Type]string)}
+	for _, tt := range []struct {
+		value interface{}
+		want  JSONSchema
+	}{
+		{new(big.Int), quantitySchema},
+		{new(hexutil.Big), quantitySchema},
+		{new(hexutil.U256), quantitySchema},
+		{hexutil.Uint64(0), quantitySchema},
+		{openRPCEncoded{}, JSONSchema{"x-go-type": "rpc.openRPCEncoded"}},
+		{new(openRPCEncoded), JSONSchema{"x-go-type": "rpc.openRPCEncoded"}},
+	} {
+		if have := gen.schema(reflect.TypeOf(tt.value)); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("%T: schema %v, want %v", tt.value, have, tt.want)
+		}
+	}
+	if len(gen.schemas) != 0 {
+		t.Errorf("components generated for opaque types: %v", gen.schemas)
+	}
+}
+
+func TestServerExecutionLimits(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	limiter, err := NewExecutionLimiter(map[string]ExecutionLimit{
+		"test":       {MaxConcurrent: 1, MaxQueued: 1, QueueTimeout: 300 * time.Millisecond},
+		"test_block": {Timeout: 50 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetExecutionLimiter(limiter)
+	client := DialInProc(server)
+	defer client.Close()
+
+	errorCode := func(err error) int {
+		if rpcErr, ok := err.(Error); ok {
+			return rpcErr.ErrorCode()
+		}
+		return 0
+	}
+	// The execution timeout cancels the context of the call
+	if err := client.Call(nil, "test_block"); errorCode(err) != errcodeTimeout {
+		t.Errorf("test_block: expected timeout, got %v", err)
+	}
+	// Occupy the execution slot of the namespace
+	running := make(chan error, 1)
+	go func() { running <- client.Call(nil, "test_sleep", time.Second) }()
+	time.Sleep(100 * time.Millisecond)
+
+	queued := make(chan error, 1)
+	go func() { queued <- client.Call(nil, "test_null") }()
+	time.Sleep(100 * time.Millisecond)
+
+	if err := client.Call(nil, "test_echo", "x", 1); errorCode(err) != errcodeQueueFull {
+		t.Errorf("test_echo: expected full queue, got %v", err)
+	}
+	if err := <-queued; errorCode(err) != errcodeQueueTimeout {
+		t.Errorf("test_null: expected queue timeout, got %v", err)
+	}
+	if err := <-running; err != nil {
+		t.Errorf("test_sleep: unexpected error: %v", err)
+	}
+	// Other namespaces are not limited, and freed slots are reused
+	if err := client.Call(nil, "nftest_echo", 1); err != nil {
+		t.Errorf("nftest_echo: unexpected error: %v", err)
+	}
+	if err := client.Call(nil, "test_null"); err != nil {
+		t.Errorf("test_null: unexpected error: %v", err)
+	}
+	if _, err := NewExecutionLimiter(map[string]ExecutionLimit{"debug": {MaxConcurrent: -1}}); err == nil {
+		t.Error("expected error for negative limit")
+	}
+	if _, err := NewExecutionLimiter(map[string]ExecutionLimit{"debug": {MaxQueued: 4}}); err == nil {
+		t.Error("expected error for queue limit without concurrency limit")
+	}
+}
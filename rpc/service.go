@@ -39,7 +39,12 @@ type serviceRegistry struct {
 	mu       sync.Mutex
 	services map[string]service
 
-	apiFilter map[string]bool
+	apiFilter        map[string]bool
+	methodFilter     *MethodFilter
+	rateLimiter      RateLimiter
+	responseCache    ResponseCache
+	recorder         *Recorder
+	executionLimiter *ExecutionLimiter
 }
 
 // service represents a registered object.
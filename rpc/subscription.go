@@ -213,7 +213,10 @@ type ClientSubscription struct {
 	etype     reflect.Type
 	channel   reflect.Value
 	namespace string
-	subid     string
+	params    json.RawMessage // arguments of the subscribe call, re-sent on reconnect
+
+	mu    sync.Mutex // protects subid, which changes when resubscribing
+	subid string
 
 	// The in channel receives notification values from client dispatcher.
 	in chan json.RawMessage
@@ -250,7 +253,8 @@ func newClientSubscription(c *Client, namespace string, channel reflect.Value) *
 }
 
 // Err returns the subscription error channel. The intended use of Err is to schedule
-// resubscription when the client connection is closed unexpectedly.
+// resubscription when the client connection is closed unexpectedly. Clients created
+// with WithReconnect resubscribe automatically instead.
 //
 // The error channel receives a value when the subscription has ended due to an error. The
 // received error is nil if Close has been called on the underlying client and no other
@@ -375,7 +379,23 @@ func (sub *ClientSubscription) unmarshal(result json.RawMessage) (interface{}, e
 	return val.Elem().Interface(), err
 }
 
+func (sub *ClientSubscription) setID(id string) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.subid = id
+}
+
+func (sub *ClientSubscription) id() string {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	return sub.subid
+}
+
 func (sub *ClientSubscription) requestUnsubscribe() error {
+	// Don't hang forever if the client is reconnecting.
+	ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+	defer cancel()
+
 	var result interface{}
-	return sub.client.Call(&result, sub.namespace+unsubscribeMethodSuffix, sub.subid)
+	return sub.client.CallContext(ctx, &result, sub.namespace+unsubscribeMethodSuffix, sub.id())
 }
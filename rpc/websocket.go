@@ -64,6 +64,7 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, wsReadLimit int64) ht
 			return
 		}
 		codec := newWebsocketCodec(conn, r.Host, r.Header, wsReadLimit)
+		codec.info.Identity = PeerIdentityFromContext(r.Context())
 		s.ServeCodec(codec, 0)
 	})
 }
@@ -290,7 +291,7 @@ type websocketCodec struct {
 	pongReceived chan struct{}
 }
 
-func newWebsocketCodec(conn *websocket.Conn, host string, req http.Header, readLimit int64) ServerCodec {
+func newWebsocketCodec(conn *websocket.Conn, host string, req http.Header, readLimit int64) *websocketCodec {
 	conn.SetReadLimit(readLimit)
 	encode := func(v interface{}, isErrorResponse bool) error {
 		return conn.WriteJSON(v)
@@ -24,6 +24,8 @@ func (s stEnv) MarshalJSON() ([]byte, error) {
 		Timestamp     math.HexOrDecimal64      `json:"currentTimestamp"     gencodec:"required"`
 		BaseFee       *math.HexOrDecimal256    `json:"currentBaseFee"       gencodec:"optional"`
 		ExcessBlobGas *math.HexOrDecimal64     `json:"currentExcessBlobGas" gencodec:"optional"`
+		L1BlockNumber *math.HexOrDecimal64     `json:"currentL1BlockNumber" gencodec:"optional"`
+		ArbOSVersion  *math.HexOrDecimal64     `json:"currentArbOSVersion"  gencodec:"optional"`
 	}
 	var enc stEnv
 	enc.Coinbase = common.UnprefixedAddress(s.Coinbase)
@@ -34,6 +36,8 @@ func (s stEnv) MarshalJSON() ([]byte, error) {
 	enc.Timestamp = math.HexOrDecimal64(s.Timestamp)
 	enc.BaseFee = (*math.HexOrDecimal256)(s.BaseFee)
 	enc.ExcessBlobGas = (*math.HexOrDecimal64)(s.ExcessBlobGas)
+	enc.L1BlockNumber = (*math.HexOrDecimal64)(s.L1BlockNumber)
+	enc.ArbOSVersion = (*math.HexOrDecimal64)(s.ArbOSVersion)
 	return json.Marshal(&enc)
 }
 
@@ -48,6 +52,8 @@ func (s *stEnv) UnmarshalJSON(input []byte) error {
 		Timestamp     *math.HexOrDecimal64      `json:"currentTimestamp"     gencodec:"required"`
 		BaseFee       *math.HexOrDecimal256     `json:"currentBaseFee"       gencodec:"optional"`
 		ExcessBlobGas *math.HexOrDecimal64      `json:"currentExcessBlobGas" gencodec:"optional"`
+		L1BlockNumber *math.HexOrDecimal64      `json:"currentL1BlockNumber" gencodec:"optional"`
+		ArbOSVersion  *math.HexOrDecimal64      `json:"currentArbOSVersion"  gencodec:"optional"`
 	}
 	var dec stEnv
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -81,5 +87,11 @@ func (s *stEnv) UnmarshalJSON(input []byte) error {
 	if dec.ExcessBlobGas != nil {
 		s.ExcessBlobGas = (*uint64)(dec.ExcessBlobGas)
 	}
+	if dec.L1BlockNumber != nil {
+		s.L1BlockNumber = (*uint64)(dec.L1BlockNumber)
+	}
+	if dec.ArbOSVersion != nil {
+		s.ArbOSVersion = (*uint64)(dec.ArbOSVersion)
+	}
 	return nil
 }
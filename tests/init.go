@@ -337,6 +337,9 @@ var Forks = map[string]*params.ChainConfig{
 		ShanghaiTime:            u64(0),
 		CancunTime:              u64(15_000),
 	},
+	// Arbitrum is the Arbitrum dev test chain. Its rules are selected by the
+	// ArbOS version, and the Kinto rules are those of mainnet.
+	"Arbitrum": params.ArbitrumDevTestChainConfig(),
 }
 
 // AvailableForks returns the set of defined fork names
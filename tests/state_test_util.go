@@ -93,6 +93,8 @@ type stEnv struct {
 	Timestamp     uint64         `json:"currentTimestamp"     gencodec:"required"`
 	BaseFee       *big.Int       `json:"currentBaseFee"       gencodec:"optional"`
 	ExcessBlobGas *uint64        `json:"currentExcessBlobGas" gencodec:"optional"`
+	L1BlockNumber *uint64        `json:"currentL1BlockNumber" gencodec:"optional"`
+	ArbOSVersion  *uint64        `json:"currentArbOSVersion"  gencodec:"optional"`
 }
 
 type stEnvMarshaling struct {
@@ -104,6 +106,19 @@ type stEnvMarshaling struct {
 	Timestamp     math.HexOrDecimal64
 	BaseFee       *math.HexOrDecimal256
 	ExcessBlobGas *math.HexOrDecimal64
+	L1BlockNumber *math.HexOrDecimal64
+	ArbOSVersion  *math.HexOrDecimal64
+}
+
+// L1BlockTxProcessor is the vm.DefaultTxProcessor of the Arbitrum chains run
+// by the tests, with the L1 block number returned by NUMBER taken from the env.
+type L1BlockTxProcessor struct {
+	vm.TxProcessingHook
+	L1Block uint64
+}
+
+func (p L1BlockTxProcessor) L1BlockNumber(blockCtx vm.BlockContext) (uint64, error) {
+	return p.L1Block, nil
 }
 
 //go:generate go run github.com/fjl/gencodec -type stTransaction -field-override stTransactionMarshaling -out gen_sttransaction.go
@@ -234,6 +249,18 @@ func (t *StateTest) RunNoVerify(subtest StateSubtest, vmconfig vm.Config, snapsh
 	}
 	vmconfig.ExtraEips = eips
 
+	// Arbitrum: the NUMBER opcode returns the L1 block number of the env, and
+	// the ArbOS version defaults to the initial one of the chain.
+	arbosVersion := uint64(0)
+	if config.IsArbitrum() {
+		if t.json.Env.L1BlockNumber == nil {
+			return state, common.Hash{}, errors.New("Arbitrum config but missing 'currentL1BlockNumber' in env section")
+		}
+		arbosVersion = config.ArbitrumChainParams.InitialArbOSVersion
+		if t.json.Env.ArbOSVersion != nil {
+			arbosVersion = *t.json.Env.ArbOSVersion
+		}
+	}
 	block := t.genesis(config).ToBlock()
 	state = MakePreState(rawdb.NewMemoryDatabase(), t.json.Pre, snapshotter, scheme)
 
@@ -289,11 +316,22 @@ func (t *StateTest) RunNoVerify(subtest StateSubtest, vmconfig vm.Config, snapsh
 		context.Random = &rnd
 		context.Difficulty = big.NewInt(0)
 	}
-	arbosVersion := types.DeserializeHeaderExtraInformation(block.Header()).ArbOSFormatVersion
+	if config.IsArbitrum() {
+		// The difficulty is the random value from the first ArbOS version on,
+		// like in core.NewEVMBlockContext
+		context.ArbOSVersion = arbosVersion
+		if arbosVersion > 0 {
+			rnd := common.BigToHash(context.Difficulty)
+			context.Random = &rnd
+		}
+	}
 	if config.IsCancun(new(big.Int), block.Time(), arbosVersion) && t.json.Env.ExcessBlobGas != nil {
 		context.BlobBaseFee = eip4844.CalcBlobFee(*t.json.Env.ExcessBlobGas)
 	}
 	evm := vm.NewEVM(context, txContext, state.StateDB, config, vmconfig)
+	if config.IsArbitrum() {
+		evm.ProcessingHook = L1BlockTxProcessor{TxProcessingHook: evm.ProcessingHook, L1Block: *t.json.Env.L1BlockNumber}
+	}
 
 	// Execute the message.
 	snapshot := state.StateDB.Snapshot()
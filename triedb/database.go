@@ -256,6 +256,29 @@ func (db *Database) Dereference(root common.Hash) error {
 	return nil
 }
 
+// SetPruneGuard installs the guard notified of the trie nodes persisted while
+// an online pruning is running, or removes it if nil. It's only supported by
+// hash-based database and will return an error for others.
+func (db *Database) SetPruneGuard(guard hashdb.PruneGuard) error {
+	hdb, ok := db.backend.(*hashdb.Database)
+	if !ok {
+		return errors.New("not supported")
+	}
+	hdb.SetPruneGuard(guard)
+	return nil
+}
+
+// ReferencedRoots returns the state roots still referenced in memory, whose
+// nodes may be partially flushed to disk. It's only supported by hash-based
+// database and will return an error for others.
+func (db *Database) ReferencedRoots() ([]common.Hash, error) {
+	hdb, ok := db.backend.(*hashdb.Database)
+	if !ok {
+		return nil, errors.New("not supported")
+	}
+	return hdb.ReferencedRoots(), nil
+}
+
 // Node retrieves the rlp-encoded node blob with provided node hash. It's
 // only supported by hash-based database and will return an error for others.
 // Note, this function should be deprecated once ETH66 is deprecated.
@@ -102,6 +102,9 @@ type Database struct {
 	dirtiesSize  common.StorageSize // Storage size of the dirty node cache (exc. metadata)
 	childrenSize common.StorageSize // Storage size of the external children tracking
 
+	guard PruneGuard          // Arbitrum: notified of the nodes persisted while an online pruning is running
+	roots map[common.Hash]int // Arbitrum: state roots referenced by the meta-root, even if already flushed
+
 	lock sync.RWMutex
 }
 
@@ -234,6 +237,9 @@ func (db *Database) Reference(child common.Hash, parent common.Hash) {
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
+	if parent == (common.Hash{}) {
+		db.referenceRoot(child)
+	}
 	db.reference(child, parent)
 }
 
@@ -272,6 +278,7 @@ func (db *Database) Dereference(root common.Hash) {
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
+	db.dereferenceRoot(root)
 	nodes, storage, start := len(db.dirties), db.dirtiesSize, time.Now()
 	db.dereference(root)
 
@@ -355,6 +362,9 @@ func (db *Database) Cap(limit common.StorageSize) error {
 	for size > limit && oldest != (common.Hash{}) {
 		// Fetch the oldest referenced node and push into the batch
 		node := db.dirties[oldest]
+		if db.guard != nil {
+			db.guard.Persisted(oldest)
+		}
 		rawdb.WriteLegacyTrieNode(batch, oldest, node.node)
 
 		// If we exceeded the ideal batch size, commit and reset
@@ -482,6 +492,9 @@ func (db *Database) commit(hash common.Hash, batch ethdb.Batch, uncacher *cleane
 		return err
 	}
 	// If we've reached an optimal batch size, commit and start over
+	if db.guard != nil {
+		db.guard.Persisted(hash)
+	}
 	rawdb.WriteLegacyTrieNode(batch, hash, node.node)
 	if batch.ValueSize() >= ethdb.IdealBatchSize {
 		if err := batch.Write(); err != nil {
@@ -0,0 +1,69 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hashdb
+
+import "github.com/ethereum/go-ethereum/common"
+
+// PruneGuard is notified of every trie node the database persists, before the
+// node is written to disk. An online pruner uses it to keep the nodes which
+// become live while it's sweeping the disk.
+type PruneGuard interface {
+	Persisted(hash common.Hash)
+}
+
+// SetPruneGuard installs the guard notified of the persisted nodes, or removes
+// it if nil.
+func (db *Database) SetPruneGuard(guard PruneGuard) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.guard = guard
+}
+
+// ReferencedRoots returns the state roots still referenced by the meta-root.
+// Part of their nodes may already have been flushed to disk by Cap, so they
+// have to be retained by an online pruner as long as they're referenced.
+func (db *Database) ReferencedRoots() []common.Hash {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	roots := make([]common.Hash, 0, len(db.roots))
+	for root := range db.roots {
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// referenceRoot tracks a reference of the meta-root to a state root. Unlike
+// the reference counter of the dirty node, it's kept after the root is flushed.
+// This function assumes the lock is already held.
+func (db *Database) referenceRoot(root common.Hash) {
+	if db.roots == nil {
+		db.roots = make(map[common.Hash]int)
+	}
+	db.roots[root]++
+}
+
+// dereferenceRoot drops a reference of the meta-root to a state root. This
+// function assumes the lock is already held.
+func (db *Database) dereferenceRoot(root common.Hash) {
+	if db.roots[root] <= 1 {
+		delete(db.roots, root)
+		return
+	}
+	db.roots[root]--
+}